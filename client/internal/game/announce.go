@@ -0,0 +1,34 @@
+package game
+
+import (
+	"image/color"
+	"time"
+
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// drawAnnouncement shows the operator's server-wide announcement as a
+// banner across the top of every screen until it expires.
+func (g *Game) drawAnnouncement(screen *ebiten.Image) {
+	if g.announcement == "" || !time.Now().Before(g.announcementUntil) {
+		return
+	}
+	msg := g.announcement
+	// one line; cut long messages to the screen width
+	if text.BoundString(basicfont.Face7x13, msg).Dx() > protocol.ScreenW-40 {
+		r := []rune(msg)
+		for len(r) > 0 && text.BoundString(basicfont.Face7x13, string(r)+"...").Dx() > protocol.ScreenW-40 {
+			r = r[:len(r)-1]
+		}
+		msg = string(r) + "..."
+	}
+	tw := text.BoundString(basicfont.Face7x13, msg).Dx()
+	y := topBarH + 4
+	ebitenutil.DrawRect(screen, 0, float64(y), float64(protocol.ScreenW), 24, color.NRGBA{120, 24, 24, 220})
+	text.Draw(screen, msg, basicfont.Face7x13, (protocol.ScreenW-tw)/2, y+16, color.NRGBA{255, 236, 180, 255})
+}
@@ -185,17 +185,16 @@ afterMessages:
 	}
 
 	if g.scr == screenBattle {
-		myHP, enemyHP := -1, -1
-		for _, b := range g.world.Bases {
-			if b.OwnerID == g.playerID {
-				myHP = b.HP
+		myHP, myMax, enemyHP, enemyMax := g.battleHPs()
+		if myMax > 0 && enemyMax > 0 {
+			if g.endlessBattle {
+				// Survival: only our base falling ends the run
+				g.endActive = myHP <= 0
+				g.endVictory = false
 			} else {
-				enemyHP = b.HP
+				g.endActive = (myHP <= 0 || enemyHP <= 0 || g.timerRemainingSeconds <= 0)
+				g.endVictory = (enemyHP <= 0 && myHP > 0) || (enemyHP <= 0 && g.timerRemainingSeconds <= 0 && myHP > 0)
 			}
-		}
-		if myHP >= 0 && enemyHP >= 0 {
-			g.endActive = (myHP <= 0 || enemyHP <= 0 || g.timerRemainingSeconds <= 0)
-			g.endVictory = (enemyHP <= 0 && myHP > 0) || (enemyHP <= 0 && g.timerRemainingSeconds <= 0 && myHP > 0)
 		} else {
 			g.endActive = false
 			g.endVictory = false
@@ -260,8 +259,10 @@ afterMessages:
 		}
 
 		if currentTime > g.lastTimerUpdate {
-			// Decrement timer by 1 second
-			if g.timerRemainingSeconds > 0 {
+			// Decrement timer by 1 second (survival counts up)
+			if g.endlessBattle {
+				g.timerRemainingSeconds++
+			} else if g.timerRemainingSeconds > 0 {
 				g.timerRemainingSeconds--
 			}
 			g.lastTimerUpdate = currentTime
@@ -512,6 +513,12 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		if g.showProfile {
 			g.drawProfileOverlay(screen)
 		}
+		g.drawCoopInvite(screen)
+		g.drawDuelInvite(screen)
+		g.drawPartyInvite(screen)
+		g.drawDraft(screen)
+		g.drawLobby(screen)
+		g.drawTournaments(screen)
 
 	case screenBattle:
 		nowMs := time.Now().UnixMilli()
@@ -522,16 +529,18 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		playerBaseCount := 0
 		enemyBaseCount := 0
 		for _, b := range g.world.Bases {
-			if b.OwnerID == g.playerID {
-				playerBaseY = float64(b.Y)
+			if g.isAlly(b.OwnerID) {
+				if b.OwnerID == g.playerID {
+					playerBaseY = float64(b.Y)
+				}
 				playerBaseCount++
 			} else {
 				enemyBaseCount++
 			}
 		}
-		// Only consider it PvP if there are exactly 2 bases AND it's actually a PvP room
-		// (PvE also has 2 bases but should not be mirrored)
-		if playerBaseCount == 1 && enemyBaseCount == 1 && strings.Contains(g.roomID, "pvp-") {
+		// Only consider it PvP if both sides have the same number of bases (1v1 or 2v2)
+		// AND it's actually a PvP room (PvE also has 2 bases but should not be mirrored)
+		if playerBaseCount >= 1 && playerBaseCount == enemyBaseCount && strings.Contains(g.roomID, "pvp-") {
 			isPvP = true
 		}
 
@@ -541,7 +550,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		// PVE should never be mirrored
 		shouldMirror := false
 		if isPvP && g.currentMapDef != nil {
-			shouldMirror = playerBaseY < float64(protocol.ScreenH)/2
+			_, worldH := g.worldSize()
+			shouldMirror = playerBaseY < worldH/2
 		}
 
 		// Draw battle arena background first
@@ -553,7 +563,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		// Helper function to mirror Y coordinate
 		mirrorY := func(y float64) float64 {
 			if shouldMirror {
-				return float64(protocol.ScreenH) - y
+				return g.mirrorWorldY(y)
 			}
 			return y
 		}
@@ -562,14 +572,14 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		for id, b := range g.world.Bases {
 			g.assets.ensureInit()
 			img := g.assets.baseEnemy
-			if b.OwnerID == g.playerID {
+			if g.isAlly(b.OwnerID) {
 				img = g.assets.baseMe
 			}
 
-			// Apply mirroring to base position for PvP (only player's own base)
+			// Apply mirroring to base position for PvP (only our side's bases)
 			renderBaseX := b.X
 			renderBaseY := b.Y
-			if shouldMirror && b.OwnerID == g.playerID {
+			if shouldMirror && g.isAlly(b.OwnerID) {
 				renderBaseY = int(mirrorY(float64(b.Y)))
 			}
 
@@ -591,7 +601,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 			if b.MaxHP > 0 {
 				fx := g.hpfxStep(g.hpFxBases, id, b.HP, nowMs)
-				isPlayer := (b.OwnerID == g.playerID)
+				isPlayer := g.isAlly(b.OwnerID)
 				x := float64(renderBaseX)
 				y := float64(renderBaseY - 6) // Fixed size, not scaled
 				w := float64(b.W)             // Fixed size, not scaled
@@ -640,7 +650,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 				renderX := currentX
 				renderY := currentY
 				if shouldMirror {
-					renderY = float64(protocol.ScreenH) - currentY
+					renderY = g.mirrorWorldY(currentY)
 				}
 
 				// Apply camera transformations
@@ -676,21 +686,24 @@ func (g *Game) Draw(screen *ebiten.Image) {
 				continue
 			}
 
-			// Apply mirroring to unit position (only player's own units)
+			// Apply mirroring to unit position (only our side's units)
 			renderX := u.X
 			renderY := u.Y
-			if shouldMirror && u.OwnerID == g.playerID {
-				renderY = float64(protocol.ScreenH) - u.Y
+			if shouldMirror && g.isAlly(u.OwnerID) {
+				renderY = g.mirrorWorldY(u.Y)
 			}
 
 			// Apply camera transformations
 			renderX = renderX*g.cameraZoom + g.cameraX
 			renderY = renderY*g.cameraZoom + g.cameraY
 
-			if img := g.ensureMiniImageByName(u.Name); img != nil {
+			if img := g.ensureUnitImage(u); img != nil {
 				op := &ebiten.DrawImageOptions{}
 				iw, ih := img.Bounds().Dx(), img.Bounds().Dy()
 				s := unitTargetPX / float64(maxInt(1, maxInt(iw, ih))) * g.cameraZoom
+				if u.Boss {
+					s *= bossScale
+				}
 
 				// Apply animation transforms if available
 				if u.AnimationData != nil {
@@ -716,7 +729,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 				ebitenutil.DrawRect(screen, renderX-3*g.cameraZoom, renderY-3*g.cameraZoom, 6*g.cameraZoom, 6*g.cameraZoom, color.NRGBA{100, 100, 100, 128})
 			}
 
-			if u.MaxHP > 0 {
+			// Bosses get the wide bar at the top of the screen instead
+			if u.MaxHP > 0 && !u.Boss {
 				lvl := g.levelForUnitName(u.Name)
 				isFullHealth := u.HP >= u.MaxHP
 
@@ -732,7 +746,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 					rx1 := int(bx + barW + 0.5)
 					ry1 := int(by + 3 + 0.5) // Fixed size, not scaled
 					barRect := image.Rect(rx0, ry0, rx1, ry1)
-					g.drawLevelBadge(screen, barRect, lvl, u.OwnerID == g.playerID)
+					g.drawLevelBadge(screen, barRect, lvl, g.isAlly(u.OwnerID))
 				} else {
 					// Damaged: Show health bar with level badge
 					barW := 26.0 * 1.05 // Fixed size, not scaled
@@ -740,23 +754,28 @@ func (g *Game) Draw(screen *ebiten.Image) {
 					by := renderY - unitTargetPX/2 - 6 // Fixed size, not scaled
 
 					fx := g.hpfxStep(g.hpFxUnits, id, u.HP, nowMs)
-					g.DrawHPBarForOwner(screen, bx, by, barW, 3, u.HP, u.MaxHP, fx.ghostHP, fx.healGhostHP, u.OwnerID == g.playerID) // Fixed size, not scaled
+					g.DrawHPBarForOwner(screen, bx, by, barW, 3, u.HP, u.MaxHP, fx.ghostHP, fx.healGhostHP, g.isAlly(u.OwnerID)) // Fixed size, not scaled
 					// Level badge left of HP bar
 					rx0 := int(bx + 0.5)
 					ry0 := int(by + 0.5)
 					rx1 := int(bx + barW + 0.5)
 					ry1 := int(by + 3 + 0.5) // Fixed size, not scaled
 					barRect := image.Rect(rx0, ry0, rx1, ry1)
-					g.drawLevelBadge(screen, barRect, lvl, u.OwnerID == g.playerID)
+					g.drawLevelBadge(screen, barRect, lvl, g.isAlly(u.OwnerID))
 				}
 			}
 		}
 
+		g.drawFog(screen)
+
 		// Draw battle UI
 		g.drawBattleBar(screen)
 
 		myCur, myMax, enCur, enMax := g.battleHPs()
 		g.drawBattleTopBars(screen, myCur, myMax, enCur, enMax)
+		g.drawBossBar(screen, nowMs)
+		g.drawSurvivalHUD(screen)
+		g.drawBattleBanner(screen)
 
 		// Draw particle effects (after UI, before victory/defeat overlay)
 		if g.particleSystem != nil {
@@ -784,7 +803,37 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		if g.endVictory || g.victory {
 			title = "Victory!"
 		}
+		if sr := g.survivalResult; sr != nil {
+			title = "Run over"
+			line := fmt.Sprintf("Waves: %d  Time: %d:%02d", sr.Waves, sr.Seconds/60, sr.Seconds%60)
+			if sr.NewBest {
+				line += "  New best!"
+			} else if sr.Best != nil {
+				line += fmt.Sprintf("  (best %d)", sr.Best.Waves)
+			}
+			text.Draw(screen, line, basicfont.Face7x13, x+120, y+40, color.NRGBA{240, 196, 25, 255})
+		}
+		if cr := g.challengeResult; cr != nil {
+			line := "Challenge failed"
+			if cr.Won {
+				line = fmt.Sprintf("Cleared in %d:%02d", cr.Seconds/60, cr.Seconds%60)
+				if cr.NewBest {
+					line += "  New best!"
+				}
+			}
+			if b := cr.Best; b != nil && !cr.NewBest {
+				line += fmt.Sprintf("  (best %d:%02d)", b.Seconds/60, b.Seconds%60)
+			}
+			text.Draw(screen, line, basicfont.Face7x13, x+120, y+40, color.NRGBA{240, 196, 25, 255})
+		}
 		text.Draw(screen, title, basicfont.Face7x13, x+20, y+40, color.White)
+		if cr := g.campaignResult; cr != nil {
+			line := fmt.Sprintf("Stars: %d/3 (best %d)", cr.Stars, cr.BestStars)
+			if cr.FirstClear {
+				line += fmt.Sprintf("  First clear! +%d gold", cr.RewardGold)
+			}
+			text.Draw(screen, line, basicfont.Face7x13, x+120, y+40, color.NRGBA{240, 196, 25, 255})
+		}
 
 		// XP gains list (if computed)
 		if g.xpGains != nil {
@@ -852,8 +901,10 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 			mx, my := ebiten.CursorPosition()
 			if g.continueBtn.hit(mx, my) {
-				g.onLeaveRoom()
-				g.roomID = ""
+				if g.lobby == nil { // lobby rooms stay open for a rematch
+					g.onLeaveRoom()
+					g.roomID = ""
+				}
 				g.scr = screenHome
 
 				g.endActive = false
@@ -872,6 +923,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		}
 
 	}
+
+	g.drawAnnouncement(screen)
 }
 
 func (g *Game) Layout(w, h int) (int, int) { return protocol.ScreenW, protocol.ScreenH }
@@ -1426,7 +1479,8 @@ func (g *Game) findTargetForUnit(u *RenderUnit) (float64, float64) {
 			return float64(b.X + b.W/2), float64(b.Y + b.H/2)
 		}
 	}
-	return float64(protocol.ScreenW / 2), float64(protocol.ScreenH / 2)
+	w, h := g.worldSize()
+	return w / 2, h / 2
 }
 
 // drawMirroredParticles draws particle effects with Y-axis mirroring for PvP
@@ -1536,14 +1590,15 @@ func (g *Game) drawDeployZones(screen *ebiten.Image, shouldMirror bool) {
 		}
 
 		// Convert normalized coordinates to screen coordinates
-		x := zone.X * float64(protocol.ScreenW)
-		y := zone.Y * float64(protocol.ScreenH)
-		w := zone.W * float64(protocol.ScreenW)
-		h := zone.H * float64(protocol.ScreenH)
+		worldW, worldH := g.worldSize()
+		x := zone.X * worldW
+		y := zone.Y * worldH
+		w := zone.W * worldW
+		h := zone.H * worldH
 
 		// Apply mirroring if needed
 		if shouldMirror {
-			y = float64(protocol.ScreenH) - y - h
+			y = g.mirrorWorldY(y) - h
 		}
 
 		// Apply camera transformations
@@ -1575,14 +1630,14 @@ func (g *Game) isPvPMode() bool {
 	playerBaseCount := 0
 	enemyBaseCount := 0
 	for _, b := range g.world.Bases {
-		if b.OwnerID == g.playerID {
+		if g.isAlly(b.OwnerID) {
 			playerBaseCount++
 		} else {
 			enemyBaseCount++
 		}
 	}
-	// PvP has exactly 2 bases (1 player + 1 enemy) AND it's a PvP room
-	return playerBaseCount == 1 && enemyBaseCount == 1 && strings.Contains(g.roomID, "pvp-")
+	// PvP has one base per player on each side (1v1 or 2v2) AND it's a PvP room
+	return playerBaseCount >= 1 && playerBaseCount == enemyBaseCount && strings.Contains(g.roomID, "pvp-")
 }
 
 // drawObstacles draws obstacles from the current map definition
@@ -1593,10 +1648,11 @@ func (g *Game) drawObstacles(screen *ebiten.Image, shouldMirror bool, mirrorY fu
 
 	for _, obstacle := range g.currentMapDef.Obstacles {
 		// Convert normalized coordinates to screen coordinates
-		x := obstacle.X * float64(protocol.ScreenW)
-		y := obstacle.Y * float64(protocol.ScreenH)
-		w := obstacle.Width * float64(protocol.ScreenW)
-		h := obstacle.Height * float64(protocol.ScreenH)
+		worldW, worldH := g.worldSize()
+		x := obstacle.X * worldW
+		y := obstacle.Y * worldH
+		w := obstacle.Width * worldW
+		h := obstacle.Height * worldH
 
 		// Apply mirroring if needed
 		if shouldMirror {
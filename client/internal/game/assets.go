@@ -145,6 +145,20 @@ func (g *Game) ensureMiniImageByName(name string) *ebiten.Image {
 	return img
 }
 
+// ensureUnitImage prefers the server-provided portrait (bosses) over the name lookup.
+func (g *Game) ensureUnitImage(u *RenderUnit) *ebiten.Image {
+	if u.Portrait == "" {
+		return g.ensureMiniImageByName(u.Name)
+	}
+	g.assets.ensureInit()
+	if img, ok := g.assets.minis[u.Portrait]; ok {
+		return img
+	}
+	img := loadImage("assets/minis/" + u.Portrait)
+	g.assets.minis[u.Portrait] = img
+	return img
+}
+
 func (g *Game) ensureObstacleImage(obstacleType string) *ebiten.Image {
 	g.assets.ensureInit()
 	if img, ok := g.assets.obstacles[obstacleType]; ok {
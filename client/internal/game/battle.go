@@ -49,29 +49,10 @@ func (g *Game) updateBattle() {
 				cx, cy := ebiten.CursorPosition()
 				deltaX := cx - g.cameraDragStartX
 				deltaY := cy - g.cameraDragStartY
-				newCameraX := g.cameraDragInitialX + float64(deltaX)
-				newCameraY := g.cameraDragInitialY + float64(deltaY)
-
-				// Apply same 20% boundary limits as edge scrolling
-				mapWidth := float64(protocol.ScreenW) * g.cameraZoom
-				mapHeight := float64(protocol.ScreenH) * g.cameraZoom
-				maxScrollX := mapWidth * 0.2  // 20% outside left/right borders
-				maxScrollY := mapHeight * 0.2 // 20% outside top/bottom borders
-
-				// Clamp camera position within boundaries
-				if newCameraX > maxScrollX {
-					newCameraX = maxScrollX
-				} else if newCameraX < -maxScrollX {
-					newCameraX = -maxScrollX
-				}
-				if newCameraY > maxScrollY {
-					newCameraY = maxScrollY
-				} else if newCameraY < -maxScrollY {
-					newCameraY = -maxScrollY
-				}
-
-				g.cameraX = newCameraX
-				g.cameraY = newCameraY
+				// Same boundary limits as edge scrolling
+				g.cameraX = g.cameraDragInitialX + float64(deltaX)
+				g.cameraY = g.cameraDragInitialY + float64(deltaY)
+				g.clampCamera()
 			}
 		} else {
 			g.cameraDragging = false
@@ -87,29 +68,10 @@ func (g *Game) updateBattle() {
 				cx, cy := ebiten.CursorPosition()
 				deltaX := cx - g.cameraLeftDragStartX
 				deltaY := cy - g.cameraLeftDragStartY
-				newCameraX := g.cameraLeftDragInitialX + float64(deltaX)
-				newCameraY := g.cameraLeftDragInitialY + float64(deltaY)
-
-				// Apply same 20% boundary limits as edge scrolling
-				mapWidth := float64(protocol.ScreenW) * g.cameraZoom
-				mapHeight := float64(protocol.ScreenH) * g.cameraZoom
-				maxScrollX := mapWidth * 0.2  // 20% outside left/right borders
-				maxScrollY := mapHeight * 0.2 // 20% outside top/bottom borders
-
-				// Clamp camera position within boundaries
-				if newCameraX > maxScrollX {
-					newCameraX = maxScrollX
-				} else if newCameraX < -maxScrollX {
-					newCameraX = -maxScrollX
-				}
-				if newCameraY > maxScrollY {
-					newCameraY = maxScrollY
-				} else if newCameraY < -maxScrollY {
-					newCameraY = -maxScrollY
-				}
-
-				g.cameraX = newCameraX
-				g.cameraY = newCameraY
+				// Same boundary limits as edge scrolling
+				g.cameraX = g.cameraLeftDragInitialX + float64(deltaX)
+				g.cameraY = g.cameraLeftDragInitialY + float64(deltaY)
+				g.clampCamera()
 			}
 		} else {
 			g.cameraLeftDragging = false
@@ -121,26 +83,23 @@ func (g *Game) updateBattle() {
 			const edgeThreshold = 50 // pixels from edge to trigger scrolling
 			const scrollSpeed = 8.0  // pixels per frame
 
-			// Calculate map boundaries (stop scrolling when we would see more than 20% outside)
-			mapWidth := float64(protocol.ScreenW) * g.cameraZoom
-			mapHeight := float64(protocol.ScreenH) * g.cameraZoom
-			maxScrollX := mapWidth * 0.2  // 20% outside left/right borders
-			maxScrollY := mapHeight * 0.2 // 20% outside top/bottom borders
+			// Map boundaries (stop scrolling when we would see more than 20% outside)
+			minX, maxX, minY, maxY := g.cameraBounds()
 
-			// Left edge - scroll right to see more left side (stop before exceeding 20%)
-			if mx < edgeThreshold && g.cameraX < maxScrollX {
+			// Left edge - scroll right to see more left side
+			if mx < edgeThreshold && g.cameraX < maxX {
 				g.cameraX += scrollSpeed
 			}
-			// Right edge - scroll left to see more right side (stop before exceeding 20%)
-			if mx > protocol.ScreenW-edgeThreshold && g.cameraX > -maxScrollX {
+			// Right edge - scroll left to see more right side
+			if mx > protocol.ScreenW-edgeThreshold && g.cameraX > minX {
 				g.cameraX -= scrollSpeed
 			}
-			// Top edge - scroll down to see more top side (stop before exceeding 20%)
-			if my < edgeThreshold && g.cameraY < maxScrollY {
+			// Top edge - scroll down to see more top side
+			if my < edgeThreshold && g.cameraY < maxY {
 				g.cameraY += scrollSpeed
 			}
-			// Bottom edge (accounting for UI) - scroll up to see more bottom side (stop before exceeding 20%)
-			if my > protocol.ScreenH-battleHUDH-edgeThreshold && g.cameraY > -maxScrollY {
+			// Bottom edge (accounting for UI) - scroll up to see more bottom side
+			if my > protocol.ScreenH-battleHUDH-edgeThreshold && g.cameraY > minY {
 				g.cameraY -= scrollSpeed
 			}
 		}
@@ -161,8 +120,10 @@ func (g *Game) updateBattle() {
 		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 			mx, my := ebiten.CursorPosition()
 			if g.continueBtn.hit(mx, my) {
-				g.onLeaveRoom()
-				g.roomID = ""
+				if g.lobby == nil { // lobby rooms stay open for a rematch
+					g.onLeaveRoom()
+					g.roomID = ""
+				}
 				g.scr = screenHome
 
 				g.endActive = false
@@ -188,11 +149,15 @@ func (g *Game) updateBattle() {
 		return
 	}
 
+	if g.spectating != "" {
+		return // watch-party spectators cannot deploy
+	}
+
 	mx, my := ebiten.CursorPosition()
 	handTop := protocol.ScreenH - battleHUDH
 
-	// Use raw mouse coordinates for deployment (server handles logical positioning)
-	deployX, deployY := float64(mx), float64(my)
+	// Deploys are sent in world units; the camera and mirroring are undone here
+	deployX, deployY := g.screenToWorld(float64(mx), float64(my))
 
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		// Check if clicking on a hand card
@@ -262,7 +227,7 @@ func (g *Game) drawBattleBar(screen *ebiten.Image) {
 	g.assets.ensureInit()
 	cx := 16
 	cy := y + 20
-	for i := 0; i < protocol.GoldMax; i++ {
+	for i := 0; i < g.goldMax(); i++ {
 		img := g.assets.coinEmpty
 		if i < g.gold {
 			img = g.assets.coinFull
@@ -279,7 +244,7 @@ func (g *Game) drawBattleBar(screen *ebiten.Image) {
 		}
 		cx += 24
 	}
-	text.Draw(screen, fmt.Sprintf("%d/%d", g.gold, protocol.GoldMax), basicfont.Face7x13, cx+8, cy+15, color.NRGBA{239, 229, 182, 255})
+	text.Draw(screen, fmt.Sprintf("%d/%d", g.gold, g.goldMax()), basicfont.Face7x13, cx+8, cy+15, color.NRGBA{239, 229, 182, 255})
 
 	slots := g.handRects()
 	for i, r := range slots {
@@ -348,29 +313,16 @@ func (g *Game) drawBattleBar(screen *ebiten.Image) {
 	}
 }
 
-// isInDeployZone checks if a given point (x, y) is within any deploy zone
+// isInDeployZone checks if a world position (x, y) is within any deploy zone
 func (g *Game) isInDeployZone(x, y float64) bool {
 	if g.currentMapDef == nil {
 		return true // Allow deployment anywhere if no map definition
 	}
 
-	// Check if PvP mirroring is active
-	shouldMirror := g.shouldMirrorForPvp()
-
-	// Convert screen coordinates to world coordinates (inverse camera transformation)
-	// World coordinates = (screen coordinates - camera offset) / camera zoom
-	worldX := (x - g.cameraX) / g.cameraZoom
-	worldY := (y - g.cameraY) / g.cameraZoom
-
-	// If mirroring is active, apply inverse mirroring to the world coordinates
-	if shouldMirror {
-		// Apply inverse mirroring to the world coordinates
-		worldY = float64(protocol.ScreenH) - worldY
-	}
-
 	// Convert world coordinates to normalized coordinates (0-1)
-	normX := worldX / float64(protocol.ScreenW)
-	normY := worldY / float64(protocol.ScreenH)
+	w, h := g.worldSize()
+	normX := x / w
+	normY := y / h
 
 	// Check if point is within any deploy zone
 	for _, zone := range g.currentMapDef.DeployZones {
@@ -682,16 +634,18 @@ func (g *Game) shouldMirrorForPvp() bool {
 	playerBaseCount := 0
 	enemyBaseCount := 0
 	for _, b := range g.world.Bases {
-		if b.OwnerID == g.playerID {
-			playerBaseY = float64(b.Y)
+		if g.isAlly(b.OwnerID) {
+			if b.OwnerID == g.playerID {
+				playerBaseY = float64(b.Y)
+			}
 			playerBaseCount++
 		} else {
 			enemyBaseCount++
 		}
 	}
-	// Only consider it PvP if there are exactly 2 bases AND it's actually a PvP room
-	// (PvE also has 2 bases but should not be mirrored)
-	if playerBaseCount == 1 && enemyBaseCount == 1 && strings.Contains(g.roomID, "pvp-") {
+	// Only consider it PvP if both sides have the same number of bases (1v1 or 2v2)
+	// AND it's actually a PvP room (PvE also has 2 bases but should not be mirrored)
+	if playerBaseCount >= 1 && playerBaseCount == enemyBaseCount && strings.Contains(g.roomID, "pvp-") {
 		isPvP = true
 	}
 
@@ -703,23 +657,30 @@ func (g *Game) shouldMirrorForPvp() bool {
 
 	// PvP Mirroring: Ensure both players see their base at bottom
 	// Mirror if player's base is at the TOP (needs to be moved to bottom)
-	return playerBaseY < float64(protocol.ScreenH)/2
+	_, h := g.worldSize()
+	return playerBaseY < h/2
 }
 
 // battleHPs returns player and enemy HP values for battle UI
+// (summed per side, so co-op partners share the top bar)
 func (g *Game) battleHPs() (myCur, myMax, enCur, enMax int) {
 	for _, b := range g.world.Bases {
-		if b.OwnerID == g.playerID {
-			myCur = b.HP
-			myMax = b.MaxHP
+		if g.isAlly(b.OwnerID) {
+			myCur += b.HP
+			myMax += b.MaxHP
 		} else {
-			enCur = b.HP
-			enMax = b.MaxHP
+			enCur += b.HP
+			enMax += b.MaxHP
 		}
 	}
 	return
 }
 
+// isAlly reports whether ownerID fights on our side (us or a co-op partner).
+func (g *Game) isAlly(ownerID int64) bool {
+	return ownerID == g.playerID || g.allies[ownerID]
+}
+
 // drawBattleTopBars draws the HP bars and timer at the top of the battle screen
 func (g *Game) drawBattleTopBars(screen *ebiten.Image, myCur, myMax, enCur, enMax int) {
 	const pad = 12
@@ -897,3 +858,61 @@ func (g *Game) drawBattleTopBars(screen *ebiten.Image, myCur, myMax, enCur, enMa
 		text.Draw(screen, "SURRENDER", basicfont.Face7x13, surrenderBtnX+60, surrenderBtnY+25, color.NRGBA{239, 229, 182, 255})
 	}
 }
+
+// bossScale enlarges boss sprites relative to regular minis.
+const bossScale = 2.0
+
+// drawBossBar draws a wide HP bar for the first living boss below the top bars.
+func (g *Game) drawBossBar(screen *ebiten.Image, nowMs int64) {
+	if g.world == nil {
+		return
+	}
+	var id int64
+	var boss *RenderUnit
+	for uid, u := range g.world.Units {
+		if u.Boss && u.HP > 0 && (boss == nil || uid < id) {
+			id, boss = uid, u
+		}
+	}
+	if boss != nil && boss.MaxHP > 0 {
+		w := float64(protocol.ScreenW) * 0.5
+		x := (float64(protocol.ScreenW) - w) / 2
+		y := 54.0
+		fx := g.hpfxStep(g.hpFxUnits, id, boss.HP, nowMs)
+		ebitenutil.DrawRect(screen, x-1, y-1, w+2, 10+2, color.NRGBA{0, 0, 0, 255})
+		g.DrawHPBarForOwner(screen, x, y, w, 10, boss.HP, boss.MaxHP, fx.ghostHP, fx.healGhostHP, g.isAlly(boss.OwnerID))
+
+		label := boss.Name
+		if boss.Phase > 0 {
+			label = fmt.Sprintf("%s - Phase %d: %s", boss.Name, boss.Phase, boss.PhaseName)
+		}
+		tw := text.BoundString(basicfont.Face7x13, label).Dx()
+		text.Draw(screen, label, basicfont.Face7x13, int(x+(w-float64(tw))/2), int(y)+24, color.White)
+	}
+}
+
+// drawSurvivalHUD shows the current wave and the countdown to the next one.
+func (g *Game) drawSurvivalHUD(screen *ebiten.Image) {
+	if g.survivalWave <= 0 || g.gameOver || g.endActive {
+		return
+	}
+	label := fmt.Sprintf("Wave %d", g.survivalWave)
+	if left := time.Until(g.survivalNextAt); left > 0 {
+		label += fmt.Sprintf("  -  next in %ds", int(left.Seconds()+0.5))
+	}
+	tw := text.BoundString(basicfont.Face7x13, label).Dx()
+	x := (protocol.ScreenW - tw) / 2
+	ebitenutil.DrawRect(screen, float64(x-8), 50, float64(tw+16), 20, color.NRGBA{0, 0, 0, 160})
+	text.Draw(screen, label, basicfont.Face7x13, x, 64, color.NRGBA{240, 196, 25, 255})
+}
+
+// drawBattleBanner shows the short-lived centre-screen banner.
+func (g *Game) drawBattleBanner(screen *ebiten.Image) {
+	if g.battleBanner != "" && time.Now().Before(g.battleBannerUntil) {
+		tw := text.BoundString(basicfont.Face7x13, g.battleBanner).Dx()
+		bx := (protocol.ScreenW - tw) / 2
+		by := protocol.ScreenH / 3
+		ebitenutil.DrawRect(screen, float64(bx-12), float64(by-18), float64(tw+24), 28, color.NRGBA{0, 0, 0, 180})
+		text.Draw(screen, g.battleBanner, basicfont.Face7x13, bx, by, color.NRGBA{240, 196, 25, 255})
+	}
+}
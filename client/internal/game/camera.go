@@ -0,0 +1,67 @@
+package game
+
+import (
+	"math"
+
+	"rumble/shared/protocol"
+)
+
+// Battle camera. The server simulates in world units sized by the map
+// (Init.MapWidth/MapHeight); the client projects them to screen pixels as
+// world*zoom + camera offset. Worlds larger than the default one scroll.
+
+// worldSize is the current battlefield size in world units.
+func (g *Game) worldSize() (w, h float64) {
+	w, h = g.worldW, g.worldH
+	if w <= 0 {
+		w = protocol.DefaultWorldW
+	}
+	if h <= 0 {
+		h = protocol.DefaultWorldH
+	}
+	return w, h
+}
+
+// setWorldSize records the battlefield size from Init.
+func (g *Game) setWorldSize(w, h int) {
+	g.worldW, g.worldH = float64(w), float64(h)
+	if g.world != nil {
+		g.world.W, g.world.H = g.worldSize()
+	}
+}
+
+// mirrorWorldY flips a world Y for the mirrored PvP view.
+func (g *Game) mirrorWorldY(y float64) float64 {
+	_, h := g.worldSize()
+	return h - y
+}
+
+// screenToWorld turns a cursor position into world units, undoing the camera
+// and, for a mirrored PvP view, the mirroring.
+func (g *Game) screenToWorld(sx, sy float64) (float64, float64) {
+	x := (sx - g.cameraX) / g.cameraZoom
+	y := (sy - g.cameraY) / g.cameraZoom
+	if g.shouldMirrorForPvp() {
+		y = g.mirrorWorldY(y)
+	}
+	return x, y
+}
+
+// cameraBounds is the allowed camera offset range: up to 20% of the screen
+// past each border, plus however much the world is larger than the default
+// one so its far side can be scrolled into view.
+func (g *Game) cameraBounds() (minX, maxX, minY, maxY float64) {
+	w, h := g.worldSize()
+	mx := float64(protocol.ScreenW) * g.cameraZoom * 0.2
+	my := float64(protocol.ScreenH) * g.cameraZoom * 0.2
+	ex := math.Max(0, (w-protocol.DefaultWorldW)*g.cameraZoom)
+	ey := math.Max(0, (h-protocol.DefaultWorldH)*g.cameraZoom)
+	return -mx - ex, mx, -my - ey, my
+}
+
+// clampCamera keeps the camera offset within cameraBounds.
+func (g *Game) clampCamera() {
+	minX, maxX, minY, maxY := g.cameraBounds()
+	g.cameraX = math.Max(minX, math.Min(maxX, g.cameraX))
+	g.cameraY = math.Max(minY, math.Min(maxY, g.cameraY))
+}
@@ -81,6 +81,10 @@ func (g *Game) resetToLogin() {
 
 	g.pvpQueued = false
 	g.pvpHosting = false
+	g.teamQueued, g.teamLobby = false, nil
+	g.draftQueued, g.draft = false, nil
+	g.lobby = nil
+	g.party, g.partyInviteFrom, g.spectating = nil, "", ""
 	g.pvpCode = ""
 	g.pvpCodeInput = ""
 	g.pvpStatus = "Logged out."
@@ -164,6 +168,10 @@ drained:
 	g.lastLobbyReq = time.Time{}
 
 	g.pvpQueued, g.pvpHosting = false, false
+	g.teamQueued, g.teamLobby = false, nil
+	g.draftQueued, g.draft = false, nil
+	g.lobby = nil
+	g.party, g.partyInviteFrom, g.spectating = nil, "", ""
 	g.pvpCode, g.pvpStatus, g.pvpCodeInput = "", "", ""
 	g.hoveredHS, g.selectedHS = -1, -1
 
@@ -0,0 +1,58 @@
+package game
+
+import (
+	"image/color"
+	"time"
+
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+func (g *Game) setCoopNote(msg string) {
+	g.coopNote = msg
+	g.coopNoteUntil = time.Now().Add(4 * time.Second)
+}
+
+// drawCoopInvite shows a pending co-op invite with Accept/Decline buttons,
+// or the last invite status line. Input is handled inline like the other overlays.
+func (g *Game) drawCoopInvite(screen *ebiten.Image) {
+	w, h := 340, 74
+	x := (protocol.ScreenW - w) / 2
+	y := topBarH + 8
+
+	if g.coopInviteFrom == "" {
+		if g.coopNote != "" && time.Now().Before(g.coopNoteUntil) {
+			tw := text.BoundString(basicfont.Face7x13, g.coopNote).Dx()
+			ebitenutil.DrawRect(screen, float64((protocol.ScreenW-tw)/2-10), float64(y), float64(tw+20), 24, color.NRGBA{30, 30, 45, 230})
+			text.Draw(screen, g.coopNote, basicfont.Face7x13, (protocol.ScreenW-tw)/2, y+16, color.White)
+		}
+		return
+	}
+
+	ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), float64(h), color.NRGBA{30, 30, 45, 240})
+	text.Draw(screen, g.coopInviteFrom+" invites you to co-op", basicfont.Face7x13, x+12, y+18, color.White)
+	text.Draw(screen, "Map: "+g.coopInviteMap, basicfont.Face7x13, x+12, y+34, color.NRGBA{240, 196, 25, 255})
+
+	accept := rect{x: x + 12, y: y + 44, w: 90, h: 22}
+	decline := rect{x: x + 112, y: y + 44, w: 90, h: 22}
+	ebitenutil.DrawRect(screen, float64(accept.x), float64(accept.y), float64(accept.w), float64(accept.h), color.NRGBA{70, 110, 70, 255})
+	ebitenutil.DrawRect(screen, float64(decline.x), float64(decline.y), float64(decline.w), float64(decline.h), color.NRGBA{110, 70, 70, 255})
+	text.Draw(screen, "Accept", basicfont.Face7x13, accept.x+22, accept.y+15, color.White)
+	text.Draw(screen, "Decline", basicfont.Face7x13, decline.x+18, decline.y+15, color.White)
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		mx, my := g.logicalCursor()
+		if accept.hit(mx, my) {
+			g.send("CoopAccept", protocol.CoopAccept{From: g.coopInviteFrom})
+			g.coopInviteFrom = ""
+		} else if decline.hit(mx, my) {
+			g.send("CoopDecline", protocol.CoopDecline{From: g.coopInviteFrom})
+			g.coopInviteFrom = ""
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	draftCols  = 4
+	draftCardH = 64
+)
+
+// draftLayout places the draft entry buttons next to the 1v1 queue and
+// friendly code buttons on the PvP tab.
+func (g *Game) draftLayout() (queueBtn, codeBtn rect) {
+	q, _, createBtn, _, _, _ := g.pvpLayout()
+	queueBtn = rect{x: q.x + q.w + 8, y: q.y, w: 160, h: q.h}
+	codeBtn = rect{x: createBtn.x + createBtn.w + 8, y: createBtn.y, w: 90, h: createBtn.h}
+	return
+}
+
+func (g *Game) drawDraftButtons(screen *ebiten.Image) {
+	queueBtn, codeBtn := g.draftLayout()
+	mx, my := ebiten.CursorPosition()
+	label := "Queue Draft"
+	if g.draftQueued {
+		label = "Leave Draft Queue"
+	}
+	btns := []struct {
+		r     rect
+		label string
+		show  bool
+	}{
+		{queueBtn, label, true},
+		{codeBtn, "Draft Code", !g.pvpHosting},
+	}
+	for _, b := range btns {
+		if !b.show {
+			continue
+		}
+		if g.fantasyUI != nil {
+			state := ButtonNormal
+			if b.r.hit(mx, my) {
+				state = ButtonHover
+			}
+			g.fantasyUI.DrawThemedButtonWithStyle(screen, b.r.x, b.r.y, b.r.w, b.r.h, b.label, state, true)
+		} else {
+			ebitenutil.DrawRect(screen, float64(b.r.x), float64(b.r.y), float64(b.r.w), float64(b.r.h), color.NRGBA{60, 60, 80, 255})
+			text.Draw(screen, b.label, basicfont.Face7x13, b.r.x+8, b.r.y+18, color.White)
+		}
+	}
+}
+
+// updateDraftButtons handles the PvP tab draft buttons; it reports whether
+// the click was consumed.
+func (g *Game) updateDraftButtons(mx, my int) bool {
+	queueBtn, codeBtn := g.draftLayout()
+	switch {
+	case queueBtn.hit(mx, my):
+		if g.draftQueued {
+			g.draftQueued = false
+			g.pvpStatus = "Left draft queue."
+			g.send("LeaveDraftQueue", protocol.LeaveDraftQueue{})
+		} else {
+			g.draftQueued = true
+			g.pvpStatus = "Queueing for a draft match…"
+			g.send("JoinDraftQueue", protocol.JoinDraftQueue{})
+		}
+	case !g.pvpHosting && codeBtn.hit(mx, my):
+		g.pvpHosting = true
+		g.pvpCode = ""
+		g.pvpStatus = "Requesting draft duel code…"
+		g.send("FriendlyCreate", protocol.FriendlyCreate{Draft: true, Rules: g.friendlyRulesID()})
+	default:
+		return false
+	}
+	return true
+}
+
+// ---- Draft overlay (replaces the home screen while a draft runs)
+
+func (g *Game) draftCardRect(i int) rect {
+	w := (protocol.ScreenW - 2*pad - (draftCols-1)*8) / draftCols
+	return rect{
+		x: pad + (i%draftCols)*(w+8),
+		y: topBarH + 70 + (i/draftCols)*(draftCardH+6),
+		w: w,
+		h: draftCardH,
+	}
+}
+
+func (g *Game) draftSkipRect() rect {
+	rows := (len(g.draft.Pool) + draftCols - 1) / draftCols
+	last := g.draftCardRect((rows - 1) * draftCols)
+	return rect{x: pad, y: last.y + last.h + 12, w: 120, h: 26}
+}
+
+func draftHas(list []string, name string) bool {
+	for _, n := range list {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) draftSecondsLeft() int {
+	left := int(time.Until(g.draftDeadline).Seconds() + 0.999)
+	if left < 0 {
+		left = 0
+	}
+	return left
+}
+
+func (g *Game) drawDraft(screen *ebiten.Image) {
+	d := g.draft
+	if d == nil {
+		return
+	}
+	ebitenutil.DrawRect(screen, 0, float64(topBarH), float64(protocol.ScreenW), float64(protocol.ScreenH-topBarH), color.NRGBA{20, 20, 30, 250})
+	text.Draw(screen, "Draft vs "+d.OppName, basicfont.Face7x13, pad, topBarH+22, color.NRGBA{240, 196, 25, 255})
+
+	var status string
+	switch {
+	case d.Action == "done":
+		status = "Draft complete — starting match…"
+	case d.YourTurn && d.Action == "ban":
+		status = fmt.Sprintf("Your turn: ban a card (%ds)", g.draftSecondsLeft())
+	case d.YourTurn:
+		status = fmt.Sprintf("Your turn: pick a card (%ds)", g.draftSecondsLeft())
+	default:
+		status = fmt.Sprintf("Waiting for %s to %s (%ds)", d.OppName, d.Action, g.draftSecondsLeft())
+	}
+	text.Draw(screen, status, basicfont.Face7x13, pad, topBarH+42, color.White)
+	text.Draw(screen, fmt.Sprintf("Step %d/%d", minInt(d.Step+1, d.Steps), d.Steps), basicfont.Face7x13, protocol.ScreenW-pad-80, topBarH+22, color.NRGBA{170, 170, 190, 255})
+
+	mx, my := ebiten.CursorPosition()
+	for i, c := range d.Pool {
+		r := g.draftCardRect(i)
+		bg := color.NRGBA{40, 40, 58, 255}
+		tag := ""
+		switch {
+		case draftHas(d.YourPicks, c.Name):
+			bg, tag = color.NRGBA{50, 90, 55, 255}, "YOURS"
+		case draftHas(d.OppPicks, c.Name):
+			bg, tag = color.NRGBA{95, 50, 50, 255}, "THEIRS"
+		case draftHas(d.Banned, c.Name):
+			bg, tag = color.NRGBA{30, 30, 34, 255}, "BANNED"
+		case d.YourTurn && r.hit(mx, my):
+			bg = color.NRGBA{70, 70, 100, 255}
+		}
+		ebitenutil.DrawRect(screen, float64(r.x), float64(r.y), float64(r.w), float64(r.h), bg)
+		if img := g.ensureMiniImageByName(c.Name); img != nil {
+			op := &ebiten.DrawImageOptions{}
+			iw, ih := img.Bounds().Dx(), img.Bounds().Dy()
+			s := 48 / float64(maxInt(1, maxInt(iw, ih)))
+			op.GeoM.Scale(s, s)
+			op.GeoM.Translate(float64(r.x+6), float64(r.y+8))
+			screen.DrawImage(img, op)
+		}
+		text.Draw(screen, safeTrim(c.Name, 11), basicfont.Face7x13, r.x+58, r.y+18, color.White)
+		kind := c.Class
+		if strings.EqualFold(c.Role, "champion") {
+			kind = "champion"
+		}
+		text.Draw(screen, fmt.Sprintf("%s %d", safeTrim(kind, 8), c.Cost), basicfont.Face7x13, r.x+58, r.y+36, color.NRGBA{170, 170, 190, 255})
+		if tag != "" {
+			text.Draw(screen, tag, basicfont.Face7x13, r.x+58, r.y+54, color.NRGBA{240, 196, 25, 255})
+		}
+	}
+
+	skip := g.draftSkipRect()
+	if d.YourTurn && d.Action == "ban" {
+		ebitenutil.DrawRect(screen, float64(skip.x), float64(skip.y), float64(skip.w), float64(skip.h), color.NRGBA{90, 70, 70, 255})
+		text.Draw(screen, "Skip ban", basicfont.Face7x13, skip.x+30, skip.y+18, color.White)
+	}
+
+	// Armies so far
+	y := skip.y + skip.h + 28
+	text.Draw(screen, "Your army", basicfont.Face7x13, pad, y, color.NRGBA{240, 196, 25, 255})
+	text.Draw(screen, d.OppName+"'s army", basicfont.Face7x13, protocol.ScreenW/2, y, color.NRGBA{240, 196, 25, 255})
+	for i, n := range d.YourPicks {
+		text.Draw(screen, n, basicfont.Face7x13, pad, y+18+16*i, color.White)
+	}
+	for i, n := range d.OppPicks {
+		text.Draw(screen, n, basicfont.Face7x13, protocol.ScreenW/2, y+18+16*i, color.White)
+	}
+}
+
+// updateDraft handles clicks on the draft overlay.
+func (g *Game) updateDraft(mx, my int) {
+	d := g.draft
+	if d == nil || !d.YourTurn || d.Action == "done" || !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return
+	}
+	if d.Action == "ban" && g.draftSkipRect().hit(mx, my) {
+		g.send("DraftBan", protocol.DraftBan{})
+		return
+	}
+	for i, c := range d.Pool {
+		if !g.draftCardRect(i).hit(mx, my) {
+			continue
+		}
+		if draftHas(d.YourPicks, c.Name) || draftHas(d.OppPicks, c.Name) || draftHas(d.Banned, c.Name) {
+			return
+		}
+		if d.Action == "ban" {
+			g.send("DraftBan", protocol.DraftBan{Name: c.Name})
+		} else {
+			g.send("DraftPick", protocol.DraftPick{Name: c.Name})
+		}
+		return
+	}
+}
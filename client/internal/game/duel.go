@@ -0,0 +1,61 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// drawDuelInvite shows a pending duel invite with Accept/Decline buttons
+// (below a co-op invite if both are open). The invite hides itself once it
+// expires.
+func (g *Game) drawDuelInvite(screen *ebiten.Image) {
+	if g.duelInviteFrom == "" {
+		return
+	}
+	left := int(time.Until(g.duelInviteUntil).Seconds())
+	if left <= 0 {
+		g.duelInviteFrom = ""
+		return
+	}
+
+	w, h := 340, 74
+	x := (protocol.ScreenW - w) / 2
+	y := topBarH + 8
+	if g.coopInviteFrom != "" {
+		y += h + 8
+	}
+	ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), float64(h), color.NRGBA{30, 30, 45, 240})
+	who := "Your friend "
+	if g.duelInviteVia == "guild" {
+		who = "Guildmate "
+	}
+	text.Draw(screen, who+g.duelInviteFrom+" challenges you", basicfont.Face7x13, x+12, y+18, color.White)
+	text.Draw(screen, fmt.Sprintf("Friendly duel - expires in %ds", left), basicfont.Face7x13, x+12, y+34, color.NRGBA{240, 196, 25, 255})
+
+	accept := rect{x: x + 12, y: y + 44, w: 90, h: 22}
+	decline := rect{x: x + 112, y: y + 44, w: 90, h: 22}
+	ebitenutil.DrawRect(screen, float64(accept.x), float64(accept.y), float64(accept.w), float64(accept.h), color.NRGBA{70, 110, 70, 255})
+	ebitenutil.DrawRect(screen, float64(decline.x), float64(decline.y), float64(decline.w), float64(decline.h), color.NRGBA{110, 70, 70, 255})
+	text.Draw(screen, "Accept", basicfont.Face7x13, accept.x+22, accept.y+15, color.White)
+	text.Draw(screen, "Decline", basicfont.Face7x13, decline.x+18, decline.y+15, color.White)
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		mx, my := g.logicalCursor()
+		if accept.hit(mx, my) {
+			g.send("DuelAccept", protocol.DuelAccept{From: g.duelInviteFrom})
+			g.duelInviteFrom = ""
+		} else if decline.hit(mx, my) {
+			g.send("DuelDecline", protocol.DuelDecline{From: g.duelInviteFrom})
+			g.duelInviteFrom = ""
+		}
+	}
+}
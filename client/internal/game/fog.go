@@ -0,0 +1,67 @@
+package game
+
+import (
+	"image/color"
+
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Fog of war overlay. The server already withholds enemies we cannot see;
+// this darkens everything outside our units' and bases' sight so the hidden
+// area reads as such.
+
+const fogHoleSize = 128
+
+var fogHole *ebiten.Image // white disc punched out of the fog layer
+
+func (g *Game) fogOn() bool {
+	return g.rules != nil && g.rules.FogOfWar
+}
+
+func (g *Game) drawFog(screen *ebiten.Image) {
+	if !g.fogOn() || g.world == nil {
+		return
+	}
+	if g.fogLayer == nil {
+		g.fogLayer = ebiten.NewImage(protocol.ScreenW, protocol.ScreenH)
+	}
+	if fogHole == nil {
+		fogHole = ebiten.NewImage(fogHoleSize, fogHoleSize)
+		vector.DrawFilledCircle(fogHole, fogHoleSize/2, fogHoleSize/2, fogHoleSize/2, color.White, true)
+	}
+	g.fogLayer.Fill(color.NRGBA{0, 0, 0, 150})
+
+	// Our side is the one mirrored in PvP, so positions get the same flip
+	// the unit and base renderers apply.
+	mirror := g.shouldMirrorForPvp()
+	punch := func(x, y, radius float64) {
+		r := radius * g.cameraZoom
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(2*r/fogHoleSize, 2*r/fogHoleSize)
+		op.GeoM.Translate(x*g.cameraZoom+g.cameraX-r, y*g.cameraZoom+g.cameraY-r)
+		op.Blend = ebiten.BlendDestinationOut
+		g.fogLayer.DrawImage(fogHole, op)
+	}
+	for _, u := range g.world.Units {
+		if g.isAlly(u.OwnerID) {
+			y := u.Y
+			if mirror {
+				y = g.mirrorWorldY(y)
+			}
+			punch(u.X, y, protocol.FogUnitSight)
+		}
+	}
+	for _, b := range g.world.Bases {
+		if g.isAlly(b.OwnerID) {
+			y := float64(b.Y)
+			if mirror {
+				y = g.mirrorWorldY(y)
+			}
+			punch(float64(b.X)+float64(b.W)/2, y+float64(b.H)/2, protocol.FogBaseSight)
+		}
+	}
+	screen.DrawImage(g.fogLayer, nil)
+}
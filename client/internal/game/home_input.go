@@ -21,6 +21,21 @@ func (g *Game) updateHome() {
 	g.computeTopBarLayout()
 	g.computeBottomBarLayout()
 
+	// A running draft takes over the home screen
+	if g.draft != nil {
+		g.updateDraft(mx, my)
+		return
+	}
+	// ...as does a friendly duel lobby
+	if g.lobby != nil {
+		g.updateLobby(mx, my)
+		return
+	}
+	if g.showTournaments {
+		g.updateTournaments(mx, my)
+		return
+	}
+
 	if g.activeTab == tabArmy && len(g.minisAll) == 0 {
 		g.requestLobbyDataOnce()
 	}
@@ -762,39 +777,45 @@ func (g *Game) updateArmyTab(mx, my int) {
 
 // Map tab input handling
 func (g *Game) updateMapTab(mx, my int) {
-	g.ensureMapHotspots()
-
 	mx, my = ebiten.CursorPosition()
 	disp := g.displayMapID()
-	bg := g.ensureBgForMap(disp)
+	bg := g.worldBg(disp)
 	offX, offY, dispW, dispH, _ := g.mapRenderRect(bg)
 
 	g.hoveredHS = -1
-	hsList := g.mapHotspots[disp]
-	if hsList == nil {
-		hsList = g.mapHotspots[defaultMapID]
-	}
+	hsList := g.worldHotspots(disp)
 	for i, hs := range hsList {
-		cx := offX + int(hs.X*float64(dispW))
-		cy := offY + int(hs.Y*float64(dispH))
-		dx, dy := mx-cx, my-cy
-		if dx*dx+dy*dy <= hs.Rpx*hs.Rpx {
+		if hs.hit(mx-offX, my-offY, dispW, dispH) {
 			g.hoveredHS = i
 			break
 		}
 	}
 
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && g.survivalBtnRect().hit(mx, my) {
+		g.mapLockedMsg = ""
+		g.send("CreateSurvival", protocol.CreateSurvival{})
+		return
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && g.challengeBtnRect().hit(mx, my) {
+		g.mapLockedMsg = ""
+		g.send("CreateChallenge", protocol.CreateChallenge{})
+		return
+	}
+
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		if g.hoveredHS >= 0 {
 			g.selectedHS = g.hoveredHS
 
 			hs := hsList[g.selectedHS]
-			arenaID := hs.TargetMapID
-			if arenaID == "" {
-				arenaID = g.arenaForHotspot(disp, hs.ID)
-			}
+			arenaID := hs.target()
 
-			g.onMapClicked(arenaID)
+			if g.hotspotLocked(disp, hs) {
+				g.mapLockedMsg = hs.Name + ": " + g.campaignHint(disp, hs)
+				g.selectedHS = -1
+			} else {
+				g.mapLockedMsg = ""
+				g.onMapClicked(arenaID)
+			}
 		}
 
 		if g.selectedHS >= 0 && g.selectedHS < len(hsList) {
@@ -822,7 +843,17 @@ func (g *Game) updatePvpTab(mx, my int) {
 
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		// Handle button clicks based on current state (matching the drawing logic)
-		if !g.pvpQueued && queueBtn.hit(mx, my) {
+		if g.updateTeamPvp(mx, my) {
+			// 2v2 queue / lobby controls
+		} else if g.updateDraftButtons(mx, my) {
+			// draft queue / draft duel code
+		} else if g.updateRulesPicker(mx, my) {
+			// friendly rules preset
+		} else if g.tournamentsBtnRect().hit(mx, my) {
+			g.openTournaments()
+		} else if g.updatePartyPvp(mx, my) {
+			// party create / leave / queue
+		} else if !g.pvpQueued && queueBtn.hit(mx, my) {
 			// Queue PvP button clicked
 			g.pvpQueued = true
 			g.pvpStatus = "Queueing for PvP…"
@@ -837,7 +868,7 @@ func (g *Game) updatePvpTab(mx, my int) {
 			g.pvpHosting = true
 			g.pvpCode = ""
 			g.pvpStatus = "Requesting friendly code…"
-			g.send("FriendlyCreate", protocol.FriendlyCreate{})
+			g.send("FriendlyCreate", protocol.FriendlyCreate{Rules: g.friendlyRulesID()})
 		} else if g.pvpHosting && cancelBtn.hit(mx, my) {
 			// Cancel Friendly button clicked
 			g.pvpHosting = false
@@ -935,10 +966,30 @@ func (g *Game) updatePvpTab(mx, my int) {
 		}
 	}
 
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if g.lbPvpBtn.hit(mx, my) {
+			g.lbSurvival, g.lbChallenge = false, false
+		} else if g.lbSurvivalBtn.hit(mx, my) {
+			g.lbSurvival, g.lbChallenge = true, false
+			g.survLbLastReq = time.Time{} // refresh now
+		} else if g.lbChallengeBtn.hit(mx, my) {
+			g.lbSurvival, g.lbChallenge = false, true
+			g.challLbLastReq = time.Time{}
+		}
+	}
+
 	if time.Since(g.lbLastReq) > 10*time.Second {
 		g.send("GetLeaderboard", protocol.GetLeaderboard{})
 		g.lbLastReq = time.Now()
 	}
+	if g.lbSurvival && time.Since(g.survLbLastReq) > 10*time.Second {
+		g.send("GetSurvivalLeaderboard", protocol.GetSurvivalLeaderboard{})
+		g.survLbLastReq = time.Now()
+	}
+	if g.lbChallenge && time.Since(g.challLbLastReq) > 10*time.Second {
+		g.send("GetChallengeLeaderboard", protocol.GetChallengeLeaderboard{})
+		g.challLbLastReq = time.Now()
+	}
 }
 
 // Settings tab input handling
@@ -1154,7 +1154,7 @@ func (g *Game) drawHomeContent(screen *ebiten.Image) {
 		}
 	case tabMap:
 		disp := g.displayMapID()
-		bg := g.ensureBgForMap(disp)
+		bg := g.worldBg(disp)
 		offX, offY, dispW, dispH, s := g.mapRenderRect(bg)
 
 		if bg != nil {
@@ -1174,12 +1174,11 @@ func (g *Game) drawHomeContent(screen *ebiten.Image) {
 
 		text.Draw(screen, "Map — click a location, then press Start",
 			basicfont.Face7x13, pad, topBarH-6, color.White)
-
-		g.ensureMapHotspots()
-		hsList := g.mapHotspots[disp]
-		if hsList == nil {
-			hsList = g.mapHotspots[defaultMapID]
+		if g.mapLockedMsg != "" {
+			text.Draw(screen, g.mapLockedMsg, basicfont.Face7x13, pad, topBarH+14, color.NRGBA{230, 120, 100, 255})
 		}
+
+		hsList := g.worldHotspots(disp)
 		for i, hs := range hsList {
 			cx := offX + int(hs.X*float64(dispW))
 			cy := offY + int(hs.Y*float64(dispH))
@@ -1191,6 +1190,12 @@ func (g *Game) drawHomeContent(screen *ebiten.Image) {
 			if i == g.selectedHS {
 				col = color.NRGBA{240, 196, 25, 255}
 			}
+			if g.hotspotLocked(disp, hs) {
+				col = color.NRGBA{0x70, 0x70, 0x70, 0xff}
+				text.Draw(screen, "locked", basicfont.Face7x13, cx+6, cy+4, col)
+			} else if n, ok := g.campaignNodeFor(disp, hs); ok && n.Stars > 0 {
+				text.Draw(screen, strings.Repeat("*", n.Stars), basicfont.Face7x13, cx+6, cy+4, color.NRGBA{240, 196, 25, 255})
+			}
 
 			ebitenutil.DrawRect(screen, float64(cx-2), float64(cy-2), 4, 4, col)
 		}
@@ -1198,7 +1203,11 @@ func (g *Game) drawHomeContent(screen *ebiten.Image) {
 		if g.hoveredHS >= 0 && g.hoveredHS < len(hsList) {
 			hs := hsList[g.hoveredHS]
 			mx, my := ebiten.CursorPosition()
+			hint := g.campaignHint(disp, hs)
 			w, h := 260, 46
+			if hint != "" {
+				w, h = 300, 62
+			}
 			x := clampInt(mx+14, 0, protocol.ScreenW-w)
 			y := clampInt(my-8-h, 0, protocol.ScreenH-h)
 			ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), float64(h),
@@ -1207,6 +1216,9 @@ func (g *Game) drawHomeContent(screen *ebiten.Image) {
 			if hs.Info != "" {
 				text.Draw(screen, hs.Info, basicfont.Face7x13, x+8, y+34, color.NRGBA{200, 200, 200, 255})
 			}
+			if hint != "" {
+				text.Draw(screen, hint, basicfont.Face7x13, x+8, y+50, color.NRGBA{240, 196, 25, 255})
+			}
 		}
 
 		if g.selectedHS >= 0 && g.selectedHS < len(hsList) {
@@ -1225,6 +1237,18 @@ func (g *Game) drawHomeContent(screen *ebiten.Image) {
 				float64(g.startBtn.w), float64(g.startBtn.h), btnCol)
 			text.Draw(screen, label, basicfont.Face7x13, g.startBtn.x+18, g.startBtn.y+18, color.White)
 		}
+
+		// Endless survival entry point
+		g.survivalBtn = g.survivalBtnRect()
+		sb := g.survivalBtn
+		ebitenutil.DrawRect(screen, float64(sb.x), float64(sb.y), float64(sb.w), float64(sb.h), color.NRGBA{110, 60, 60, 240})
+		text.Draw(screen, "Endless Survival", basicfont.Face7x13, sb.x+24, sb.y+20, color.White)
+		best := "Best: -"
+		if b := g.survivalBest; b != nil {
+			best = fmt.Sprintf("Best: wave %d (%d:%02d)", b.Waves, b.Seconds/60, b.Seconds%60)
+		}
+		text.Draw(screen, best, basicfont.Face7x13, sb.x, sb.y-6, color.NRGBA{240, 196, 25, 255})
+		g.drawChallengeButton(screen)
 	case tabPvp:
 
 		contentY := topBarH
@@ -1392,7 +1416,13 @@ func (g *Game) drawHomeContent(screen *ebiten.Image) {
 			text.Draw(screen, label, basicfont.Face7x13, joinInput.x+8, joinInput.y+17, color.White)
 		}
 
-		bottomY := joinBtn.y + joinBtn.h
+		g.drawTeamPvp(screen)
+		g.drawDraftButtons(screen)
+		g.drawRulesPicker(screen)
+		g.drawTournamentsBtn(screen)
+		g.drawPartyPvp(screen)
+
+		bottomY := maxInt(g.partyBottom(), g.teamBottom())
 		sepY := bottomY + 20
 
 		// Draw themed separator
@@ -1434,6 +1464,14 @@ func (g *Game) drawHomeContent(screen *ebiten.Image) {
 		// Leaderboard panel with enhanced styling
 		panelPad := pad
 		rows := minInt(50, len(g.pvpLeaders))
+		lbTitle := "Top 50 - PvP Leaderboard"
+		if g.lbSurvival {
+			rows = minInt(50, len(g.survLeaders))
+			lbTitle = "Top 50 - Survival Leaderboard"
+		} else if g.lbChallenge {
+			rows = minInt(50, len(g.challLeaders))
+			lbTitle = "Top 50 - Weekly Challenge"
+		}
 		const rowH = 16
 		leaderboardPanelH := 16 + 16 + rows*rowH + 8
 		if leaderboardPanelH < 120 {
@@ -1448,7 +1486,7 @@ func (g *Game) drawHomeContent(screen *ebiten.Image) {
 		// Draw themed leaderboard panel
 		if g.fantasyUI != nil {
 			g.fantasyUI.DrawThemedCard(screen, panelPad, leaderboardPanelTop,
-				protocol.ScreenW-2*panelPad, leaderboardPanelH, "Top 50 - PvP Leaderboard", []string{})
+				protocol.ScreenW-2*panelPad, leaderboardPanelH, lbTitle, []string{})
 
 			// Add timestamp if available
 			if g.lbLastStamp != 0 {
@@ -1459,19 +1497,73 @@ func (g *Game) drawHomeContent(screen *ebiten.Image) {
 			ebitenutil.DrawRect(screen, float64(panelPad), float64(leaderboardPanelTop),
 				float64(protocol.ScreenW-2*panelPad), float64(leaderboardPanelH), color.NRGBA{0x24, 0x24, 0x30, 0xFF})
 
-			text.Draw(screen, "Top 50 - PvP Leaderboard", basicfont.Face7x13, panelPad+8, leaderboardPanelTop+18, color.White)
+			text.Draw(screen, lbTitle, basicfont.Face7x13, panelPad+8, leaderboardPanelTop+18, color.White)
 			if g.lbLastStamp != 0 {
 				ts := time.UnixMilli(g.lbLastStamp).Format("15:04:05")
 				text.Draw(screen, "as of "+ts, basicfont.Face7x13, panelPad+240, leaderboardPanelTop+18, color.NRGBA{170, 170, 180, 255})
 			}
 		}
 
+		// PvP / Survival / Weekly toggle
+		g.lbChallengeBtn = rect{x: protocol.ScreenW - panelPad - 8 - 66, y: leaderboardPanelTop + 6, w: 66, h: 18}
+		g.lbSurvivalBtn = rect{x: g.lbChallengeBtn.x - 6 - 80, y: g.lbChallengeBtn.y, w: 80, h: 18}
+		g.lbPvpBtn = rect{x: g.lbSurvivalBtn.x - 6 - 50, y: g.lbSurvivalBtn.y, w: 50, h: 18}
+		for _, t := range []struct {
+			r  rect
+			s  string
+			on bool
+		}{{g.lbPvpBtn, "PvP", !g.lbSurvival && !g.lbChallenge}, {g.lbSurvivalBtn, "Survival", g.lbSurvival}, {g.lbChallengeBtn, "Weekly", g.lbChallenge}} {
+			col := color.NRGBA{60, 60, 80, 255}
+			if t.on {
+				col = color.NRGBA{70, 110, 70, 255}
+			}
+			ebitenutil.DrawRect(screen, float64(t.r.x), float64(t.r.y), float64(t.r.w), float64(t.r.h), col)
+			text.Draw(screen, t.s, basicfont.Face7x13, t.r.x+8, t.r.y+13, color.White)
+		}
+
 		colRankX := panelPad + 8
 		colNameX := panelPad + 58
 		colRatX := panelPad + 360
 		colTierX := panelPad + 440
 
 		hdrY := leaderboardPanelTop + 36
+		if g.lbSurvival {
+			text.Draw(screen, "#", basicfont.Face7x13, colRankX, hdrY, color.NRGBA{200, 200, 210, 255})
+			text.Draw(screen, "Player", basicfont.Face7x13, colNameX, hdrY, color.NRGBA{200, 200, 210, 255})
+			text.Draw(screen, "Waves", basicfont.Face7x13, colRatX, hdrY, color.NRGBA{200, 200, 210, 255})
+			text.Draw(screen, "Time", basicfont.Face7x13, colTierX, hdrY, color.NRGBA{200, 200, 210, 255})
+			for i := 0; i < rows; i++ {
+				e := g.survLeaders[i]
+				y := hdrY + 16 + i*rowH
+				if i%2 == 0 {
+					ebitenutil.DrawRect(screen, float64(panelPad+4), float64(y-12),
+						float64(protocol.ScreenW-2*panelPad-8), rowH, color.NRGBA{0x28, 0x28, 0x36, 0xFF})
+				}
+				text.Draw(screen, fmt.Sprintf("%2d.", i+1), basicfont.Face7x13, colRankX, y, color.White)
+				text.Draw(screen, trim(e.Name, 22), basicfont.Face7x13, colNameX, y, color.White)
+				text.Draw(screen, fmt.Sprintf("%d", e.Waves), basicfont.Face7x13, colRatX, y, color.White)
+				text.Draw(screen, fmt.Sprintf("%d:%02d", e.Seconds/60, e.Seconds%60), basicfont.Face7x13, colTierX, y, color.NRGBA{240, 196, 25, 255})
+			}
+			break
+		}
+		if g.lbChallenge {
+			text.Draw(screen, "#", basicfont.Face7x13, colRankX, hdrY, color.NRGBA{200, 200, 210, 255})
+			text.Draw(screen, "Player", basicfont.Face7x13, colNameX, hdrY, color.NRGBA{200, 200, 210, 255})
+			text.Draw(screen, "Time", basicfont.Face7x13, colRatX, hdrY, color.NRGBA{200, 200, 210, 255})
+			text.Draw(screen, safeTrim(g.weeklyRules.Name, 18), basicfont.Face7x13, colTierX, hdrY, color.NRGBA{240, 196, 25, 255})
+			for i := 0; i < rows; i++ {
+				e := g.challLeaders[i]
+				y := hdrY + 16 + i*rowH
+				if i%2 == 0 {
+					ebitenutil.DrawRect(screen, float64(panelPad+4), float64(y-12),
+						float64(protocol.ScreenW-2*panelPad-8), rowH, color.NRGBA{0x28, 0x28, 0x36, 0xFF})
+				}
+				text.Draw(screen, fmt.Sprintf("%2d.", i+1), basicfont.Face7x13, colRankX, y, color.White)
+				text.Draw(screen, trim(e.Name, 22), basicfont.Face7x13, colNameX, y, color.White)
+				text.Draw(screen, fmt.Sprintf("%d:%02d", e.Seconds/60, e.Seconds%60), basicfont.Face7x13, colRatX, y, color.NRGBA{240, 196, 25, 255})
+			}
+			break
+		}
 		text.Draw(screen, "#", basicfont.Face7x13, colRankX, hdrY, color.NRGBA{200, 200, 210, 255})
 		text.Draw(screen, "Player", basicfont.Face7x13, colNameX, hdrY, color.NRGBA{200, 200, 210, 255})
 		text.Draw(screen, "Rating", basicfont.Face7x13, colRatX, hdrY, color.NRGBA{200, 200, 210, 255})
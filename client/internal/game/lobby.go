@@ -0,0 +1,195 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// lobbyTimeLimits mirrors the server's choices (0 = the map's default).
+var lobbyTimeLimits = []int{0, 120, 180, 240, 300, 420, 600}
+
+// lobbyLayout places the friendly lobby controls: three host setting rows,
+// then the ready and leave buttons.
+func (g *Game) lobbyLayout() (mapBtn, timeBtn, rulesBtn, readyBtn, leaveBtn rect) {
+	x, y := pad+90, topBarH+120
+	mapBtn = rect{x: x, y: y, w: 260, h: 26}
+	timeBtn = rect{x: x, y: y + 36, w: 260, h: 26}
+	rulesBtn = rect{x: x, y: y + 72, w: 260, h: 26}
+	readyBtn = rect{x: pad, y: y + 130, w: 160, h: 32}
+	leaveBtn = rect{x: pad + 172, y: y + 130, w: 120, h: 32}
+	return
+}
+
+func (g *Game) lobbyIsHost() bool {
+	return g.lobby != nil && g.lobby.HostID == g.playerID
+}
+
+func (g *Game) lobbyMapName() string {
+	if g.lobby.MapID == "" {
+		return "Random duel map"
+	}
+	for _, m := range g.lobby.Maps {
+		if m.ID == g.lobby.MapID {
+			return m.Name
+		}
+	}
+	return g.lobby.MapID
+}
+
+func (g *Game) lobbyRulesName() string {
+	if g.lobby.Rules == "" {
+		return "Standard"
+	}
+	for _, m := range g.mutatorPresets {
+		if m.ID == g.lobby.Rules {
+			return m.Name
+		}
+	}
+	return g.lobby.Rules
+}
+
+func lobbyTimeLabel(sec int) string {
+	if sec <= 0 {
+		return "Map default"
+	}
+	return fmt.Sprintf("%d:%02d", sec/60, sec%60)
+}
+
+func (g *Game) drawLobby(screen *ebiten.Image) {
+	l := g.lobby
+	if l == nil {
+		return
+	}
+	ebitenutil.DrawRect(screen, 0, float64(topBarH), float64(protocol.ScreenW), float64(protocol.ScreenH-topBarH-menuBarH), color.NRGBA{20, 20, 30, 250})
+	title := "Friendly Duel Lobby"
+	if l.Played > 0 {
+		title = fmt.Sprintf("Friendly Duel Lobby - %d played", l.Played)
+	}
+	text.Draw(screen, title, basicfont.Face7x13, pad, topBarH+24, color.NRGBA{240, 196, 25, 255})
+
+	// Players and their ready flags
+	for i, id := range l.Players {
+		name := l.Names[id]
+		if id == l.HostID {
+			name += " (host)"
+		}
+		mark, col := "not ready", color.NRGBA{170, 170, 190, 255}
+		if l.Ready[id] {
+			mark, col = "READY", color.NRGBA{120, 220, 120, 255}
+		}
+		y := topBarH + 52 + i*20
+		text.Draw(screen, safeTrim(name, 30), basicfont.Face7x13, pad, y, color.White)
+		text.Draw(screen, mark, basicfont.Face7x13, pad+260, y, col)
+	}
+
+	mapBtn, timeBtn, rulesBtn, readyBtn, leaveBtn := g.lobbyLayout()
+	mx, my := ebiten.CursorPosition()
+	host := g.lobbyIsHost()
+	for _, row := range []struct {
+		r     rect
+		label string
+		value string
+	}{
+		{mapBtn, "Map", g.lobbyMapName()},
+		{timeBtn, "Time limit", lobbyTimeLabel(l.TimeLimit)},
+		{rulesBtn, "Rules", g.lobbyRulesName()},
+	} {
+		text.Draw(screen, row.label, basicfont.Face7x13, pad, row.r.y+17, color.NRGBA{200, 200, 210, 255})
+		col := color.NRGBA{40, 40, 58, 255}
+		if host {
+			col = color.NRGBA{60, 60, 80, 255}
+			if row.r.hit(mx, my) {
+				col = color.NRGBA{75, 75, 100, 255}
+			}
+		}
+		ebitenutil.DrawRect(screen, float64(row.r.x), float64(row.r.y), float64(row.r.w), float64(row.r.h), col)
+		text.Draw(screen, safeTrim(row.value, 34), basicfont.Face7x13, row.r.x+8, row.r.y+17, color.White)
+	}
+	if !host {
+		text.Draw(screen, "The host picks the settings.", basicfont.Face7x13, pad, rulesBtn.y+rulesBtn.h+20, color.NRGBA{170, 170, 190, 255})
+	}
+
+	readyLabel := "Ready"
+	if l.Played > 0 {
+		readyLabel = "Rematch"
+	}
+	if l.Ready[g.playerID] {
+		readyLabel = "Not ready"
+	}
+	for _, b := range []struct {
+		r     rect
+		label string
+	}{{readyBtn, readyLabel}, {leaveBtn, "Leave"}} {
+		if g.fantasyUI != nil {
+			state := ButtonNormal
+			if b.r.hit(mx, my) {
+				state = ButtonHover
+			}
+			g.fantasyUI.DrawThemedButtonWithStyle(screen, b.r.x, b.r.y, b.r.w, b.r.h, b.label, state, true)
+		} else {
+			ebitenutil.DrawRect(screen, float64(b.r.x), float64(b.r.y), float64(b.r.w), float64(b.r.h), color.NRGBA{60, 60, 80, 255})
+			text.Draw(screen, b.label, basicfont.Face7x13, b.r.x+8, b.r.y+20, color.White)
+		}
+	}
+}
+
+// updateLobby handles clicks in the friendly lobby.
+func (g *Game) updateLobby(mx, my int) {
+	l := g.lobby
+	if l == nil || !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return
+	}
+	mapBtn, timeBtn, rulesBtn, readyBtn, leaveBtn := g.lobbyLayout()
+	switch {
+	case readyBtn.hit(mx, my):
+		g.send("SetReady", protocol.SetReady{Ready: !l.Ready[g.playerID]})
+	case leaveBtn.hit(mx, my):
+		g.onLeaveRoom()
+		g.roomID = ""
+		g.lobby = nil
+		g.pvpStatus = "Left the lobby."
+	case !g.lobbyIsHost():
+	case mapBtn.hit(mx, my):
+		ids := []string{""}
+		for _, m := range l.Maps {
+			ids = append(ids, m.ID)
+		}
+		g.sendLobbySettings(nextOf(ids, l.MapID), l.TimeLimit, l.Rules)
+	case timeBtn.hit(mx, my):
+		next := lobbyTimeLimits[0]
+		for i, t := range lobbyTimeLimits {
+			if t == l.TimeLimit {
+				next = lobbyTimeLimits[(i+1)%len(lobbyTimeLimits)]
+			}
+		}
+		g.sendLobbySettings(l.MapID, next, l.Rules)
+	case rulesBtn.hit(mx, my):
+		ids := []string{""}
+		for _, m := range g.mutatorPresets {
+			ids = append(ids, m.ID)
+		}
+		g.sendLobbySettings(l.MapID, l.TimeLimit, nextOf(ids, l.Rules))
+	}
+}
+
+func (g *Game) sendLobbySettings(mapID string, timeLimit int, rules string) {
+	g.send("LobbySettings", protocol.LobbySettings{MapID: mapID, TimeLimit: timeLimit, Rules: rules})
+}
+
+// nextOf returns the entry after cur in list (wrapping; the first if cur is missing).
+func nextOf(list []string, cur string) string {
+	for i, s := range list {
+		if s == cur {
+			return list[(i+1)%len(list)]
+		}
+	}
+	return list[0]
+}
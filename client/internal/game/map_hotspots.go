@@ -1,75 +1,133 @@
 package game
 
-func (g *Game) arenaForHotspot(worldID, hsID string) string {
+import (
+	"fmt"
+	"strings"
 
-	if worldID == "rumble_world" {
-		switch hsID {
-		case "north_tower":
-			return "north_tower"
-		case "spawn_west":
-			return "west_keep"
-		case "spawn_east":
-			return "east_gate"
-		case "mid_bridge":
-			return "mid_bridge"
-		case "south_gate":
-			return "south_gate"
-		}
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// applyWorld caches a server world definition as Map tab hotspots. Hotspots
+// are hit-tested against their rect and marked at its centre.
+func (g *Game) applyWorld(def protocol.WorldDef) {
+	if g.worlds == nil {
+		g.worlds = map[string]protocol.WorldDef{}
 	}
+	if g.mapHotspots == nil {
+		g.mapHotspots = map[string][]Hotspot{}
+	}
+	g.worlds[def.ID] = def
+	hs := make([]Hotspot, 0, len(def.Hotspots))
+	for _, w := range def.Hotspots {
+		r := w.Rect
+		hs = append(hs, Hotspot{
+			ID: w.ID, Name: w.Name, Info: w.Info,
+			X: (r.Left + r.Right) * 0.5, Y: (r.Top + r.Bottom) * 0.5, Rpx: 18,
+			HitRect:     &HSRect{Left: r.Left, Top: r.Top, Right: r.Right, Bottom: r.Bottom},
+			TargetMapID: w.TargetMapID,
+			Requires:    w.Requires,
+		})
+	}
+	g.mapHotspots[def.ID] = hs
+}
 
-	return "north_tower"
+// worldHotspots returns the hotspots of a world, or the default world's
+// while its definition has not arrived yet.
+func (g *Game) worldHotspots(worldID string) []Hotspot {
+	if hs, ok := g.mapHotspots[worldID]; ok {
+		return hs
+	}
+	return g.mapHotspots[defaultMapID]
 }
 
-func (g *Game) ensureMapHotspots() {
-	if g.mapHotspots != nil {
-		return
+// worldBg is a world's background image; it defaults to the world ID.
+func (g *Game) worldBg(worldID string) *ebiten.Image {
+	if def, ok := g.worlds[worldID]; ok && def.Background != "" {
+		return g.ensureBgForMap(def.Background)
 	}
+	return g.ensureBgForMap(worldID)
+}
 
-	g.mapHotspots = map[string][]Hotspot{
-		"rumble_world": {
-			{ID: "spawn_west", Name: "Western Keep", Info: "Good for melee rush.",
-				X: 0.1250, Y: 0.5767, Rpx: 18,
-				HitRect: &HSRect{Left: 0.18, Top: 0.54, Right: 0.26, Bottom: 0.62}},
-			{ID: "spawn_east", Name: "Eastern Gate", Info: "Open field, risky.",
-				X: 0.6317, Y: 0.4367, Rpx: 18,
-				HitRect: &HSRect{Left: 0.74, Top: 0.43, Right: 0.82, Bottom: 0.51}},
-			{ID: "mid_bridge", Name: "Central Bridge", Info: "Choke point.",
-				X: 0.2817, Y: 0.1617, Rpx: 18,
-				HitRect: &HSRect{Left: 0.47, Top: 0.47, Right: 0.53, Bottom: 0.53}},
-			{ID: "north_tower", Name: "North Tower", Info: "High ground.",
-				X: 0.7000, Y: 0.1400, Rpx: 18,
-				HitRect: &HSRect{Left: 0.53, Top: 0.22, Right: 0.59, Bottom: 0.30}},
-			{ID: "south_gate", Name: "South Gate", Info: "Wide approach.",
-				X: 0.3567, Y: 0.7350, Rpx: 18,
-				HitRect: &HSRect{Left: 0.40, Top: 0.74, Right: 0.48, Bottom: 0.82}},
-		},
+// hit tests a point relative to a map drawn w x h pixels large.
+func (hs Hotspot) hit(x, y, w, h int) bool {
+	if r := hs.HitRect; r != nil {
+		fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+		return fx >= r.Left && fx <= r.Right && fy >= r.Top && fy <= r.Bottom
 	}
+	dx, dy := x-int(hs.X*float64(w)), y-int(hs.Y*float64(h))
+	return dx*dx+dy*dy <= hs.Rpx*hs.Rpx
 }
 
-func (g *Game) ensureMapRects() {
-	if g.rectHotspots != nil {
-		return
+// target is the map a hotspot launches.
+func (hs Hotspot) target() string {
+	if hs.TargetMapID != "" {
+		return hs.TargetMapID
 	}
-	g.rectHotspots = map[string][]HitRect{
-		"rumble_world": {
+	return hs.ID
+}
+
+// campaignNodeFor returns the campaign entry behind a hotspot, if the map is part of the campaign.
+func (g *Game) campaignNodeFor(worldID string, hs Hotspot) (protocol.CampaignNode, bool) {
+	n, ok := g.campaign[hs.target()]
+	return n, ok
+}
 
-			{ID: "spawn_west", Name: "Western Keep", Info: "Good for melee rush.", L: 0.185, T: 0.540, R: 0.255, B: 0.615},
-			{ID: "spawn_east", Name: "Eastern Gate", Info: "Open field, risky.", L: 0.745, T: 0.440, R: 0.815, B: 0.515},
-			{ID: "mid_bridge", Name: "Central Bridge", Info: "Choke point.", L: 0.470, T: 0.475, R: 0.530, B: 0.545},
-			{ID: "north_tower", Name: "North Tower", Info: "High ground.", L: 0.530, T: 0.210, R: 0.590, B: 0.280},
-			{ID: "south_gate", Name: "South Gate", Info: "Wide approach.", L: 0.410, T: 0.750, R: 0.470, B: 0.820},
-		},
+// worldReqsMissing lists the hotspot's own requirements without a star yet.
+func (g *Game) worldReqsMissing(hs Hotspot) []string {
+	var out []string
+	for _, req := range hs.Requires {
+		if g.campaign[req].Stars < 1 {
+			out = append(out, req)
+		}
 	}
+	return out
+}
+
+// hotspotLocked reports whether the server marked the hotspot's map as
+// locked or the world still requires other maps first.
+func (g *Game) hotspotLocked(worldID string, hs Hotspot) bool {
+	if n, ok := g.campaignNodeFor(worldID, hs); ok && !n.Unlocked {
+		return true
+	}
+	return len(g.worldReqsMissing(hs)) > 0
+}
 
-	g.ensureMapHotspots()
-	for mapID, rects := range g.rectHotspots {
-		hs := make([]Hotspot, len(rects))
-		for i, r := range rects {
-			hs[i] = Hotspot{
-				ID: r.ID, Name: r.Name, Info: r.Info,
-				X: (r.L + r.R) * 0.5, Y: (r.T + r.B) * 0.5, Rpx: 18,
+// campaignHint is the second tooltip line for a hotspot: requirements when
+// locked, stars and criteria otherwise.
+func (g *Game) campaignHint(worldID string, hs Hotspot) string {
+	n, ok := g.campaignNodeFor(worldID, hs)
+	if g.hotspotLocked(worldID, hs) {
+		reqs := g.worldReqsMissing(hs)
+		if ok && !n.Unlocked {
+			reqs = append(append([]string(nil), n.Requires...), reqs...)
+		}
+		names := make([]string, 0, len(reqs))
+		for _, req := range reqs {
+			if rn, ok := g.campaign[req]; ok && rn.Name != "" {
+				names = append(names, rn.Name)
+			} else {
+				names = append(names, req)
 			}
 		}
-		g.mapHotspots[mapID] = hs
+		return "Locked - clear " + strings.Join(names, ", ")
+	}
+	if !ok {
+		return ""
+	}
+	criteria := fmt.Sprintf("win, base >=%d%%", n.BaseHPPct)
+	outOf := 3
+	switch {
+	case !n.Timed:
+		outOf = 2 // the time star needs a clock
+	case n.TimeUnder > 0:
+		criteria += fmt.Sprintf(", <%ds", n.TimeUnder)
 	}
+	return fmt.Sprintf("Stars %d/%d  (%s)", n.Stars, outOf, criteria)
+}
+
+// survivalBtnRect places the Endless Survival button in the map tab's bottom-right corner.
+func (g *Game) survivalBtnRect() rect {
+	return rect{x: protocol.ScreenW - pad - 160, y: protocol.ScreenH - menuBarH - 44, w: 160, h: 30}
 }
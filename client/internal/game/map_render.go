@@ -184,6 +184,23 @@ func (g *Game) drawArenaBG(screen *ebiten.Image) {
 		return
 	}
 
+	// Maps with their own world size stretch the background over the whole
+	// world and scroll it with the camera.
+	if g.scr == screenBattle && g.currentMapDef != nil && (g.currentMapDef.WorldW > 0 || g.currentMapDef.WorldH > 0) {
+		iw, ih := bg.Bounds().Dx(), bg.Bounds().Dy()
+		if iw == 0 || ih == 0 {
+			return
+		}
+		ww, wh := g.worldSize()
+		ebitenutil.DrawRect(screen, 0, 0, float64(protocol.ScreenW), float64(protocol.ScreenH), color.NRGBA{0, 0, 0, 255})
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(ww/float64(iw)*g.cameraZoom, wh/float64(ih)*g.cameraZoom)
+		op.GeoM.Translate(g.cameraX, g.cameraY)
+		op.Filter = ebiten.FilterLinear
+		screen.DrawImage(bg, op)
+		return
+	}
+
 	var offX, offY, dispW, dispH int
 	var s float64
 
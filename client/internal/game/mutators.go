@@ -0,0 +1,86 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// goldMax is the gold cap of the current battle (mutators may change it).
+func (g *Game) goldMax() int {
+	if g.rules != nil && g.rules.GoldMax > 0 {
+		return g.rules.GoldMax
+	}
+	return protocol.GoldMax
+}
+
+// ---- Friendly rules picker (PvP tab)
+
+// rulesBtnRect sits under the friendly code field.
+func (g *Game) rulesBtnRect() rect {
+	_, _, _, _, joinInput, joinBtn := g.pvpLayout()
+	return rect{x: joinInput.x, y: joinBtn.y + joinBtn.h + 10, w: 220, h: 24}
+}
+
+// friendlyRulesID is the preset sent with FriendlyCreate ("" = standard rules).
+func (g *Game) friendlyRulesID() string {
+	if g.rulesIdx <= 0 || g.rulesIdx > len(g.mutatorPresets) {
+		return ""
+	}
+	return g.mutatorPresets[g.rulesIdx-1].ID
+}
+
+func (g *Game) drawRulesPicker(screen *ebiten.Image) {
+	r := g.rulesBtnRect()
+	name, desc := "Standard", "Regular rules"
+	if g.friendlyRulesID() != "" {
+		m := g.mutatorPresets[g.rulesIdx-1]
+		name, desc = m.Name, m.Description
+	}
+	mx, my := ebiten.CursorPosition()
+	col := color.NRGBA{60, 60, 80, 255}
+	if r.hit(mx, my) {
+		col = color.NRGBA{75, 75, 100, 255}
+	}
+	ebitenutil.DrawRect(screen, float64(r.x), float64(r.y), float64(r.w), float64(r.h), col)
+	text.Draw(screen, "Rules: "+safeTrim(name, 22), basicfont.Face7x13, r.x+8, r.y+16, color.White)
+	text.Draw(screen, safeTrim(desc, 44), basicfont.Face7x13, r.x+r.w+10, r.y+16, color.NRGBA{170, 170, 190, 255})
+}
+
+// updateRulesPicker cycles through the presets; it reports whether the click
+// was consumed.
+func (g *Game) updateRulesPicker(mx, my int) bool {
+	if g.pvpHosting || !g.rulesBtnRect().hit(mx, my) {
+		return false
+	}
+	g.rulesIdx = (g.rulesIdx + 1) % (len(g.mutatorPresets) + 1)
+	return true
+}
+
+// ---- Weekly challenge (map tab)
+
+// challengeBtnRect mirrors the survival button in the bottom-left corner.
+func (g *Game) challengeBtnRect() rect {
+	return rect{x: pad, y: protocol.ScreenH - menuBarH - 44, w: 160, h: 30}
+}
+
+func (g *Game) drawChallengeButton(screen *ebiten.Image) {
+	r := g.challengeBtnRect()
+	ebitenutil.DrawRect(screen, float64(r.x), float64(r.y), float64(r.w), float64(r.h), color.NRGBA{60, 70, 120, 240})
+	text.Draw(screen, "Weekly Challenge", basicfont.Face7x13, r.x+24, r.y+20, color.White)
+
+	line := "Best: -"
+	if b := g.weeklyBest; b != nil && b.Week == g.challengeWeek {
+		line = fmt.Sprintf("Best: %d:%02d", b.Seconds/60, b.Seconds%60)
+	}
+	if g.weeklyRules.Name != "" {
+		line = g.weeklyRules.Name + " - " + line
+	}
+	text.Draw(screen, line, basicfont.Face7x13, r.x, r.y-6, color.NRGBA{240, 196, 25, 255})
+}
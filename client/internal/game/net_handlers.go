@@ -31,9 +31,13 @@ func (g *Game) handle(env Msg) {
 		g.pvpRank = p.PvPRank
 		g.avatar = p.Avatar
 		g.unitXP = p.UnitXP
+		g.survivalBest = p.SurvivalBest
+		g.weeklyBest = p.WeeklyBest
 
 		g.send("ListMinis", protocol.ListMinis{})
 		g.send("ListMaps", protocol.ListMaps{})
+		g.send("GetCampaign", protocol.GetCampaign{})
+		g.send("GetMutators", protocol.GetMutators{})
 		if len(g.avatars) == 0 {
 			g.avatars = g.listAvatars()
 		}
@@ -113,10 +117,24 @@ func (g *Game) handle(env Msg) {
 				break
 			}
 		}
+	case "QueueBlocked":
+		var qb protocol.QueueBlocked
+		json.Unmarshal(env.Data, &qb)
+		g.pvpQueued, g.teamQueued = false, false
+		g.pvpStatus = qb.Message
+	case "MatchAbandoned":
+		var ma protocol.MatchAbandoned
+		json.Unmarshal(env.Data, &ma)
+		what := "left the match"
+		if ma.Reason == "afk" {
+			what = "went AFK"
+		}
+		g.battleBanner = strings.Join(ma.Names, " & ") + " " + what
+		g.battleBannerUntil = time.Now().Add(4 * time.Second)
 	case "RatingUpdate":
 		var ru protocol.RatingUpdate
 		json.Unmarshal(env.Data, &ru)
-		if ru.MatchType == "queue" {
+		if ru.MatchType == "queue" || ru.MatchType == "team" {
 			g.pvpRating = ru.NewRating
 			g.pvpRank = ru.Rank
 			sign := "+"
@@ -165,9 +183,99 @@ func (g *Game) handle(env Msg) {
 		if g.currentMap == "" {
 			g.currentMap = defaultMapID
 		}
-		g.ensureMapHotspots()
+		g.send("GetWorld", protocol.GetWorld{ID: g.displayMapID()})
 		g.hoveredHS, g.selectedHS = -1, -1
 
+	case "WorldDef":
+		var m protocol.WorldDefMsg
+		json.Unmarshal(env.Data, &m)
+		g.applyWorld(m.Def)
+		if m.Def.ID == g.displayMapID() {
+			g.hoveredHS, g.selectedHS = -1, -1
+		}
+
+	case "CampaignState":
+		var cs protocol.CampaignState
+		json.Unmarshal(env.Data, &cs)
+		g.campaign = make(map[string]protocol.CampaignNode, len(cs.Nodes))
+		for _, n := range cs.Nodes {
+			g.campaign[n.MapID] = n
+		}
+		g.mapLockedMsg = ""
+
+	case "CampaignResult":
+		var cr protocol.CampaignResult
+		json.Unmarshal(env.Data, &cr)
+		g.campaignResult = &cr
+
+	case "CoopInvited":
+		var ci protocol.CoopInvited
+		json.Unmarshal(env.Data, &ci)
+		g.coopInviteFrom = ci.From
+		g.coopInviteMap = defaultIfEmpty(ci.MapName, ci.MapID)
+	case "CoopInviteSent":
+		var cs protocol.CoopInviteSent
+		json.Unmarshal(env.Data, &cs)
+		g.setCoopNote("Co-op invite sent to " + cs.To)
+	case "CoopDeclined":
+		var cd protocol.CoopDeclined
+		json.Unmarshal(env.Data, &cd)
+		g.setCoopNote(cd.By + " declined your co-op invite")
+	case "DuelInvited":
+		var di protocol.DuelInvited
+		json.Unmarshal(env.Data, &di)
+		g.duelInviteFrom = di.From
+		g.duelInviteVia = di.Via
+		g.duelInviteUntil = time.Now().Add(time.Duration(di.ExpiresIn) * time.Second)
+	case "DuelInviteSent":
+		var ds protocol.DuelInviteSent
+		json.Unmarshal(env.Data, &ds)
+		g.setCoopNote("Duel invite sent to " + ds.To)
+	case "DuelDeclined":
+		var dd protocol.DuelDeclined
+		json.Unmarshal(env.Data, &dd)
+		g.setCoopNote(dd.By + " declined your duel")
+	case "SurvivalWave":
+		var sw protocol.SurvivalWave
+		json.Unmarshal(env.Data, &sw)
+		g.survivalWave = sw.Wave
+		g.survivalNextAt = time.Now().Add(time.Duration(sw.NextIn) * time.Second)
+		g.battleBanner = fmt.Sprintf("Wave %d - %d enemies", sw.Wave, sw.Units)
+		g.battleBannerUntil = time.Now().Add(2 * time.Second)
+	case "SurvivalResult":
+		var sr protocol.SurvivalResult
+		json.Unmarshal(env.Data, &sr)
+		g.survivalResult = &sr
+		if sr.Best != nil {
+			g.survivalBest = sr.Best
+		}
+	case "MutatorList":
+		var ml protocol.MutatorList
+		json.Unmarshal(env.Data, &ml)
+		g.mutatorPresets = ml.Presets
+		g.weeklyRules = ml.Weekly
+		g.challengeWeek = ml.Week
+		if g.rulesIdx > len(g.mutatorPresets) {
+			g.rulesIdx = 0
+		}
+	case "ChallengeResult":
+		var cr protocol.ChallengeResult
+		json.Unmarshal(env.Data, &cr)
+		g.challengeResult = &cr
+		if cr.Best != nil {
+			g.weeklyBest = cr.Best
+		}
+	case "ChallengeLeaderboard":
+		var lb protocol.ChallengeLeaderboard
+		json.Unmarshal(env.Data, &lb)
+		g.challLeaders = lb.Items
+		g.challengeWeek = lb.Week
+		g.weeklyRules = lb.Rules
+	case "SurvivalLeaderboard":
+		var lb protocol.SurvivalLeaderboard
+		json.Unmarshal(env.Data, &lb)
+		g.survLeaders = lb.Items
+
 	case "Init":
 		var m protocol.Init
 		json.Unmarshal(env.Data, &m)
@@ -188,6 +296,11 @@ func (g *Game) handle(env Msg) {
 
 		g.selectedIdx = -1
 		g.dragActive = false
+		g.campaignResult = nil
+		g.survivalResult = nil
+		g.challengeResult = nil
+		g.rules = m.Rules
+		g.survivalWave = 0
 		g.endActive = false
 		g.endVictory = false
 		g.gameOver = false
@@ -202,6 +315,7 @@ func (g *Game) handle(env Msg) {
 			g.world.Obstacles = g.currentMapDef.Obstacles
 			g.world.Lanes = g.currentMapDef.Lanes
 		}
+		g.setWorldSize(m.MapWidth, m.MapHeight)
 
 		g.enemyAvatar = ""
 		g.enemyTargetThumb = nil
@@ -209,6 +323,14 @@ func (g *Game) handle(env Msg) {
 
 		// Initialize timer for battle
 		g.timerRemainingSeconds = 180 // Default 3:00 minutes
+		g.endlessBattle = m.Endless
+		g.allies = map[int64]bool{}
+		for _, id := range m.Allies {
+			g.allies[id] = true
+		}
+		if m.Endless {
+			g.timerRemainingSeconds = 0 // survival: counts up
+		}
 		g.timerPaused = false
 		g.pauseOverlay = false
 
@@ -263,6 +385,7 @@ func (g *Game) handle(env Msg) {
 		var s protocol.FullSnapshot
 		json.Unmarshal(env.Data, &s)
 		g.world = buildWorldFromSnapshot(s, g.currentMapDef)
+		g.world.W, g.world.H = g.worldSize()
 
 		// Center camera on player's base if needed
 		if g.needsCameraCenter && g.scr == screenBattle {
@@ -287,7 +410,7 @@ func (g *Game) handle(env Msg) {
 		var m protocol.GameOver
 		json.Unmarshal(env.Data, &m)
 		g.gameOver = true
-		g.victory = (m.WinnerID == g.playerID)
+		g.victory = g.isAlly(m.WinnerID)
 		// Compute XP gains from pre-battle snapshot
 		g.xpGains = map[string]int{}
 		if g.preBattleXP != nil && g.unitXP != nil {
@@ -360,6 +483,18 @@ func (g *Game) handle(env Msg) {
 		if g.world != nil {
 			g.world.StartSpawnAnimation(se.UnitID, se.UnitName, se.UnitClass, se.UnitSubclass, se.UnitX, se.UnitY)
 		}
+	case "BossPhaseEvent":
+		var be protocol.BossPhaseEvent
+		json.Unmarshal(env.Data, &be)
+
+		if g.particleSystem != nil {
+			g.particleSystem.CreateUnitAbilityEffect(be.X, be.Y, "rage")
+			if be.AoERadius > 0 {
+				g.particleSystem.CreateExplosionEffect(be.X, be.Y, be.AoERadius/60)
+			}
+		}
+		g.battleBanner = fmt.Sprintf("%s - %s", be.BossName, be.PhaseName)
+		g.battleBannerUntil = time.Now().Add(3 * time.Second)
 	case "VictoryEvent":
 		var ve protocol.VictoryEvent
 		json.Unmarshal(env.Data, &ve)
@@ -429,13 +564,117 @@ func (g *Game) handle(env Msg) {
 		g.pvpCode = strings.ToUpper(strings.TrimSpace(m.Code))
 		g.pvpStatus = "Share this code: " + g.pvpCode
 
+	case "TeamQueueStatus":
+		var st protocol.TeamQueueStatus
+		json.Unmarshal(env.Data, &st)
+		g.teamQueued = st.Queued
+		g.teamWaiting = st.Waiting
+		if st.Queued {
+			g.pvpStatus = fmt.Sprintf("Queueing for 2v2… (%d/4)", st.Waiting)
+		}
+	case "TeamLobbyState":
+		var st protocol.TeamLobbyState
+		json.Unmarshal(env.Data, &st)
+		g.teamLobby = &st
+		g.pvpStatus = fmt.Sprintf("2v2 lobby %s: %d/4 players", st.Code, len(st.Teams[0])+len(st.Teams[1]))
+	case "TeamLobbyClosed":
+		var m protocol.TeamLobbyClosed
+		json.Unmarshal(env.Data, &m)
+		g.teamLobby = nil
+		g.pvpStatus = "Left 2v2 lobby."
+		if m.Reason != "" {
+			g.pvpStatus = "2v2 lobby closed: " + m.Reason
+		}
+
+	case "DraftQueueStatus":
+		var st protocol.DraftQueueStatus
+		json.Unmarshal(env.Data, &st)
+		g.draftQueued = st.Queued
+	case "DraftState":
+		var st protocol.DraftState
+		json.Unmarshal(env.Data, &st)
+		g.draft = &st
+		g.draftQueued = false
+		g.pvpHosting = false
+		g.draftDeadline = time.Now().Add(time.Duration(st.SecondsLeft) * time.Second)
+	case "DraftCancelled":
+		var m protocol.DraftCancelled
+		json.Unmarshal(env.Data, &m)
+		g.draft = nil
+		g.pvpStatus = "Draft cancelled."
+		if m.Reason != "" {
+			g.pvpStatus = "Draft cancelled: " + m.Reason
+		}
+
+	case "RoomStatus":
+		var st protocol.RoomStatus
+		json.Unmarshal(env.Data, &st)
+		g.lobby = &st
+		g.roomID = st.RoomID
+		g.pvpHosting = false
+		g.pvpCode = ""
+	case "PartyState":
+		var ps protocol.PartyState
+		json.Unmarshal(env.Data, &ps)
+		g.party = &ps
+	case "PartyInvited":
+		var pi protocol.PartyInvited
+		json.Unmarshal(env.Data, &pi)
+		g.partyInviteFrom = pi.Leader
+		g.partyInviteUntil = time.Now().Add(time.Duration(pi.ExpiresIn) * time.Second)
+	case "PartyDeclined":
+		var pd protocol.PartyDeclined
+		json.Unmarshal(env.Data, &pd)
+		g.setCoopNote(pd.By + " declined the party invite")
+	case "PartyClosed":
+		var pc protocol.PartyClosed
+		json.Unmarshal(env.Data, &pc)
+		g.party = nil
+		if pc.Reason != "" {
+			g.setCoopNote(pc.Reason)
+		}
+	case "SpectateStart":
+		var ss protocol.SpectateStart
+		json.Unmarshal(env.Data, &ss)
+		g.spectating = ss.Watching
+		g.roomID = ss.RoomID
+		g.battleBanner = "Watching " + ss.Watching
+		g.battleBannerUntil = time.Now().Add(3 * time.Second)
+	case "TournamentList":
+		var tl protocol.TournamentList
+		json.Unmarshal(env.Data, &tl)
+		g.tournaments = tl.Items
+	case "TournamentState":
+		var ts protocol.TournamentState
+		json.Unmarshal(env.Data, &ts)
+		if g.tourney == nil || g.tourney.ID == ts.Tournament.ID {
+			g.tourney = &ts.Tournament
+		}
+	case "TournamentMatchReady":
+		var mr protocol.TournamentMatchReady
+		json.Unmarshal(env.Data, &mr)
+		g.coopNote = fmt.Sprintf("Tournament match vs %s - be online and out of battle within %ds", mr.Opponent, mr.ExpiresIn)
+		g.coopNoteUntil = time.Now().Add(10 * time.Second)
+	case "LobbyClosed":
+		var lc protocol.LobbyClosed
+		json.Unmarshal(env.Data, &lc)
+		g.lobby = nil
+		if g.scr != screenBattle {
+			g.roomID = ""
+		}
+		g.pvpStatus = lc.Reason
 	case "RoomCreated":
 		var rc protocol.RoomCreated
 		json.Unmarshal(env.Data, &rc)
 		g.roomID = rc.RoomID
+		g.spectating = ""
 
 		g.pvpQueued = false
 		g.pvpHosting = false
+		g.teamQueued = false
+		g.teamLobby = nil
+		g.draftQueued = false
+		g.draft = nil
 		// Snapshot XP before battle starts
 		g.preBattleXP = map[string]int{}
 		for k, v := range g.unitXP {
@@ -452,6 +691,18 @@ func (g *Game) handle(env Msg) {
 			}
 		}
 
+	case "Announcement":
+		var a protocol.Announcement
+		json.Unmarshal(env.Data, &a)
+		g.announcement = a.Text
+		g.announcementUntil = time.UnixMilli(a.ExpiresAt)
+	case "Kicked":
+		var k protocol.Kicked
+		json.Unmarshal(env.Data, &k)
+		g.resetToLoginNoAutoConnect()
+		g.auth.msg = k.Reason
+		return
+
 	case "LoggedOut":
 
 		g.resetToLoginNoAutoConnect()
@@ -615,6 +866,7 @@ func (g *Game) onStartBattle() { g.send("StartBattle", protocol.StartBattle{}) }
 func (g *Game) onLeaveRoom() {
 	g.send("LeaveRoom", protocol.LeaveRoom{})
 	g.currentArena = ""
+	g.spectating = ""
 }
 
 // centerCameraOnPlayerBase centers the camera with bottom aligned to map bottom
@@ -644,6 +896,7 @@ func (g *Game) centerCameraOnPlayerBase() {
 		// Position camera so bottom of screen is at map bottom (assuming map height is available)
 		// For now, position to show more of the map from the bottom
 		g.cameraY = float64(protocol.ScreenH) - playerBaseY*g.cameraZoom - 100 // Small offset from bottom
+		g.clampCamera()
 
 		g.needsCameraCenter = false // Clear the flag
 	}
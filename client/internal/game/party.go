@@ -0,0 +1,190 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// inviteToParty invites name, creating a party first if we are not in one.
+func (g *Game) inviteToParty(name string) {
+	if g.party == nil {
+		g.send("PartyCreate", protocol.PartyCreate{})
+	}
+	g.send("PartyInvite", protocol.PartyInvite{Name: name})
+	g.setCoopNote("Party invite sent to " + name)
+}
+
+func (g *Game) partyLeader() bool {
+	return g.party != nil && strings.EqualFold(g.party.Leader, g.name)
+}
+
+// partyLayout places the party row under the Tournaments button on the PvP tab.
+func (g *Game) partyLayout() (partyBtn, teamBtn, coopBtn, watchBtn rect) {
+	tb := g.tournamentsBtnRect()
+	y := tb.y + tb.h + 10
+	partyBtn = rect{x: tb.x, y: y, w: 120, h: 28}
+	teamBtn = rect{x: tb.x + 128, y: y, w: 120, h: 28}
+	coopBtn = rect{x: tb.x + 256, y: y, w: 120, h: 28}
+	watchBtn = rect{x: tb.x + 384, y: y, w: 120, h: 28}
+	return
+}
+
+// partyBottom is the lowest y used by the party row (members line included).
+func (g *Game) partyBottom() int {
+	partyBtn, _, _, _ := g.partyLayout()
+	if g.party == nil {
+		return partyBtn.y + partyBtn.h
+	}
+	return partyBtn.y + partyBtn.h + 20
+}
+
+func (g *Game) drawPartyPvp(screen *ebiten.Image) {
+	partyBtn, teamBtn, coopBtn, watchBtn := g.partyLayout()
+	mx, my := ebiten.CursorPosition()
+
+	btns := []struct {
+		r     rect
+		label string
+	}{{partyBtn, "Create Party"}}
+	if g.party != nil {
+		btns[0].label = "Leave Party"
+		if g.partyLeader() {
+			team := "Party 2v2"
+			if g.party.Queue == "team" {
+				team = "Cancel 2v2"
+			}
+			watch := "Watch: off"
+			if g.party.Queue == "watch" {
+				watch = "Watch: on"
+			}
+			btns = append(btns, struct {
+				r     rect
+				label string
+			}{teamBtn, team}, struct {
+				r     rect
+				label string
+			}{coopBtn, "Party Co-op"}, struct {
+				r     rect
+				label string
+			}{watchBtn, watch})
+		}
+	}
+	for _, b := range btns {
+		if g.fantasyUI != nil {
+			state := ButtonNormal
+			if b.r.hit(mx, my) {
+				state = ButtonHover
+			}
+			g.fantasyUI.DrawThemedButtonWithStyle(screen, b.r.x, b.r.y, b.r.w, b.r.h, b.label, state, true)
+		} else {
+			ebitenutil.DrawRect(screen, float64(b.r.x), float64(b.r.y), float64(b.r.w), float64(b.r.h), color.NRGBA{60, 60, 80, 255})
+			text.Draw(screen, b.label, basicfont.Face7x13, b.r.x+8, b.r.y+18, color.White)
+		}
+	}
+
+	if g.party == nil {
+		return
+	}
+	names := make([]string, 0, len(g.party.Members))
+	for _, m := range g.party.Members {
+		n := m.Name
+		if strings.EqualFold(n, g.party.Leader) {
+			n += " (leader)"
+		}
+		if !m.Online {
+			n += " (offline)"
+		}
+		names = append(names, n)
+	}
+	line := "Party: " + strings.Join(names, ", ")
+	if g.party.LeaderGrace > 0 {
+		line += fmt.Sprintf(" - waiting %ds for the leader", g.party.LeaderGrace)
+	} else if len(g.party.Invited) > 0 {
+		line += " - invited " + strings.Join(g.party.Invited, ", ")
+	}
+	text.Draw(screen, safeTrim(line, 82), basicfont.Face7x13, partyBtn.x, partyBtn.y+partyBtn.h+16, color.NRGBA{170, 170, 190, 255})
+}
+
+// updatePartyPvp handles clicks on the party row; it reports whether the
+// click was consumed.
+func (g *Game) updatePartyPvp(mx, my int) bool {
+	partyBtn, teamBtn, coopBtn, watchBtn := g.partyLayout()
+	switch {
+	case partyBtn.hit(mx, my):
+		if g.party != nil {
+			g.send("PartyLeave", protocol.PartyLeave{})
+		} else {
+			g.send("PartyCreate", protocol.PartyCreate{})
+		}
+	case !g.partyLeader():
+		return false
+	case teamBtn.hit(mx, my):
+		mode := "team"
+		if g.party.Queue == "team" {
+			mode = ""
+		}
+		g.send("PartyQueue", protocol.PartyQueue{Mode: mode})
+	case coopBtn.hit(mx, my):
+		g.send("PartyQueue", protocol.PartyQueue{Mode: "coop"})
+	case watchBtn.hit(mx, my):
+		g.send("PartyQueue", protocol.PartyQueue{Mode: "watch"})
+	default:
+		return false
+	}
+	g.pvpInputActive = false
+	return true
+}
+
+// drawPartyInvite shows a pending party invite below any co-op and duel
+// invites; it hides itself once it expires.
+func (g *Game) drawPartyInvite(screen *ebiten.Image) {
+	if g.partyInviteFrom == "" {
+		return
+	}
+	left := int(time.Until(g.partyInviteUntil).Seconds())
+	if left <= 0 {
+		g.partyInviteFrom = ""
+		return
+	}
+
+	w, h := 340, 74
+	x := (protocol.ScreenW - w) / 2
+	y := topBarH + 8
+	if g.coopInviteFrom != "" {
+		y += h + 8
+	}
+	if g.duelInviteFrom != "" {
+		y += h + 8
+	}
+	ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), float64(h), color.NRGBA{30, 30, 45, 240})
+	text.Draw(screen, g.partyInviteFrom+" invites you to a party", basicfont.Face7x13, x+12, y+18, color.White)
+	text.Draw(screen, fmt.Sprintf("Expires in %ds", left), basicfont.Face7x13, x+12, y+34, color.NRGBA{240, 196, 25, 255})
+
+	accept := rect{x: x + 12, y: y + 44, w: 90, h: 22}
+	decline := rect{x: x + 112, y: y + 44, w: 90, h: 22}
+	ebitenutil.DrawRect(screen, float64(accept.x), float64(accept.y), float64(accept.w), float64(accept.h), color.NRGBA{70, 110, 70, 255})
+	ebitenutil.DrawRect(screen, float64(decline.x), float64(decline.y), float64(decline.w), float64(decline.h), color.NRGBA{110, 70, 70, 255})
+	text.Draw(screen, "Accept", basicfont.Face7x13, accept.x+22, accept.y+15, color.White)
+	text.Draw(screen, "Decline", basicfont.Face7x13, decline.x+18, decline.y+15, color.White)
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		mx, my := g.logicalCursor()
+		if accept.hit(mx, my) {
+			g.send("PartyAccept", protocol.PartyAccept{Leader: g.partyInviteFrom})
+			g.partyInviteFrom = ""
+		} else if decline.hit(mx, my) {
+			g.send("PartyDecline", protocol.PartyDecline{Leader: g.partyInviteFrom})
+			g.partyInviteFrom = ""
+		}
+	}
+}
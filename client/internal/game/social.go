@@ -885,7 +885,7 @@ func (g *Game) drawSocial(screen *ebiten.Image) {
 		// Move action buttons 20% north
 		actionsTop := y + int(float64(h)*0.8) - 64
 		var promoteR, demoteR, kickR, transferR image.Rectangle
-		var unfriendR, messageR image.Rectangle
+		var unfriendR, messageR, coopR, duelR, partyR image.Rectangle
 		isSelf := strings.EqualFold(g.memberProfile.Name, g.name)
 		canKick := false
 		canPromote := false
@@ -929,6 +929,13 @@ func (g *Game) drawSocial(screen *ebiten.Image) {
 			}
 			messageR = btn(baseX, baseY, "Message", true)
 			unfriendR = btn(baseX+110, baseY, "Unfriend", true)
+			coopR = btn(baseX, baseY+30, "Co-op", true)
+			duelR = btn(baseX+110, baseY+30, "Duel", true)
+			partyR = btn(baseX+220, baseY+30, "Party", true)
+		} else if !isSelf && !g.profileFromFriends {
+			// guildmates can duel and party up without being friends
+			duelR = btn(x+14+84, actionsTop+60, "Duel", true)
+			partyR = btn(x+14+84+110, actionsTop+60, "Party", true)
 		}
 		mx, my := g.logicalCursor()
 		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
@@ -953,6 +960,21 @@ func (g *Game) drawSocial(screen *ebiten.Image) {
 				g.transferLeaderTarget = g.memberProfile.Name
 				g.transferLeaderConfirm = true
 			}
+			if isFriend && !isSelf && ptIn(mx, my, coopR) {
+				g.send("CoopInvite", protocol.CoopInvite{To: g.memberProfile.Name})
+				g.memberProfileOverlay = false
+				g.profileFromFriends = false
+			}
+			if !isSelf && (isFriend || !g.profileFromFriends) && ptIn(mx, my, duelR) {
+				g.send("InviteToDuel", protocol.InviteToDuel{Friend: g.memberProfile.Name})
+				g.memberProfileOverlay = false
+				g.profileFromFriends = false
+			}
+			if !isSelf && (isFriend || !g.profileFromFriends) && ptIn(mx, my, partyR) {
+				g.inviteToParty(g.memberProfile.Name)
+				g.memberProfileOverlay = false
+				g.profileFromFriends = false
+			}
 			if isFriend && !isSelf && ptIn(mx, my, unfriendR) {
 				g.send("RemoveFriend", protocol.RemoveFriend{Name: g.memberProfile.Name})
 			}
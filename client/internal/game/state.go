@@ -151,16 +151,65 @@ type Game struct {
 	xpBarHovered      bool   // true when mouse is hovering over XP bar
 
 	// Map tab (new hotspot UI)
-	mapHotspots  map[string][]Hotspot // key: mapID -> hotspots
-	hoveredHS    int                  // -1 if none
-	selectedHS   int                  // -1 if none
-	mapDebug     bool                 // add in Game struct
-	rectHotspots map[string][]HitRect
-	showRects    bool // optional debug outline toggle
+	mapHotspots map[string][]Hotspot // key: world ID -> hotspots (built from worlds)
+	worlds      map[string]protocol.WorldDef
+	hoveredHS   int  // -1 if none
+	selectedHS  int  // -1 if none
+	mapDebug    bool // add in Game struct
+	showRects   bool // optional debug outline toggle
+
+	// PvE campaign progression (server-authoritative)
+	campaign       map[string]protocol.CampaignNode // key: target map ID
+	campaignResult *protocol.CampaignResult         // last campaign run, shown on the end overlay
+	mapLockedMsg   string                           // hint shown after clicking a locked hotspot
+
+	// Endless survival
+	survivalBest   *protocol.SurvivalRecord // from Profile
+	endlessBattle  bool                     // current battle is a survival run (Init.Endless)
+	survivalWave   int                      // current wave in a running survival match
+	survivalNextAt time.Time                // when the next wave is due
+	survivalResult *protocol.SurvivalResult // last run, shown on the end overlay
+	survivalBtn    rect
+
+	// Rule mutators and the weekly challenge
+	rules           *protocol.Mutators // current battle's ruleset (Init.Rules)
+	mutatorPresets  []protocol.Mutators
+	rulesIdx        int // friendly rules: 0 = standard, i = mutatorPresets[i-1]
+	weeklyRules     protocol.Mutators
+	fogLayer        *ebiten.Image // fog of war overlay (see fog.go)
+	challengeWeek   string
+	weeklyBest      *protocol.ChallengeRecord // from Profile
+	challengeResult *protocol.ChallengeResult // last challenge, shown on the end overlay
+
+	// Co-op PvE
+	allies         map[int64]bool // teammates in the current battle (Init.Allies)
+	coopInviteFrom string         // pending invite shown on the home screen
+	coopInviteMap  string
+	coopNote       string // short status line (invite sent / declined)
+	coopNoteUntil  time.Time
+
+	// Duel invites from friends and guildmates
+	duelInviteFrom  string
+	duelInviteVia   string // "friend" | "guild"
+	duelInviteUntil time.Time
+
+	// Party (PartyState) and a pending party invite
+	party            *protocol.PartyState
+	partyInviteFrom  string
+	partyInviteUntil time.Time
+	spectating       string // player whose battle we watch (watch party)
 
 	currentArena string
 	pendingArena string
 
+	// Server-wide announcement banner from an operator
+	announcement      string
+	announcementUntil time.Time
+
+	// Centre-screen battle banner (boss phases, survival waves)
+	battleBanner      string
+	battleBannerUntil time.Time
+
 	// HP bar FX (recent-damage yellow chip)
 	hpFxUnits map[int64]*hpFx
 	hpFxBases map[int64]*hpFx
@@ -190,6 +239,8 @@ type Game struct {
 	cameraLeftDragInitialY float64
 	// Flag to center camera on player's base once bases are populated
 	needsCameraCenter bool
+	// Battlefield size in world units from Init (see camera.go)
+	worldW, worldH float64
 
 	// --- PvP UI state ---
 	pvpStatus      string // status line at the top of the PvP tab
@@ -199,6 +250,20 @@ type Game struct {
 	pvpCodeInput   string // what the user typed into the "Join with code" field
 	pvpInputActive bool   // text input focus for the code field
 	pvpCodeArea    rect   // This will be the pvpcode copy area
+	// 2v2 queue / friendly lobby
+	teamQueued  bool
+	teamWaiting int
+	teamLobby   *protocol.TeamLobbyState // nil when not in a lobby
+	// draft mode
+	draftQueued   bool
+	draft         *protocol.DraftState // non-nil while a draft runs
+	draftDeadline time.Time
+	// friendly duel lobby (RoomStatus); kept across matches for rematches
+	lobby *protocol.RoomStatus
+	// tournaments overlay (list, or one bracket when tourney is set)
+	showTournaments bool
+	tournaments     []protocol.TournamentSummary
+	tourney         *protocol.Tournament
 	// profile PvP
 	pvpRating int
 	pvpRank   string
@@ -206,6 +271,17 @@ type Game struct {
 	pvpLeaders  []protocol.LeaderboardEntry
 	lbLastReq   time.Time
 	lbLastStamp int64 // server GeneratedAt (optional)
+	// Survival leaderboard (toggled on the same panel)
+	lbSurvival    bool
+	survLeaders   []protocol.SurvivalLeaderboardEntry
+	lbPvpBtn      rect
+	lbSurvivalBtn rect
+	survLbLastReq time.Time
+	// Weekly challenge leaderboard (third toggle)
+	lbChallenge    bool
+	challLeaders   []protocol.ChallengeLeaderboardEntry
+	lbChallengeBtn rect
+	challLbLastReq time.Time
 
 	// --- Timer UI state ---
 	timerRemainingSeconds int    // remaining seconds
@@ -0,0 +1,130 @@
+package game
+
+import (
+	"image/color"
+	"strings"
+
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// teamLayout places the 2v2 controls in a column right of the 1v1 ones.
+// The join buttons reuse the friendly code field.
+func (g *Game) teamLayout() (queueBtn, lobbyBtn, joinA, joinB rect) {
+	const btnH = 28
+	_, _, createBtn, _, _, joinBtn := g.pvpLayout()
+	x := joinBtn.x + joinBtn.w + 16
+	queueBtn = rect{x: x, y: createBtn.y - 44, w: 150, h: btnH}
+	lobbyBtn = rect{x: x, y: createBtn.y, w: 150, h: btnH}
+	joinA = rect{x: x, y: joinBtn.y, w: 120, h: btnH}
+	joinB = rect{x: x + 126, y: joinBtn.y, w: 120, h: btnH}
+	return
+}
+
+// teamBottom is the lowest y used by the 2v2 controls (roster included).
+func (g *Game) teamBottom() int {
+	_, _, joinA, _ := g.teamLayout()
+	if g.teamLobby == nil {
+		return joinA.y + joinA.h
+	}
+	return joinA.y + joinA.h + 18 + 2*16
+}
+
+// drawTeamPvp draws the 2v2 queue/lobby buttons and the lobby roster.
+func (g *Game) drawTeamPvp(screen *ebiten.Image) {
+	queueBtn, lobbyBtn, joinA, joinB := g.teamLayout()
+	mx, my := ebiten.CursorPosition()
+
+	queueLabel := "Queue 2v2"
+	if g.teamQueued {
+		queueLabel = "Leave 2v2 Queue"
+	}
+	lobbyLabel := "Host 2v2 Lobby"
+	if g.teamLobby != nil {
+		lobbyLabel = "Leave 2v2 Lobby"
+	}
+	btns := []struct {
+		r     rect
+		label string
+	}{
+		{queueBtn, queueLabel},
+		{lobbyBtn, lobbyLabel},
+		{joinA, "Join Side A"},
+		{joinB, "Join Side B"},
+	}
+	for _, b := range btns {
+		if g.fantasyUI != nil {
+			state := ButtonNormal
+			if b.r.hit(mx, my) {
+				state = ButtonHover
+			}
+			g.fantasyUI.DrawThemedButtonWithStyle(screen, b.r.x, b.r.y, b.r.w, b.r.h, b.label, state, true)
+		} else {
+			ebitenutil.DrawRect(screen, float64(b.r.x), float64(b.r.y), float64(b.r.w), float64(b.r.h), color.NRGBA{60, 60, 80, 255})
+			text.Draw(screen, b.label, basicfont.Face7x13, b.r.x+8, b.r.y+18, color.White)
+		}
+	}
+
+	// Lobby roster below the join buttons
+	if g.teamLobby == nil {
+		return
+	}
+	x, y := joinA.x, joinA.y+joinA.h+18
+	text.Draw(screen, "Lobby "+g.teamLobby.Code, basicfont.Face7x13, x, y, color.NRGBA{240, 196, 25, 255})
+	for t, side := range g.teamLobby.Teams {
+		label := "A: "
+		if t == 1 {
+			label = "B: "
+		}
+		names := strings.Join(side, ", ")
+		if names == "" {
+			names = "—"
+		}
+		text.Draw(screen, label+safeTrim(names, 30), basicfont.Face7x13, x, y+16*(t+1), color.White)
+	}
+}
+
+// updateTeamPvp handles clicks on the 2v2 controls; it reports whether the
+// click was consumed.
+func (g *Game) updateTeamPvp(mx, my int) bool {
+	queueBtn, lobbyBtn, joinA, joinB := g.teamLayout()
+	switch {
+	case queueBtn.hit(mx, my):
+		if g.teamQueued {
+			g.teamQueued = false
+			g.pvpStatus = "Left 2v2 queue."
+			g.send("LeaveTeamQueue", protocol.LeaveTeamQueue{})
+		} else {
+			g.teamQueued = true
+			g.pvpStatus = "Queueing for 2v2…"
+			g.send("JoinTeamQueue", protocol.JoinTeamQueue{})
+		}
+	case lobbyBtn.hit(mx, my):
+		if g.teamLobby != nil {
+			g.send("TeamLobbyLeave", protocol.TeamLobbyLeave{})
+		} else {
+			g.pvpStatus = "Opening 2v2 lobby…"
+			g.send("TeamLobbyCreate", protocol.TeamLobbyCreate{})
+		}
+	case joinA.hit(mx, my), joinB.hit(mx, my):
+		code := strings.ToUpper(strings.TrimSpace(g.pvpCodeInput))
+		if code == "" {
+			g.pvpStatus = "Enter a code first."
+			break
+		}
+		team := 0
+		if joinB.hit(mx, my) {
+			team = 1
+		}
+		g.pvpStatus = "Joining 2v2 lobby " + code + "…"
+		g.send("TeamLobbyJoin", protocol.TeamLobbyJoin{Code: code, Team: team})
+	default:
+		return false
+	}
+	g.pvpInputActive = false
+	return true
+}
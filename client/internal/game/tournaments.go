@@ -0,0 +1,249 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+const tourneyRowH = 22
+
+// tournamentsBtnRect sits under the friendly rules picker on the PvP tab.
+func (g *Game) tournamentsBtnRect() rect {
+	rb := g.rulesBtnRect()
+	return rect{x: rb.x, y: rb.y + rb.h + 10, w: 150, h: 28}
+}
+
+func (g *Game) drawTournamentsBtn(screen *ebiten.Image) {
+	r := g.tournamentsBtnRect()
+	mx, my := ebiten.CursorPosition()
+	if g.fantasyUI != nil {
+		state := ButtonNormal
+		if r.hit(mx, my) {
+			state = ButtonHover
+		}
+		g.fantasyUI.DrawThemedButtonWithStyle(screen, r.x, r.y, r.w, r.h, "Tournaments", state, true)
+		return
+	}
+	ebitenutil.DrawRect(screen, float64(r.x), float64(r.y), float64(r.w), float64(r.h), color.NRGBA{60, 60, 80, 255})
+	text.Draw(screen, "Tournaments", basicfont.Face7x13, r.x+8, r.y+18, color.White)
+}
+
+func (g *Game) openTournaments() {
+	g.showTournaments = true
+	g.tourney = nil
+	g.send("ListTournaments", protocol.ListTournaments{})
+}
+
+// tourneyLayout places the overlay buttons along the bottom of the panel.
+func (g *Game) tourneyLayout() (createA, createB, joinBtn, closeBtn rect) {
+	y := protocol.ScreenH - menuBarH - 48
+	createA = rect{x: pad, y: y, w: 130, h: 32}
+	createB = rect{x: pad + 138, y: y, w: 130, h: 32}
+	joinBtn = rect{x: pad, y: y, w: 130, h: 32}
+	closeBtn = rect{x: protocol.ScreenW - pad - 110, y: y, w: 110, h: 32}
+	return
+}
+
+func (g *Game) tourneyRowRect(i int) rect {
+	return rect{x: pad, y: topBarH + 44 + i*tourneyRowH, w: protocol.ScreenW - 2*pad, h: tourneyRowH - 2}
+}
+
+// tourneyJoined reports whether we are signed up for the open tournament.
+func (g *Game) tourneyJoined() bool {
+	if g.tourney == nil {
+		return false
+	}
+	for _, p := range g.tourney.Players {
+		if strings.EqualFold(p.Name, g.name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) drawTournaments(screen *ebiten.Image) {
+	if !g.showTournaments {
+		return
+	}
+	ebitenutil.DrawRect(screen, 0, float64(topBarH), float64(protocol.ScreenW), float64(protocol.ScreenH-topBarH-menuBarH), color.NRGBA{20, 20, 30, 250})
+	gold := color.NRGBA{240, 196, 25, 255}
+	dim := color.NRGBA{170, 170, 190, 255}
+	mx, my := ebiten.CursorPosition()
+
+	createA, createB, joinBtn, closeBtn := g.tourneyLayout()
+	btns := []struct {
+		r     rect
+		label string
+	}{{closeBtn, "Back"}}
+
+	if t := g.tourney; t == nil {
+		text.Draw(screen, "Tournaments", basicfont.Face7x13, pad, topBarH+24, gold)
+		if len(g.tournaments) == 0 {
+			text.Draw(screen, "No tournaments yet - create one!", basicfont.Face7x13, pad, topBarH+56, dim)
+		}
+		for i, s := range g.tournaments {
+			r := g.tourneyRowRect(i)
+			if r.y+r.h > createA.y-8 {
+				break
+			}
+			if r.hit(mx, my) {
+				ebitenutil.DrawRect(screen, float64(r.x), float64(r.y), float64(r.w), float64(r.h), color.NRGBA{50, 50, 70, 255})
+			}
+			status := s.Status
+			switch s.Status {
+			case "signup":
+				status = "signup " + tourneyCountdown(s.SignupEnds)
+			case "finished":
+				status = "won by " + s.Champion
+			}
+			line := fmt.Sprintf("%-28s %-6s %2d/%-2d %s", safeTrim(s.Name, 28), s.Format, s.Players, s.MaxPlayers, status)
+			text.Draw(screen, safeTrim(line, 82), basicfont.Face7x13, r.x+4, r.y+15, color.White)
+		}
+		btns = append(btns, struct {
+			r     rect
+			label string
+		}{createA, "New Single"}, struct {
+			r     rect
+			label string
+		}{createB, "New Double"})
+	} else {
+		format := "Single elimination"
+		if t.Format == "double" {
+			format = "Double elimination"
+		}
+		text.Draw(screen, safeTrim(t.Name, 60), basicfont.Face7x13, pad, topBarH+24, gold)
+		sub := fmt.Sprintf("%s - %d/%d players - %s", format, len(t.Players), t.MaxPlayers, t.Status)
+		if t.Status == "signup" {
+			sub += ", starts in " + tourneyCountdown(t.SignupEnds)
+		}
+		if t.Champion != "" {
+			sub += " - champion " + t.Champion
+		}
+		text.Draw(screen, safeTrim(sub, 82), basicfont.Face7x13, pad, topBarH+40, dim)
+
+		row := 0
+		if len(t.Matches) == 0 {
+			for _, p := range t.Players {
+				r := g.tourneyRowRect(row)
+				if r.y+r.h > joinBtn.y-8 {
+					break
+				}
+				text.Draw(screen, fmt.Sprintf("%-24s %d", safeTrim(p.Name, 24), p.Rating), basicfont.Face7x13, r.x+4, r.y+15, color.White)
+				row++
+			}
+		}
+		for _, m := range t.Matches {
+			r := g.tourneyRowRect(row)
+			if r.y+r.h > joinBtn.y-8 {
+				break
+			}
+			if m.A == "" && m.B == "" {
+				continue
+			}
+			line := fmt.Sprintf("%s%d  %s vs %s", m.Bracket, m.Round, tourneyName(m.A), tourneyName(m.B))
+			col := color.Color(color.White)
+			switch m.Status {
+			case "done":
+				line += "  -> " + m.Winner
+				if m.Walkover {
+					line += " (w/o)"
+				}
+				col = dim
+			case "playing":
+				line += "  (playing)"
+				col = color.NRGBA{120, 220, 120, 255}
+			case "ready":
+				line += "  (waiting for players)"
+			}
+			text.Draw(screen, safeTrim(line, 82), basicfont.Face7x13, r.x+4, r.y+15, col)
+			row++
+		}
+		if t.Status == "signup" {
+			label := "Join"
+			if g.tourneyJoined() {
+				label = "Leave"
+			}
+			btns = append(btns, struct {
+				r     rect
+				label string
+			}{joinBtn, label})
+		}
+	}
+
+	for _, b := range btns {
+		if g.fantasyUI != nil {
+			state := ButtonNormal
+			if b.r.hit(mx, my) {
+				state = ButtonHover
+			}
+			g.fantasyUI.DrawThemedButtonWithStyle(screen, b.r.x, b.r.y, b.r.w, b.r.h, b.label, state, true)
+		} else {
+			ebitenutil.DrawRect(screen, float64(b.r.x), float64(b.r.y), float64(b.r.w), float64(b.r.h), color.NRGBA{60, 60, 80, 255})
+			text.Draw(screen, b.label, basicfont.Face7x13, b.r.x+8, b.r.y+20, color.White)
+		}
+	}
+}
+
+// updateTournaments handles clicks in the tournaments overlay.
+func (g *Game) updateTournaments(mx, my int) {
+	if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return
+	}
+	createA, createB, joinBtn, closeBtn := g.tourneyLayout()
+	t := g.tourney
+	switch {
+	case closeBtn.hit(mx, my):
+		if t != nil {
+			g.openTournaments()
+		} else {
+			g.showTournaments = false
+		}
+	case t == nil && (createA.hit(mx, my) || createB.hit(mx, my)):
+		format := "single"
+		if createB.hit(mx, my) {
+			format = "double"
+		}
+		g.send("CreateTournament", protocol.CreateTournament{Name: g.name + "'s Cup", Format: format})
+	case t == nil:
+		for i, s := range g.tournaments {
+			if g.tourneyRowRect(i).hit(mx, my) {
+				g.send("GetTournament", protocol.GetTournament{ID: s.ID})
+				break
+			}
+		}
+	case t.Status == "signup" && joinBtn.hit(mx, my):
+		if g.tourneyJoined() {
+			g.send("LeaveTournament", protocol.LeaveTournament{ID: t.ID})
+		} else {
+			g.send("JoinTournament", protocol.JoinTournament{ID: t.ID})
+		}
+	}
+}
+
+func tourneyName(name string) string {
+	if name == "" {
+		return "?"
+	}
+	return safeTrim(name, 16)
+}
+
+func tourneyCountdown(endsMs int64) string {
+	left := time.Until(time.UnixMilli(endsMs))
+	if left < 0 {
+		left = 0
+	}
+	if left >= time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(left.Hours()), int(left.Minutes())%60)
+	}
+	return fmt.Sprintf("%d:%02d", int(left.Minutes()), int(left.Seconds())%60)
+}
@@ -55,21 +55,17 @@ type connResult struct {
 
 // ---- Map hotspot types used across files ----
 
-type HitRect struct {
-	ID, Name, Info string
-	L, T, R, B     float64 // normalized 0..1
-}
-
 type HSRect struct {
 	Left, Top, Right, Bottom float64 // normalized 0..1
 }
 
 type Hotspot struct {
 	ID, Name, Info string
-	X, Y           float64 // normalized center
-	Rpx            int     // draw radius / hit radius (px)
-	HitRect        *HSRect // optional precise rect for hit-testing
-	TargetMapID    string  // when set, which arena/map to launch
+	X, Y           float64  // normalized center
+	Rpx            int      // draw radius / hit radius (px)
+	HitRect        *HSRect  // optional precise rect for hit-testing
+	TargetMapID    string   // when set, which arena/map to launch
+	Requires       []string // world-level unlock requirements (map IDs)
 }
 
 // World map image fallback id used across files
@@ -18,6 +18,11 @@ type RenderUnit struct {
 	Range              int
 	Particle           string
 	AnimationData      *UnitAnimationData // Animation system data
+	// Boss units (PvE)
+	Boss      bool
+	Phase     int
+	PhaseName string
+	Portrait  string
 }
 
 type RenderProjectile struct {
@@ -55,6 +60,19 @@ type World struct {
 	Obstacles       []protocol.Obstacle // Current map obstacles
 	Lanes           []protocol.Lane     // Current map lanes
 	SpawnAnimations []*SpawnAnimation   // Active spawn animations
+	W, H            float64             // world size in world units (0 = default world)
+}
+
+// size is the world size, falling back to the default world.
+func (w *World) size() (float64, float64) {
+	ww, wh := w.W, w.H
+	if ww <= 0 {
+		ww = protocol.DefaultWorldW
+	}
+	if wh <= 0 {
+		wh = protocol.DefaultWorldH
+	}
+	return ww, wh
 }
 
 func buildWorldFromSnapshot(s protocol.FullSnapshot, currentMapDef *protocol.MapDef) *World {
@@ -70,6 +88,7 @@ func buildWorldFromSnapshot(s protocol.FullSnapshot, currentMapDef *protocol.Map
 			PrevX: float64(u.X), PrevY: float64(u.Y),
 			TargetX: float64(u.X), TargetY: float64(u.Y),
 			HP: u.HP, MaxHP: u.MaxHP, OwnerID: u.OwnerID, Class: u.Class, Range: u.Range, Particle: u.Particle,
+			Boss: u.Boss, Phase: u.Phase, PhaseName: u.PhaseName, Portrait: u.Portrait,
 			AnimationData: NewUnitAnimationData(), // Initialize animation system
 		}
 	}
@@ -108,10 +127,15 @@ func (w *World) ApplyDelta(d protocol.StateDelta) {
 		ru.OwnerID, ru.Class = u.OwnerID, u.Class
 		ru.Range = u.Range
 		ru.Particle = u.Particle
+		ru.Boss, ru.Phase, ru.PhaseName, ru.Portrait = u.Boss, u.Phase, u.PhaseName, u.Portrait
 	}
 	for _, id := range d.UnitsRemoved {
 		delete(w.Units, id)
 	}
+	// Fog of war: the unit is still alive, we just lost sight of it.
+	for _, id := range d.LeftVision {
+		delete(w.Units, id)
+	}
 
 	// Handle projectiles from server
 	if len(d.Projectiles) > 0 {
@@ -160,11 +184,12 @@ func (w *World) LerpPositions() {
 // Check if a point collides with any obstacle
 func (w *World) IsPointInObstacle(x, y float64) bool {
 	for _, obstacle := range w.Obstacles {
-		// Convert normalized coordinates to screen coordinates for collision check
-		obsX := obstacle.X * float64(protocol.ScreenW)
-		obsY := obstacle.Y * float64(protocol.ScreenH)
-		obsW := obstacle.Width * float64(protocol.ScreenW)
-		obsH := obstacle.Height * float64(protocol.ScreenH)
+		// Convert normalized coordinates to world coordinates for collision check
+		ww, wh := w.size()
+		obsX := obstacle.X * ww
+		obsY := obstacle.Y * wh
+		obsW := obstacle.Width * ww
+		obsH := obstacle.Height * wh
 
 		// Simple AABB collision detection
 		if x >= obsX && x <= obsX+obsW && y >= obsY && y <= obsY+obsH {
@@ -185,9 +210,10 @@ func (w *World) FindClosestLanePoint(x, y float64) (float64, float64) {
 
 	for _, lane := range w.Lanes {
 		for _, point := range lane.Points {
-			// Convert normalized coordinates to screen coordinates
-			px := point.X * float64(protocol.ScreenW)
-			py := point.Y * float64(protocol.ScreenH)
+			// Convert normalized coordinates to world coordinates
+			ww, wh := w.size()
+			px := point.X * ww
+			py := point.Y * wh
 
 			dist := (px-x)*(px-x) + (py-y)*(py-y)
 			if dist < minDist {
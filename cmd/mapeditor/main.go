@@ -140,6 +140,16 @@ type editor struct {
 	statusLogDragging   bool
 	statusLogDragStartX int
 	statusLogDragStartY int
+
+	// world mode (see world.go)
+	worldMode     bool
+	world         protocol.WorldDef
+	worldList     []protocol.MapInfo
+	worldBg       *ebiten.Image
+	worldSel      int
+	worldField    int    // focused field in the top panel, -1 = none
+	worldReqInput string // Requires being typed, comma separated
+	worldDrag     string // ""|"move"|"resize"
 }
 
 func getenv(k, def string) string {
@@ -311,6 +321,14 @@ func (e *editor) Update() error {
 				}
 			case "Maps":
 				// ignore
+			case "WorldDef":
+				var wd protocol.WorldDefMsg
+				_ = json.Unmarshal(m.Data, &wd)
+				e.applyWorld(wd.Def)
+			case "Worlds":
+				var ws protocol.Worlds
+				_ = json.Unmarshal(m.Data, &ws)
+				e.worldList = ws.Items
 			case "Error":
 				var em protocol.ErrorMsg
 				_ = json.Unmarshal(m.Data, &em)
@@ -321,6 +339,12 @@ func (e *editor) Update() error {
 		}
 	}
 done:
+	if inpututil.IsKeyJustPressed(ebiten.KeyW) && (ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta)) {
+		e.toggleWorldMode()
+	}
+	if e.worldMode {
+		return e.updateWorld()
+	}
 	mx, my := ebiten.CursorPosition()
 
 	// Handle camera controls for map editor
@@ -1654,6 +1678,11 @@ func (e *editor) Draw(screen *ebiten.Image) {
 		return
 	}
 
+	if e.worldMode {
+		e.drawWorld(screen)
+		return
+	}
+
 	// Top UI bar background to prevent overlap with canvas - optimized single draw
 	ebitenutil.DrawRect(screen, 0, 0, float64(vw), float64(topUIH), color.NRGBA{28, 28, 40, 255})
 
@@ -2629,6 +2658,7 @@ func (e *editor) Draw(screen *ebiten.Image) {
 			"  Drag: Move/resize selected elements",
 			"  Delete: Remove selected element",
 			"  Ctrl+S: Save map",
+			"  Ctrl+W: Switch to world map editing",
 			"  G: Toggle grid overlay",
 			"  D: Toggle lane direction (when lane selected)",
 			"",
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rumble/shared/protocol"
+
+	"github.com/gorilla/websocket"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// World mode edits the world map (WorldDef) instead of a battle map:
+// drag on empty space to add a hotspot, drag a hotspot to move it, drag its
+// bottom-right corner to resize, click a field in the top panel to edit it.
+
+const worldTopH = 120
+
+var worldFieldNames = []string{"ID", "Name", "Info", "Target map", "Requires"}
+
+// sendWS writes one envelope to the server, if connected.
+func (e *editor) sendWS(typ string, data interface{}) error {
+	if e.ws == nil {
+		return fmt.Errorf("not connected")
+	}
+	b, _ := json.Marshal(struct {
+		Type string      `json:"type"`
+		Data interface{} `json:"data"`
+	}{Type: typ, Data: data})
+	return e.ws.WriteMessage(websocket.TextMessage, b)
+}
+
+func (e *editor) toggleWorldMode() {
+	e.worldMode = !e.worldMode
+	e.worldSel, e.worldField, e.worldDrag = -1, -1, ""
+	if !e.worldMode {
+		e.status = "Map mode"
+		return
+	}
+	if e.world.ID == "" {
+		e.world = protocol.WorldDef{ID: "rumble_world", Name: "Rumble World"}
+	}
+	e.status = "World mode: loading " + e.world.ID
+	_ = e.sendWS("ListWorlds", protocol.ListWorlds{})
+	if err := e.sendWS("GetWorld", protocol.GetWorld{ID: e.world.ID}); err != nil {
+		e.loadWorldBg()
+		e.status = "World mode (offline)"
+	}
+}
+
+func (e *editor) applyWorld(def protocol.WorldDef) {
+	e.world = def
+	e.worldSel, e.worldField, e.worldDrag = -1, -1, ""
+	e.loadWorldBg()
+	e.status = fmt.Sprintf("Loaded world: %s (%d hotspots)", def.Name, len(def.Hotspots))
+}
+
+// loadWorldBg looks for the world background next to the client's map art.
+func (e *editor) loadWorldBg() {
+	name := e.world.Background
+	if name == "" {
+		name = e.world.ID
+	}
+	e.worldBg = nil
+	for _, p := range []string{
+		filepath.Join("..", "..", "client", "internal", "game", "assets", "maps", name+".png"),
+		filepath.Join("..", "..", "client", "internal", "game", "assets", "maps", name+".jpg"),
+		filepath.Join("maps", name+".png"),
+	} {
+		if img, _, err := ebitenutil.NewImageFromFile(p); err == nil {
+			e.worldBg = img
+			return
+		}
+	}
+}
+
+// worldCanvas is where the world background is drawn, letterboxed below
+// the top panel.
+func (e *editor) worldCanvas() (x, y, w, h int) {
+	vw, vh := ebiten.WindowSize()
+	vh -= worldTopH
+	if e.worldBg == nil {
+		return 0, worldTopH, vw, vh
+	}
+	iw, ih := e.worldBg.Bounds().Dx(), e.worldBg.Bounds().Dy()
+	s := float64(vw) / float64(iw)
+	if sy := float64(vh) / float64(ih); sy < s {
+		s = sy
+	}
+	w, h = int(float64(iw)*s), int(float64(ih)*s)
+	return (vw - w) / 2, worldTopH + (vh-h)/2, w, h
+}
+
+func (e *editor) worldRectPx(r protocol.WorldRect) (x0, y0, x1, y1 int) {
+	cx, cy, cw, ch := e.worldCanvas()
+	return cx + int(r.Left*float64(cw)), cy + int(r.Top*float64(ch)),
+		cx + int(r.Right*float64(cw)), cy + int(r.Bottom*float64(ch))
+}
+
+func (e *editor) worldNorm(mx, my int) (float64, float64) {
+	cx, cy, cw, ch := e.worldCanvas()
+	return clamp01(float64(mx-cx) / float64(cw)), clamp01(float64(my-cy) / float64(ch))
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func (e *editor) worldFieldRect(i int) (x, y, w, h int) {
+	return 8 + (i%3)*260, 36 + (i/3)*28, 250, 22
+}
+
+func (e *editor) worldFieldValue(hs *protocol.WorldHotspot, i int) *string {
+	switch i {
+	case 0:
+		return &hs.ID
+	case 1:
+		return &hs.Name
+	case 2:
+		return &hs.Info
+	case 3:
+		return &hs.TargetMapID
+	}
+	return nil
+}
+
+func (e *editor) updateWorld() error {
+	mx, my := ebiten.CursorPosition()
+	ctrl := ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta)
+	var sel *protocol.WorldHotspot
+	if e.worldSel >= 0 && e.worldSel < len(e.world.Hotspots) {
+		sel = &e.world.Hotspots[e.worldSel]
+	}
+
+	// Text entry into the focused field
+	if sel != nil && e.worldField >= 0 {
+		for _, r := range ebiten.AppendInputChars(nil) {
+			if r < 32 {
+				continue
+			}
+			if p := e.worldFieldValue(sel, e.worldField); p != nil {
+				*p += string(r)
+			} else {
+				e.worldReqInput += string(r)
+			}
+		}
+		for _, k := range inpututil.AppendJustPressedKeys(nil) {
+			switch k {
+			case ebiten.KeyBackspace:
+				if p := e.worldFieldValue(sel, e.worldField); p != nil {
+					if len(*p) > 0 {
+						*p = (*p)[:len(*p)-1]
+					}
+				} else if len(e.worldReqInput) > 0 {
+					e.worldReqInput = e.worldReqInput[:len(e.worldReqInput)-1]
+				}
+			case ebiten.KeyTab:
+				e.commitWorldField(sel)
+				e.worldField = (e.worldField + 1) % len(worldFieldNames)
+				e.beginWorldField(sel)
+			case ebiten.KeyEnter, ebiten.KeyEscape:
+				e.commitWorldField(sel)
+				e.worldField = -1
+			}
+		}
+		if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			return nil
+		}
+		e.commitWorldField(sel)
+		e.worldField = -1
+	}
+
+	for _, k := range inpututil.AppendJustPressedKeys(nil) {
+		switch {
+		case k == ebiten.KeyS && ctrl:
+			e.saveWorld()
+		case k == ebiten.KeyN && ctrl:
+			e.world = protocol.WorldDef{ID: "new_world", Name: "New World"}
+			e.worldSel = -1
+			e.loadWorldBg()
+			e.status = "New world created"
+		case (k == ebiten.KeyPageDown || k == ebiten.KeyPageUp) && len(e.worldList) > 0:
+			i := 0
+			for j, w := range e.worldList {
+				if w.ID == e.world.ID {
+					i = j
+				}
+			}
+			if k == ebiten.KeyPageDown {
+				i = (i + 1) % len(e.worldList)
+			} else {
+				i = (i + len(e.worldList) - 1) % len(e.worldList)
+			}
+			_ = e.sendWS("GetWorld", protocol.GetWorld{ID: e.worldList[i].ID})
+		case k == ebiten.KeyDelete && sel != nil:
+			e.world.Hotspots = append(e.world.Hotspots[:e.worldSel], e.world.Hotspots[e.worldSel+1:]...)
+			e.worldSel = -1
+			e.status = "Hotspot deleted"
+			return nil
+		}
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if my < worldTopH {
+			for i := range worldFieldNames {
+				x, y, w, h := e.worldFieldRect(i)
+				if sel != nil && mx >= x && mx < x+w && my >= y && my < y+h {
+					e.worldField = i
+					e.beginWorldField(sel)
+				}
+			}
+			return nil
+		}
+		e.worldDrag = ""
+		for i := len(e.world.Hotspots) - 1; i >= 0; i-- {
+			x0, y0, x1, y1 := e.worldRectPx(e.world.Hotspots[i].Rect)
+			if mx >= x1-6 && mx <= x1+6 && my >= y1-6 && my <= y1+6 {
+				e.worldSel, e.worldDrag = i, "resize"
+				break
+			}
+			if mx >= x0 && mx <= x1 && my >= y0 && my <= y1 {
+				e.worldSel, e.worldDrag = i, "move"
+				break
+			}
+		}
+		if e.worldDrag == "" {
+			nx, ny := e.worldNorm(mx, my)
+			n := len(e.world.Hotspots) + 1
+			e.world.Hotspots = append(e.world.Hotspots, protocol.WorldHotspot{
+				ID:   fmt.Sprintf("hotspot_%d", n),
+				Name: fmt.Sprintf("Hotspot %d", n),
+				Rect: protocol.WorldRect{Left: nx, Top: ny, Right: nx, Bottom: ny},
+			})
+			e.worldSel, e.worldDrag = len(e.world.Hotspots)-1, "resize"
+			e.status = "Hotspot added - set its target map"
+		}
+		e.lastMx, e.lastMy = mx, my
+		return nil
+	}
+
+	if e.worldDrag != "" && sel != nil {
+		if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+			r := &sel.Rect
+			if r.Right < r.Left {
+				r.Left, r.Right = r.Right, r.Left
+			}
+			if r.Bottom < r.Top {
+				r.Top, r.Bottom = r.Bottom, r.Top
+			}
+			e.worldDrag = ""
+			return nil
+		}
+		_, _, cw, ch := e.worldCanvas()
+		dx := float64(mx-e.lastMx) / float64(cw)
+		dy := float64(my-e.lastMy) / float64(ch)
+		r := &sel.Rect
+		if e.worldDrag == "move" {
+			dx = clampDelta(dx, r.Left, r.Right)
+			dy = clampDelta(dy, r.Top, r.Bottom)
+			r.Left += dx
+			r.Right += dx
+			r.Top += dy
+			r.Bottom += dy
+		} else {
+			r.Right, r.Bottom = e.worldNorm(mx, my)
+		}
+		e.lastMx, e.lastMy = mx, my
+	}
+	return nil
+}
+
+// clampDelta keeps a moved span [lo,hi] inside 0..1.
+func clampDelta(d, lo, hi float64) float64 {
+	if lo+d < 0 {
+		return -lo
+	}
+	if hi+d > 1 {
+		return 1 - hi
+	}
+	return d
+}
+
+func (e *editor) beginWorldField(sel *protocol.WorldHotspot) {
+	if e.worldField == 4 {
+		e.worldReqInput = strings.Join(sel.Requires, ", ")
+	}
+}
+
+// commitWorldField turns the Requires text back into map IDs.
+func (e *editor) commitWorldField(sel *protocol.WorldHotspot) {
+	if e.worldField != 4 {
+		return
+	}
+	sel.Requires = nil
+	for _, id := range strings.Split(e.worldReqInput, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			sel.Requires = append(sel.Requires, id)
+		}
+	}
+}
+
+func (e *editor) saveWorld() {
+	if strings.TrimSpace(e.world.ID) == "" {
+		e.world.ID = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(e.world.Name)), " ", "_")
+	}
+	localDir := "local_worlds"
+	_ = os.MkdirAll(localDir, 0o755)
+	path := filepath.Join(localDir, e.world.ID+".json")
+	b, _ := json.MarshalIndent(e.world, "", "  ")
+	localErr := os.WriteFile(path, b, 0o644)
+
+	err := e.sendWS("SaveWorld", protocol.SaveWorld{Def: e.world})
+	switch {
+	case err != nil && localErr == nil:
+		e.status = "Saved locally to " + path + " (server: " + err.Error() + ")"
+	case err != nil:
+		e.status = "Save failed: " + err.Error()
+	case localErr != nil:
+		e.status = "Saved to server, local save failed: " + localErr.Error()
+	default:
+		e.status = "World saved to server and locally"
+	}
+}
+
+func (e *editor) drawWorld(screen *ebiten.Image) {
+	vw, vh := ebiten.WindowSize()
+	ebitenutil.DrawRect(screen, 0, 0, float64(vw), float64(vh), color.NRGBA{18, 18, 26, 255})
+
+	cx, cy, cw, ch := e.worldCanvas()
+	if e.worldBg != nil {
+		iw, ih := e.worldBg.Bounds().Dx(), e.worldBg.Bounds().Dy()
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(float64(cw)/float64(iw), float64(ch)/float64(ih))
+		op.GeoM.Translate(float64(cx), float64(cy))
+		op.Filter = ebiten.FilterLinear
+		screen.DrawImage(e.worldBg, op)
+	} else {
+		ebitenutil.DrawRect(screen, float64(cx), float64(cy), float64(cw), float64(ch), color.NRGBA{34, 34, 51, 255})
+	}
+
+	for i, hs := range e.world.Hotspots {
+		x0, y0, x1, y1 := e.worldRectPx(hs.Rect)
+		col := color.NRGBA{0x66, 0x99, 0xcc, 0xff}
+		if i == e.worldSel {
+			col = color.NRGBA{240, 196, 25, 255}
+		}
+		if hs.TargetMapID == "" {
+			col = color.NRGBA{220, 90, 90, 255}
+		}
+		ebitenutil.DrawRect(screen, float64(x0), float64(y0), float64(x1-x0), float64(y1-y0), color.NRGBA{col.R, col.G, col.B, 60})
+		ebitenutil.DrawLine(screen, float64(x0), float64(y0), float64(x1), float64(y0), col)
+		ebitenutil.DrawLine(screen, float64(x1), float64(y0), float64(x1), float64(y1), col)
+		ebitenutil.DrawLine(screen, float64(x1), float64(y1), float64(x0), float64(y1), col)
+		ebitenutil.DrawLine(screen, float64(x0), float64(y1), float64(x0), float64(y0), col)
+		ebitenutil.DrawRect(screen, float64(x1-3), float64(y1-3), 6, 6, col)
+		text.Draw(screen, hs.Name, basicfont.Face7x13, x0, y0-4, color.White)
+	}
+
+	// Top panel: world info and the selected hotspot's fields
+	ebitenutil.DrawRect(screen, 0, 0, float64(vw), worldTopH, color.NRGBA{28, 28, 40, 255})
+	title := fmt.Sprintf("WORLD MODE - %s (%s)  Ctrl+W map mode, Ctrl+S save, Ctrl+N new, PgUp/PgDn switch world, Del remove",
+		e.world.Name, e.world.ID)
+	text.Draw(screen, title, basicfont.Face7x13, 8, 20, color.NRGBA{240, 196, 25, 255})
+
+	if e.worldSel >= 0 && e.worldSel < len(e.world.Hotspots) {
+		hs := &e.world.Hotspots[e.worldSel]
+		for i, name := range worldFieldNames {
+			x, y, w, h := e.worldFieldRect(i)
+			bg := color.NRGBA{40, 40, 50, 255}
+			if i == e.worldField {
+				bg = color.NRGBA{60, 60, 80, 255}
+			}
+			ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), float64(h), bg)
+			val := strings.Join(hs.Requires, ", ")
+			if p := e.worldFieldValue(hs, i); p != nil {
+				val = *p
+			} else if i == e.worldField {
+				val = e.worldReqInput
+			}
+			if i == e.worldField {
+				val += "|"
+			}
+			text.Draw(screen, name+": "+val, basicfont.Face7x13, x+4, y+15, color.White)
+		}
+	} else {
+		text.Draw(screen, "Drag on the map to add a hotspot, click one to edit it.", basicfont.Face7x13, 8, 50, color.NRGBA{170, 170, 190, 255})
+	}
+	if e.status != "" {
+		text.Draw(screen, e.status, basicfont.Face7x13, 8, worldTopH-8, color.NRGBA{170, 170, 190, 255})
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"rumble/server/auth"
+	"rumble/server/srv"
+	"rumble/server/store"
+	"rumble/shared/protocol"
+)
+
+func cmdUsers(st store.Store, args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	query := ""
+	if len(args) == 1 {
+		query = strings.ToLower(args[0])
+	}
+	n := 0
+	err := forEachJSON(st, store.Users, func(key string, u auth.User) error {
+		if query != "" && !strings.Contains(key, query) && !strings.Contains(strings.ToLower(u.ID), query) {
+			return nil
+		}
+		p, _ := loadProfile(st, u.Username)
+		flags := ""
+		if u.Role != "" {
+			flags += "  [" + u.Role + "]"
+		}
+		if u.Ban.Active() {
+			flags += "  [banned: " + u.Ban.Reason + "]"
+		}
+		fmt.Printf("%-20s %-18s created %s  rating %4d  gold %6d  guild %s%s\n",
+			u.Username, u.ID, u.CreatedAt.Format("2006-01-02"), p.PvPRating, p.Gold, orDash(p.GuildID), flags)
+		n++
+		return nil
+	})
+	fmt.Printf("%d account(s)\n", n)
+	return err
+}
+
+func cmdPasswd(st store.Store, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errUsage
+	}
+	u, err := loadUser(st, args[0])
+	if err != nil {
+		return err
+	}
+	var pw string
+	if len(args) == 2 {
+		pw = args[1]
+	} else {
+		fmt.Fprint(os.Stderr, "new password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		pw = strings.TrimRight(line, "\r\n")
+	}
+	if len(pw) < 6 { // same rule as registration
+		return errors.New("password must be at least 6 characters")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = string(hash)
+	if err := store.PutJSON(st, store.Users, strings.ToLower(u.Username), u); err != nil {
+		return err
+	}
+	fmt.Printf("password reset for %s\n", u.Username)
+	return nil
+}
+
+// cmdRole: role <user> admin|none
+func cmdRole(st store.Store, args []string) error {
+	if len(args) != 2 || (args[1] != auth.RoleAdmin && args[1] != "none") {
+		return errUsage
+	}
+	u, err := loadUser(st, args[0])
+	if err != nil {
+		return err
+	}
+	u.Role = args[1]
+	if u.Role == "none" {
+		u.Role = ""
+	}
+	if err := store.PutJSON(st, store.Users, strings.ToLower(u.Username), u); err != nil {
+		return err
+	}
+	fmt.Printf("%s: role %s\n", u.Username, args[1])
+	return nil
+}
+
+// cmdSet: set <user> rating|gold <n> | set <user> xp <unit> <n>
+func cmdSet(st store.Store, args []string) error {
+	if len(args) < 3 {
+		return errUsage
+	}
+	p, err := loadProfile(st, args[0])
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(args[len(args)-1])
+	if err != nil || n < 0 {
+		return fmt.Errorf("bad value %q", args[len(args)-1])
+	}
+	switch {
+	case args[1] == "rating" && len(args) == 3:
+		p.PvPRating = n
+		p.PvPRank = "" // re-derived by the server on load
+	case args[1] == "gold" && len(args) == 3:
+		p.Gold = n
+	case args[1] == "xp" && len(args) == 4:
+		if p.UnitXP == nil {
+			p.UnitXP = map[string]int{}
+		}
+		p.UnitXP[args[2]] = n
+	default:
+		return errUsage
+	}
+	if err := saveProfile(st, p); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s set to %d\n", p.Name, strings.Join(args[1:len(args)-1], " "), n)
+	return nil
+}
+
+// cmdClear: clear <user> rating|gold | clear <user> xp [unit]
+func cmdClear(st store.Store, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+	p, err := loadProfile(st, args[0])
+	if err != nil {
+		return err
+	}
+	switch {
+	case args[1] == "rating" && len(args) == 2:
+		p.PvPRating, p.PvPRank = 0, "" // the server restores the default rating
+	case args[1] == "gold" && len(args) == 2:
+		p.Gold = 0
+	case args[1] == "xp" && len(args) == 2:
+		p.UnitXP = nil
+	case args[1] == "xp" && len(args) == 3:
+		delete(p.UnitXP, args[2])
+	default:
+		return errUsage
+	}
+	if err := saveProfile(st, p); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s cleared\n", p.Name, strings.Join(args[1:], " "))
+	return nil
+}
+
+// cmdRename moves an account to a new name everywhere the name is a key:
+// the account, the name index, the profile, guild membership, friend lists
+// and DM conversations. The account ID stays the same. Tournaments and match
+// records are history and keep the name the player had at the time.
+func cmdRename(st store.Store, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	oldName, newName := args[0], strings.TrimSpace(args[1])
+	if err := auth.ValidUsername(newName); err != nil {
+		return err
+	}
+	u, err := loadUser(st, oldName)
+	if err != nil {
+		return err
+	}
+	oldName = u.Username
+	oldKey, newKey := strings.ToLower(oldName), strings.ToLower(newName)
+	if oldKey != newKey {
+		if _, err := loadUser(st, newName); err == nil {
+			return fmt.Errorf("account %q already exists", newName)
+		}
+		if _, err := store.LookupAccount(st, newName); err == nil {
+			return fmt.Errorf("name %q is already bound to an account", newName)
+		}
+	}
+	p, err := loadProfile(st, oldName)
+	if err != nil {
+		return err
+	}
+
+	// account and name index
+	u.Username = newName
+	if err := store.PutJSON(st, store.Users, newKey, u); err != nil {
+		return err
+	}
+	if err := store.PutJSON(st, store.Names, store.NameKey(newName), p.AccountID); err != nil {
+		return err
+	}
+	if oldKey != newKey {
+		if err := st.Delete(store.Users, oldKey); err != nil {
+			return err
+		}
+		if err := st.Delete(store.Names, store.NameKey(oldName)); err != nil {
+			return err
+		}
+	}
+
+	// profile
+	p.Name = newName
+	if err := saveProfile(st, p); err != nil {
+		return err
+	}
+
+	// guilds: members are keyed by the name as typed at login
+	guilds := 0
+	err = forEachJSON(st, store.Guilds, func(id string, g srv.Guild) error {
+		changed := false
+		for m, role := range g.Members {
+			if strings.EqualFold(m, oldName) {
+				delete(g.Members, m)
+				g.Members[newName] = role
+				changed = true
+			}
+		}
+		if strings.EqualFold(g.Leader, oldName) {
+			g.Leader = newName
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+		guilds++
+		return store.PutJSON(st, store.Guilds, id, g)
+	})
+	if err != nil {
+		return err
+	}
+
+	// friends: lower-cased on both sides
+	friends := 0
+	if oldKey != newKey {
+		err = forEachJSON(st, store.Friends, func(key string, set map[string]bool) error {
+			if key == oldKey {
+				friends++
+				if err := st.Delete(store.Friends, oldKey); err != nil {
+					return err
+				}
+				return store.PutJSON(st, store.Friends, newKey, set)
+			}
+			if !set[oldKey] {
+				return nil
+			}
+			delete(set, oldKey)
+			set[newKey] = true
+			friends++
+			return store.PutJSON(st, store.Friends, key, set)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// DMs: conversation keys are "a|b" of lower-cased names
+	convos := 0
+	err = forEachJSON(st, store.DMs, func(key string, msgs []protocol.FriendDM) error {
+		a, b, ok := strings.Cut(key, "|")
+		if !ok || (a != oldKey && b != oldKey) {
+			return nil
+		}
+		for i := range msgs {
+			if strings.EqualFold(msgs[i].From, oldName) {
+				msgs[i].From = newName
+			}
+			if strings.EqualFold(msgs[i].To, oldName) {
+				msgs[i].To = newName
+			}
+		}
+		if a == oldKey {
+			a = newKey
+		} else {
+			b = newKey
+		}
+		nk := convoKey(a, b)
+		if nk != key {
+			if err := st.Delete(store.DMs, key); err != nil {
+				return err
+			}
+		}
+		convos++
+		return store.PutJSON(st, store.DMs, nk, msgs)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("renamed %s -> %s (%d guild(s), %d friend list(s), %d conversation(s); tournaments and match records keep the old name)\n",
+		oldName, newName, guilds, friends, convos)
+	return nil
+}
+
+// convoKey matches the server's DM conversation key.
+func convoKey(a, b string) string {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if a < b {
+		return a + "|" + b
+	}
+	return b + "|" + a
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
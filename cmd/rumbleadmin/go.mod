@@ -0,0 +1,22 @@
+module rumble/rumbleadmin
+
+go 1.23.0
+
+require (
+	golang.org/x/crypto v0.41.0
+	rumble/server v0.0.0
+	rumble/shared v0.0.0
+)
+
+require (
+	github.com/golang-jwt/jwt/v5 v5.3.0 // indirect
+	github.com/gorilla/websocket v1.5.1 // indirect
+	go.etcd.io/bbolt v1.4.3 // indirect
+	golang.org/x/net v0.42.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
+
+replace (
+	rumble/server => ../../server
+	rumble/shared => ../../shared
+)
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"rumble/server/srv"
+	"rumble/server/store"
+)
+
+func cmdGuilds(st store.Store, args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	query := ""
+	if len(args) == 1 {
+		query = strings.ToLower(args[0])
+	}
+	n := 0
+	err := forEachJSON(st, store.Guilds, func(id string, g srv.Guild) error {
+		if query != "" && !strings.Contains(strings.ToLower(g.Name), query) && !strings.Contains(strings.ToLower(id), query) {
+			return nil
+		}
+		fmt.Printf("%-14s %-24s leader %-16s %2d member(s)  %s  created %s\n",
+			id, g.Name, orDash(g.Leader), len(g.Members), g.Privacy, time.Unix(g.Created, 0).Format("2006-01-02"))
+		n++
+		return nil
+	})
+	fmt.Printf("%d guild(s)\n", n)
+	return err
+}
+
+// cmdGuild: guild disband <id> | guild transfer <id> <user>
+func cmdGuild(st store.Store, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+	var g srv.Guild
+	id := args[1]
+	err := store.GetJSON(st, store.Guilds, id, &g)
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("no guild %q", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "disband" && len(args) == 2:
+		if err := st.Delete(store.Guilds, id); err != nil {
+			return err
+		}
+		members := make([]string, 0, len(g.Members))
+		for m := range g.Members {
+			members = append(members, m)
+		}
+		sort.Strings(members)
+		for _, m := range members {
+			p, err := loadProfile(st, m)
+			if err != nil || p.GuildID != id {
+				continue
+			}
+			p.GuildID = ""
+			if err := saveProfile(st, p); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("disbanded %s (%s), %d member(s) released\n", id, g.Name, len(members))
+		return nil
+
+	case args[0] == "transfer" && len(args) == 3:
+		to := ""
+		for m := range g.Members {
+			if strings.EqualFold(m, args[2]) {
+				to = m
+			}
+		}
+		if to == "" {
+			return fmt.Errorf("%s is not a member of %s", args[2], id)
+		}
+		// same demotion as a leader handing over in game
+		if g.Leader != "" && g.Leader != to {
+			if _, ok := g.Members[g.Leader]; ok {
+				g.Members[g.Leader] = "officer"
+			}
+		}
+		g.Leader = to
+		g.Members[to] = "leader"
+		if err := store.PutJSON(st, store.Guilds, id, g); err != nil {
+			return err
+		}
+		fmt.Printf("%s (%s) is now led by %s\n", id, g.Name, to)
+		return nil
+	}
+	return errUsage
+}
@@ -0,0 +1,166 @@
+// Command rumbleadmin edits the server's stored state: accounts, profiles,
+// guilds, friends and DMs. It opens the store directly, so stop the server
+// first; both stores are locked while the server has them open.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"rumble/server/auth"
+	"rumble/server/config"
+	"rumble/server/store"
+	"rumble/shared/protocol"
+)
+
+const usage = `usage: rumbleadmin [-store kind:path] <command> [args]
+
+accounts
+  users [query]                      list accounts, optionally matching query
+  passwd <user> [password]           set a new password (read from stdin if omitted)
+  set <user> rating|gold <n>         set PvP rating or gold
+  set <user> xp <unit> <n>           set one unit's XP
+  clear <user> rating|gold           reset PvP rating to the default, or gold to 0
+  clear <user> xp [unit]             clear one unit's XP, or all of it
+  rename <old> <new>                 rename an account in profiles, guilds, friends and DMs
+  role <user> admin|none             grant or revoke access to the server's /admin API
+
+guilds
+  guilds [query]                     list guilds
+  guild disband <id>                 delete a guild and clear it from member profiles
+  guild transfer <id> <user>         make a member the leader
+
+maintenance
+  purge-dms <days> [-dry-run]        delete DMs older than days
+  check                              report broken references (exit 1 if any)
+`
+
+func main() {
+	// Default to the server's own store: its config file and RUMBLE_* env
+	cfg, err := config.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rumbleadmin: config: %v\n", err)
+		os.Exit(1)
+	}
+	storePath := cfg.DataDir
+	if cfg.Store != "json" {
+		storePath = cfg.DBPath
+	}
+	at := flag.String("store", cfg.Store+":"+storePath, "store to edit, kind:path (json:data or bolt:data/rumble.db)")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fmt.Fprintln(os.Stderr, "\nflags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	kind, path, ok := strings.Cut(*at, ":")
+	if !ok {
+		fmt.Fprintln(os.Stderr, "rumbleadmin: -store takes kind:path, e.g. json:data")
+		os.Exit(2)
+	}
+	st, err := store.Open(kind, path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rumbleadmin: open %s: %v\n", *at, err)
+		os.Exit(1)
+	}
+
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	switch cmd {
+	case "users":
+		err = cmdUsers(st, args)
+	case "passwd":
+		err = cmdPasswd(st, args)
+	case "set":
+		err = cmdSet(st, args)
+	case "clear":
+		err = cmdClear(st, args)
+	case "rename":
+		err = cmdRename(st, args)
+	case "role":
+		err = cmdRole(st, args)
+	case "guilds":
+		err = cmdGuilds(st, args)
+	case "guild":
+		err = cmdGuild(st, args)
+	case "purge-dms":
+		err = cmdPurgeDMs(st, args)
+	case "check":
+		err = cmdCheck(st, args)
+	default:
+		err = errUsage
+	}
+	if cerr := st.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if errors.Is(err, errUsage) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if errors.Is(err, errProblems) {
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rumbleadmin %s: %v\n", cmd, err)
+		os.Exit(1)
+	}
+}
+
+var (
+	errUsage    = errors.New("usage")
+	errProblems = errors.New("check found problems")
+)
+
+// ---- shared lookups
+
+// loadUser returns the account stored for username.
+func loadUser(st store.Store, username string) (auth.User, error) {
+	var u auth.User
+	err := store.GetJSON(st, store.Users, strings.ToLower(username), &u)
+	if errors.Is(err, store.ErrNotFound) {
+		return u, fmt.Errorf("no account %q", username)
+	}
+	return u, err
+}
+
+// loadProfile returns the stored profile of name's account. An account that
+// never saved a profile gets an empty one; the server fills in defaults.
+func loadProfile(st store.Store, name string) (protocol.Profile, error) {
+	id, err := store.LookupAccount(st, name)
+	if errors.Is(err, store.ErrNotFound) {
+		return protocol.Profile{}, fmt.Errorf("no account %q", name)
+	}
+	if err != nil {
+		return protocol.Profile{}, err
+	}
+	p := protocol.Profile{AccountID: id, Name: name}
+	err = store.GetJSON(st, store.Players, id, &p)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return p, err
+	}
+	p.AccountID = id
+	return p, nil
+}
+
+func saveProfile(st store.Store, p protocol.Profile) error {
+	return store.PutJSON(st, store.Players, p.AccountID, p)
+}
+
+// forEachJSON decodes every entry of bucket into a fresh T.
+func forEachJSON[T any](st store.Store, bucket string, fn func(key string, v T) error) error {
+	return st.ForEach(bucket, func(key string, val []byte) error {
+		var v T
+		if err := json.Unmarshal(val, &v); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s/%s: %v\n", bucket, key, err)
+			return nil
+		}
+		return fn(key, v)
+	})
+}
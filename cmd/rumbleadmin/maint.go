@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rumble/server/auth"
+	"rumble/server/srv"
+	"rumble/server/store"
+	"rumble/shared/protocol"
+)
+
+// cmdPurgeDMs: purge-dms <days> [-dry-run]
+func cmdPurgeDMs(st store.Store, args []string) error {
+	dryRun := false
+	var rest []string
+	for _, a := range args {
+		if a == "-dry-run" || a == "--dry-run" {
+			dryRun = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+	if len(rest) != 1 {
+		return errUsage
+	}
+	days, err := strconv.Atoi(rest[0])
+	if err != nil || days < 0 {
+		return fmt.Errorf("bad day count %q", rest[0])
+	}
+	cutoff := time.Now().AddDate(0, 0, -days).UnixMilli()
+
+	purged, convos := 0, 0
+	err = forEachJSON(st, store.DMs, func(key string, msgs []protocol.FriendDM) error {
+		kept := msgs[:0]
+		for _, m := range msgs {
+			if m.Ts >= cutoff {
+				kept = append(kept, m)
+			}
+		}
+		if len(kept) == len(msgs) {
+			return nil
+		}
+		purged += len(msgs) - len(kept)
+		convos++
+		if dryRun {
+			return nil
+		}
+		if len(kept) == 0 {
+			return st.Delete(store.DMs, key)
+		}
+		return store.PutJSON(st, store.DMs, key, kept)
+	})
+	if err != nil {
+		return err
+	}
+	verb := "purged"
+	if dryRun {
+		verb = "would purge"
+	}
+	fmt.Printf("%s %d message(s) older than %d day(s) from %d conversation(s)\n", verb, purged, days, convos)
+	return nil
+}
+
+// cmdCheck reports references that point nowhere: guild members or leaders
+// without an account or profile, profiles pointing at guilds they are not in,
+// accounts missing from the name index, and friends without an account.
+func cmdCheck(st store.Store, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	var problems []string
+	report := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	users := map[string]auth.User{} // by lower-cased name
+	if err := forEachJSON(st, store.Users, func(key string, u auth.User) error {
+		users[key] = u
+		return nil
+	}); err != nil {
+		return err
+	}
+	names := map[string]string{} // name key -> account ID
+	if err := forEachJSON(st, store.Names, func(key string, id string) error {
+		names[key] = id
+		return nil
+	}); err != nil {
+		return err
+	}
+	profiles := map[string]protocol.Profile{} // by account ID
+	if err := forEachJSON(st, store.Players, func(id string, p protocol.Profile) error {
+		profiles[id] = p
+		return nil
+	}); err != nil {
+		return err
+	}
+	guilds := map[string]srv.Guild{}
+	if err := forEachJSON(st, store.Guilds, func(id string, g srv.Guild) error {
+		guilds[id] = g
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for key, u := range users {
+		id, ok := names[store.NameKey(u.Username)]
+		switch {
+		case !ok:
+			report("account %s has no name index entry", u.Username)
+		case u.ID != "" && id != u.ID:
+			report("account %s has ID %s but its name maps to %s", u.Username, u.ID, id)
+		}
+		if key != strings.ToLower(u.Username) {
+			report("account %s is stored under key %q", u.Username, key)
+		}
+	}
+	for id, p := range profiles {
+		if p.Name == "" {
+			report("profile %s has no name", id)
+			continue
+		}
+		if names[store.NameKey(p.Name)] != id {
+			report("profile %s (%s) is not the one its name maps to", id, p.Name)
+		}
+		if p.GuildID == "" {
+			continue
+		}
+		g, ok := guilds[p.GuildID]
+		if !ok {
+			report("profile %s points at missing guild %s", p.Name, p.GuildID)
+		} else if !hasMember(g, p.Name) {
+			report("profile %s points at guild %s but is not a member", p.Name, p.GuildID)
+		}
+	}
+	for gid, g := range guilds {
+		for m := range g.Members {
+			id, ok := names[store.NameKey(m)]
+			if !ok {
+				report("guild %s member %s has no account", gid, m)
+			} else if _, ok := profiles[id]; !ok {
+				report("guild %s member %s has no profile", gid, m)
+			}
+		}
+		switch {
+		case g.Leader == "":
+			report("guild %s has no leader", gid)
+		case !hasMember(g, g.Leader):
+			report("guild %s leader %s is not a member", gid, g.Leader)
+		}
+		if len(g.Members) == 0 {
+			report("guild %s has no members", gid)
+		}
+	}
+	if err := forEachJSON(st, store.Friends, func(key string, set map[string]bool) error {
+		if _, ok := users[key]; !ok && len(set) > 0 {
+			report("friend list of %s has no account", key)
+		}
+		for f := range set {
+			if _, ok := users[f]; !ok {
+				report("%s lists friend %s, who has no account", key, f)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	sort.Strings(problems)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("%d problem(s)\n", len(problems))
+		return errProblems
+	}
+	fmt.Println("no problems found")
+	return nil
+}
+
+func hasMember(g srv.Guild, name string) bool {
+	for m := range g.Members {
+		if strings.EqualFold(m, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"rumble/server/auth"
+	"rumble/server/srv"
+)
+
+// The /admin API: live introspection and operator actions on a running
+// server. Every route needs a token of an account whose role is "admin"
+// (rumbleadmin role <user> admin).
+//
+//	GET  /admin/rooms                       live rooms
+//	GET  /admin/rooms?id=<room>             snapshot of the match (bug reports)
+//	POST /admin/rooms?id=<room>&action=end  end the match as a draw
+//	POST /admin/rooms?id=<room>&action=kill end it and remove the room
+//	GET  /admin/clients                     connected clients
+//	GET  /admin/queues                      matchmaking queues
+//	POST /admin/kick      {"user", "reason"}
+//	POST /admin/ban       {"user", "reason", "hours"}  hours 0 = permanent
+//	POST /admin/unban     {"user"}
+//	POST /admin/announce  {"text", "seconds"}          empty text clears it
+func registerAdmin(mux *http.ServeMux, hub *srv.Hub, authz *auth.Auth) {
+	mux.Handle("/admin/rooms", authz.RequireAdmin(func(w http.ResponseWriter, r *http.Request, admin string) {
+		switch r.Method {
+		case http.MethodGet:
+			id := r.URL.Query().Get("id")
+			if id == "" {
+				writeJSON(w, hub.AdminRooms())
+				return
+			}
+			b, err := hub.MatchSnapshot(id)
+			if errors.Is(err, srv.ErrNoRoom) {
+				http.Error(w, "no such room", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			log.Printf("admin %s: snapshot of room %s", admin, id)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Disposition", `attachment; filename="`+id+`.json"`)
+			_, _ = w.Write(b)
+		case http.MethodPost:
+			id, action := r.URL.Query().Get("id"), r.URL.Query().Get("action")
+			var err error
+			switch action {
+			case "end":
+				err = hub.AdminEndRoom(id)
+			case "kill":
+				err = hub.AdminKillRoom(id)
+			default:
+				http.Error(w, "action must be end or kill", http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, srv.ErrNoRoom) {
+				http.Error(w, "no such room", http.StatusNotFound)
+				return
+			}
+			log.Printf("admin %s: %s room %s", admin, action, id)
+			writeJSON(w, map[string]bool{"ok": true})
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	mux.Handle("/admin/clients", authz.RequireAdmin(func(w http.ResponseWriter, r *http.Request, admin string) {
+		writeJSON(w, hub.AdminClients())
+	}))
+	mux.Handle("/admin/queues", authz.RequireAdmin(func(w http.ResponseWriter, r *http.Request, admin string) {
+		writeJSON(w, hub.AdminQueues())
+	}))
+
+	type userReq struct {
+		User   string  `json:"user"`
+		Reason string  `json:"reason"`
+		Hours  float64 `json:"hours"`
+	}
+	mux.Handle("/admin/kick", authz.RequireAdmin(func(w http.ResponseWriter, r *http.Request, admin string) {
+		var req userReq
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if req.User = strings.TrimSpace(req.User); req.User == "" {
+			http.Error(w, "missing user", http.StatusBadRequest)
+			return
+		}
+		if req.Reason == "" {
+			req.Reason = "Kicked by a server operator"
+		}
+		n := hub.Kick(req.User, req.Reason, false)
+		log.Printf("admin %s: kick %s (%d connection(s)): %s", admin, req.User, n, req.Reason)
+		writeJSON(w, map[string]int{"connections": n})
+	}))
+	mux.Handle("/admin/ban", authz.RequireAdmin(func(w http.ResponseWriter, r *http.Request, admin string) {
+		var req userReq
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if req.User = strings.TrimSpace(req.User); req.User == "" {
+			http.Error(w, "missing user", http.StatusBadRequest)
+			return
+		}
+		if req.Reason == "" {
+			req.Reason = "Banned by a server operator"
+		}
+		var until time.Time
+		if req.Hours > 0 {
+			until = time.Now().Add(time.Duration(req.Hours * float64(time.Hour)))
+		}
+		if err := authz.SetBan(req.User, req.Reason, until); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		n := hub.Kick(req.User, req.Reason, true)
+		log.Printf("admin %s: ban %s (%d connection(s)): %s", admin, req.User, n, req.Reason)
+		writeJSON(w, map[string]int{"connections": n})
+	}))
+	mux.Handle("/admin/unban", authz.RequireAdmin(func(w http.ResponseWriter, r *http.Request, admin string) {
+		var req userReq
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if req.User = strings.TrimSpace(req.User); req.User == "" {
+			http.Error(w, "missing user", http.StatusBadRequest)
+			return
+		}
+		if err := authz.SetBan(req.User, "", time.Time{}); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Printf("admin %s: unban %s", admin, req.User)
+		writeJSON(w, map[string]bool{"ok": true})
+	}))
+	mux.Handle("/admin/announce", authz.RequireAdmin(func(w http.ResponseWriter, r *http.Request, admin string) {
+		var req struct {
+			Text    string `json:"text"`
+			Seconds int    `json:"seconds"`
+		}
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if req.Seconds <= 0 {
+			req.Seconds = 60
+		}
+		a := hub.Announce(req.Text, time.Duration(req.Seconds)*time.Second)
+		log.Printf("admin %s: announce %q", admin, a.Text)
+		writeJSON(w, a)
+	}))
+}
+
+// readJSON decodes a POST body into v, answering the request itself when it
+// cannot.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
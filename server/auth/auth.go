@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"rumble/server/store"
 	"rumble/shared/protocol"
 	"strings"
 	"sync"
@@ -19,32 +21,78 @@ import (
 )
 
 type User struct {
+	ID           string    `json:"id,omitempty"` // account ID, see store.EnsureAccount
 	Username     string    `json:"username"`
 	PasswordHash string    `json:"password_hash"`
 	CreatedAt    time.Time `json:"created_at"`
+	Role         string    `json:"role,omitempty"` // "admin" may use the /admin API
+	Ban          *Ban      `json:"ban,omitempty"`
+}
+
+// RoleAdmin is the User.Role of server operators.
+const RoleAdmin = "admin"
+
+var usernameChars = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ValidUsername checks a new account name: 3 to 20 letters, digits, '_',
+// '-' or '.'. Names registered before the rule are left alone.
+func ValidUsername(name string) error {
+	if n := len(name); n < 3 || n > 20 {
+		return errors.New("username must be 3 to 20 characters")
+	}
+	if !usernameChars.MatchString(name) {
+		return errors.New("username may only use letters, digits, '_', '-' and '.'")
+	}
+	return nil
+}
+
+// Ban keeps a user from logging in or connecting.
+type Ban struct {
+	Reason string `json:"reason"`
+	Until  int64  `json:"until,omitempty"` // Unix ms; 0 = permanent
+}
+
+// Active reports whether the ban is still in force.
+func (b *Ban) Active() bool {
+	return b != nil && (b.Until == 0 || time.Now().UnixMilli() < b.Until)
 }
 
 type userStore struct {
 	mu    sync.RWMutex
-	path  string
+	st    store.Store
 	users map[string]*User
 }
 
-func newUserStore(path string) (*userStore, error) {
-	us := &userStore{path: path, users: map[string]*User{}}
-	_ = os.MkdirAll(filepath.Dir(path), 0o755)
-	if b, err := os.ReadFile(path); err == nil {
-		_ = json.Unmarshal(b, &us.users)
+func newUserStore(st store.Store) (*userStore, error) {
+	us := &userStore{st: st, users: map[string]*User{}}
+	err := st.ForEach(store.Users, func(key string, val []byte) error {
+		var u User
+		if json.Unmarshal(val, &u) == nil {
+			us.users[key] = &u
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Accounts registered before account IDs get one now
+	for _, u := range us.users {
+		if u.ID != "" {
+			continue
+		}
+		if u.ID, err = store.EnsureAccount(st, u.Username); err != nil {
+			return nil, err
+		}
+		if err := us.save(u); err != nil {
+			return nil, err
+		}
 	}
 	return us, nil
 }
 
-func (s *userStore) save() error {
-	// Read under RLock, then write file without holding the lock
-	s.mu.RLock()
-	b, _ := json.MarshalIndent(s.users, "", "  ")
-	s.mu.RUnlock()
-	return os.WriteFile(s.path, b, 0o600)
+// save persists one account.
+func (s *userStore) save(u *User) error {
+	return store.PutJSON(s.st, store.Users, strings.ToLower(u.Username), u)
 }
 
 func (s *userStore) exists(username string) bool {
@@ -61,32 +109,59 @@ func (s *userStore) get(username string) (*User, bool) {
 	return u, ok
 }
 
+// ban returns the ban on username, if any.
+func (s *userStore) ban(username string) *Ban {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if u := s.users[strings.ToLower(username)]; u != nil {
+		return u.Ban
+	}
+	return nil
+}
+
 func (s *userStore) put(u *User) error {
 	s.mu.Lock()
 	s.users[strings.ToLower(u.Username)] = u
 	s.mu.Unlock()
-	return s.save()
+	return s.save(u)
 }
 
 type Auth struct {
-	users  *userStore
-	jwtKey []byte
-	issuer string
+	users    *userStore
+	jwtKey   []byte
+	issuer   string
+	lifetime time.Duration // of issued tokens
 }
 
-func NewAuth(dataDir string) (*Auth, error) {
-	users, err := newUserStore(filepath.Join(dataDir, "users.json"))
+// NewAuth loads the accounts from st; the JWT signing key stays a file in
+// dataDir.
+func NewAuth(dataDir string, st store.Store) (*Auth, error) {
+	users, err := newUserStore(st)
 	if err != nil {
 		return nil, err
 	}
+	_ = os.MkdirAll(dataDir, 0o755)
 	keyPath := filepath.Join(dataDir, "jwt.key")
 	key, err := os.ReadFile(keyPath)
 	if err != nil || len(key) < 32 {
 		key = make([]byte, 32)
 		_, _ = rand.Read(key)
-		_ = os.WriteFile(keyPath, key, 0o600)
+		if err := store.WriteFile(keyPath, key, 0o600); err != nil {
+			return nil, err
+		}
+	}
+	return &Auth{users: users, jwtKey: key, issuer: "WarRumble", lifetime: 24 * time.Hour}, nil
+}
+
+// SetTokens sets the issuer and lifetime of login tokens. Tokens from
+// another issuer stop being accepted.
+func (a *Auth) SetTokens(issuer string, lifetime time.Duration) {
+	if issuer != "" {
+		a.issuer = issuer
+	}
+	if lifetime > 0 {
+		a.lifetime = lifetime
 	}
-	return &Auth{users: users, jwtKey: key, issuer: "WarRumble"}, nil
 }
 
 type RegisterReq struct {
@@ -106,7 +181,11 @@ func (a *Auth) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	req.Username = strings.TrimSpace(req.Username)
-	if req.Username == "" || len(req.Password) < 6 || req.Password != req.PasswordConfirm {
+	if err := ValidUsername(req.Username); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Password) < 6 || req.Password != req.PasswordConfirm {
 		http.Error(w, "invalid username or password mismatch / too short", http.StatusBadRequest)
 		return
 	}
@@ -115,7 +194,12 @@ func (a *Auth) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	hash, _ := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	u := &User{Username: req.Username, PasswordHash: string(hash), CreatedAt: time.Now()}
+	id, err := store.EnsureAccount(a.users.st, req.Username)
+	if err != nil {
+		http.Error(w, "save failed", http.StatusInternalServerError)
+		return
+	}
+	u := &User{ID: id, Username: req.Username, PasswordHash: string(hash), CreatedAt: time.Now()}
 	if err := a.users.put(u); err != nil {
 		http.Error(w, "save failed", http.StatusInternalServerError)
 		return
@@ -154,11 +238,15 @@ func (a *Auth) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid credentials", http.StatusUnauthorized)
 		return
 	}
+	if ban := a.users.ban(u.Username); ban.Active() {
+		http.Error(w, banMessage(ban), http.StatusForbidden)
+		return
+	}
 	claims := jwt.MapClaims{
 		"sub": u.Username,
 		"iss": a.issuer,
 		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
+		"exp": time.Now().Add(a.lifetime).Unix(),
 		"ver": protocol.GameVersion, // Include client version in token
 	}
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -172,7 +260,7 @@ func (a *Auth) ParseToken(tok string) (string, error) {
 	}
 	t, err := jwt.Parse(tok, func(t *jwt.Token) (interface{}, error) {
 		return a.jwtKey, nil
-	})
+	}, jwt.WithIssuer(a.issuer), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil || !t.Valid {
 		return "", errors.New("invalid token")
 	}
@@ -185,6 +273,9 @@ func (a *Auth) ParseToken(tok string) (string, error) {
 			}
 		}
 		if sub, ok := claims["sub"].(string); ok {
+			if a.users.ban(sub).Active() {
+				return "", errors.New("banned")
+			}
 			return sub, nil
 		}
 	}
@@ -208,3 +299,63 @@ func (a *Auth) RequireAuth(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// IsAdmin reports whether username has the admin role.
+func (a *Auth) IsAdmin(username string) bool {
+	u, ok := a.users.get(username)
+	return ok && u.Role == RoleAdmin
+}
+
+// RequireAdmin is RequireAuth for accounts with the admin role; it passes
+// the admin's name to next.
+func (a *Auth) RequireAdmin(next func(w http.ResponseWriter, r *http.Request, admin string)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tok string
+		if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+			tok = strings.TrimPrefix(h, "Bearer ")
+		} else {
+			tok = r.URL.Query().Get("token")
+		}
+		user, err := a.ParseToken(tok)
+		if err != nil || user == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !a.IsAdmin(user) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r, user)
+	})
+}
+
+// SetBan bans username until the given time (zero time = permanently), or
+// lifts the ban when reason is empty.
+func (a *Auth) SetBan(username, reason string, until time.Time) error {
+	a.users.mu.Lock()
+	u := a.users.users[strings.ToLower(username)]
+	if u == nil {
+		a.users.mu.Unlock()
+		return errors.New("no such user")
+	}
+	next := *u
+	if reason == "" {
+		next.Ban = nil
+	} else {
+		next.Ban = &Ban{Reason: reason}
+		if !until.IsZero() {
+			next.Ban.Until = until.UnixMilli()
+		}
+	}
+	a.users.users[strings.ToLower(username)] = &next
+	a.users.mu.Unlock()
+	return a.users.save(&next)
+}
+
+func banMessage(b *Ban) string {
+	msg := "account banned: " + b.Reason
+	if b.Until != 0 {
+		msg += " (until " + time.UnixMilli(b.Until).UTC().Format("2006-01-02 15:04 UTC") + ")"
+	}
+	return msg
+}
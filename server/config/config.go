@@ -0,0 +1,309 @@
+// Package config resolves the server configuration from, in increasing
+// priority: built-in defaults, a JSON config file, RUMBLE_* environment
+// variables and command-line flags.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is everything the server reads at startup. Relative paths are
+// relative to the working directory; DBPath and SnapshotDir default to
+// places next to DataDir.
+type Config struct {
+	Listen  string `json:"listen"`
+	TLSCert string `json:"tls_cert,omitempty"` // serve HTTPS when both are set
+	TLSKey  string `json:"tls_key,omitempty"`
+
+	DataDir string `json:"data_dir"`
+	Store   string `json:"store"`             // "json" or "bolt"
+	DBPath  string `json:"db_path,omitempty"` // default <data_dir>/rumble.db
+
+	// Browser origins allowed to open the WebSocket. Empty allows only the
+	// server's own host; "*" allows any. Clients that send no Origin (the
+	// desktop and mobile builds) are always allowed.
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+
+	ReadTimeout  Duration `json:"read_timeout"`
+	WriteTimeout Duration `json:"write_timeout"`
+	IdleTimeout  Duration `json:"idle_timeout"`
+
+	TickRate int         `json:"tick_rate"` // room steps per second
+	Queue    QueueConfig `json:"queue"`
+
+	JWTIssuer   string   `json:"jwt_issuer"`
+	JWTLifetime Duration `json:"jwt_lifetime"`
+
+	SnapshotDir   string   `json:"snapshot_dir,omitempty"` // default snapshots/ next to data_dir
+	SnapshotEvery Duration `json:"snapshot_every"`         // 0 disables
+	SnapshotKeep  int      `json:"snapshot_keep"`
+
+	ShutdownGrace   Duration `json:"shutdown_grace"`   // how long running matches may finish on SIGTERM
+	CheckpointEvery Duration `json:"checkpoint_every"` // how often running matches are saved, 0 disables
+	ResumeWait      Duration `json:"resume_wait"`      // how long a resumed match waits for its players
+}
+
+// QueueConfig is the ranked queue discipline for leavers and AFK players.
+type QueueConfig struct {
+	AFKSeconds      float64    `json:"afk_seconds"`      // at the gold cap without deploying
+	LeaverWindow    Duration   `json:"leaver_window"`    // how long an abandon counts
+	LeaverCooldowns []Duration `json:"leaver_cooldowns"` // queue ban after the 1st, 2nd, ... abandon
+}
+
+// Default is the configuration the server always had.
+func Default() Config {
+	return Config{
+		Listen:       ":8080",
+		DataDir:      "data",
+		Store:        "json",
+		ReadTimeout:  Duration(15 * time.Second),
+		WriteTimeout: Duration(15 * time.Second),
+		IdleTimeout:  Duration(60 * time.Second),
+		TickRate:     20,
+		Queue: QueueConfig{
+			AFKSeconds:   45,
+			LeaverWindow: Duration(24 * time.Hour),
+			LeaverCooldowns: []Duration{0, Duration(2 * time.Minute), Duration(5 * time.Minute),
+				Duration(15 * time.Minute), Duration(30 * time.Minute)},
+		},
+		JWTIssuer:       "WarRumble",
+		JWTLifetime:     Duration(24 * time.Hour),
+		SnapshotEvery:   Duration(time.Hour),
+		SnapshotKeep:    24,
+		ShutdownGrace:   Duration(2 * time.Minute),
+		CheckpointEvery: Duration(30 * time.Second),
+		ResumeWait:      Duration(2 * time.Minute),
+	}
+}
+
+// DefaultFile is read when no config file is named and it exists.
+const DefaultFile = "server.json"
+
+// setting is one option settable from the environment and the command line.
+type setting struct {
+	flag, env, usage string
+	set              func(c *Config, v string) error
+}
+
+var settings = []setting{
+	{"listen", "RUMBLE_LISTEN", "address to listen on (default :8080)", setString(func(c *Config) *string { return &c.Listen })},
+	{"tls-cert", "RUMBLE_TLS_CERT", "TLS certificate file; with -tls-key serves HTTPS", setString(func(c *Config) *string { return &c.TLSCert })},
+	{"tls-key", "RUMBLE_TLS_KEY", "TLS private key file", setString(func(c *Config) *string { return &c.TLSKey })},
+	{"data", "RUMBLE_DATA_DIR", "data directory (default data)", setString(func(c *Config) *string { return &c.DataDir })},
+	{"store", "RUMBLE_STORE", "storage backend: json (files under the data dir) or bolt (one database file)", setString(func(c *Config) *string { return &c.Store })},
+	{"db", "RUMBLE_DB", "database file for -store=bolt (default <data>/rumble.db)", setString(func(c *Config) *string { return &c.DBPath })},
+	{"origins", "RUMBLE_ALLOWED_ORIGINS", "comma-separated browser origins allowed on /ws, or *", func(c *Config, v string) error {
+		c.AllowedOrigins = splitList(v)
+		return nil
+	}},
+	{"read-timeout", "RUMBLE_READ_TIMEOUT", "HTTP read timeout (default 15s)", setDuration(func(c *Config) *Duration { return &c.ReadTimeout })},
+	{"write-timeout", "RUMBLE_WRITE_TIMEOUT", "HTTP write timeout (default 15s)", setDuration(func(c *Config) *Duration { return &c.WriteTimeout })},
+	{"idle-timeout", "RUMBLE_IDLE_TIMEOUT", "HTTP keep-alive idle timeout (default 60s)", setDuration(func(c *Config) *Duration { return &c.IdleTimeout })},
+	{"tick-rate", "RUMBLE_TICK_RATE", "room simulation steps per second (default 20)", setInt(func(c *Config) *int { return &c.TickRate })},
+	{"afk-seconds", "RUMBLE_AFK_SECONDS", "seconds at the gold cap without deploying before a ranked player counts as AFK (default 45)", func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		c.Queue.AFKSeconds = f
+		return err
+	}},
+	{"leaver-window", "RUMBLE_LEAVER_WINDOW", "how long a ranked abandon counts towards the leaver record (default 24h)", setDuration(func(c *Config) *Duration { return &c.Queue.LeaverWindow })},
+	{"leaver-cooldowns", "RUMBLE_LEAVER_COOLDOWNS", "comma-separated queue bans after the 1st, 2nd, ... abandon (default 0,2m,5m,15m,30m)", func(c *Config, v string) error {
+		c.Queue.LeaverCooldowns = nil
+		for _, s := range splitList(v) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			c.Queue.LeaverCooldowns = append(c.Queue.LeaverCooldowns, Duration(d))
+		}
+		return nil
+	}},
+	{"jwt-issuer", "RUMBLE_JWT_ISSUER", "issuer of login tokens (default WarRumble)", setString(func(c *Config) *string { return &c.JWTIssuer })},
+	{"jwt-lifetime", "RUMBLE_JWT_LIFETIME", "lifetime of login tokens (default 24h)", setDuration(func(c *Config) *Duration { return &c.JWTLifetime })},
+	{"snapshot-dir", "RUMBLE_SNAPSHOT_DIR", "where snapshots of the data dir are kept (default snapshots/ next to it)", setString(func(c *Config) *string { return &c.SnapshotDir })},
+	{"snapshot-every", "RUMBLE_SNAPSHOT_EVERY", "interval between snapshots of the data dir, 0 disables (default 1h)", setDuration(func(c *Config) *Duration { return &c.SnapshotEvery })},
+	{"snapshot-keep", "RUMBLE_SNAPSHOT_KEEP", "number of snapshots to keep (default 24)", setInt(func(c *Config) *int { return &c.SnapshotKeep })},
+	{"shutdown-grace", "RUMBLE_SHUTDOWN_GRACE", "how long running matches may finish before shutdown saves them (default 2m)", setDuration(func(c *Config) *Duration { return &c.ShutdownGrace })},
+	{"checkpoint-every", "RUMBLE_CHECKPOINT_EVERY", "interval between checkpoints of running matches, 0 disables (default 30s)", setDuration(func(c *Config) *Duration { return &c.CheckpointEvery })},
+	{"resume-wait", "RUMBLE_RESUME_WAIT", "how long a match resumed after a restart waits for its players (default 2m)", setDuration(func(c *Config) *Duration { return &c.ResumeWait })},
+}
+
+// Load parses args with fs and returns the resolved configuration. The
+// config file is named by -config or $RUMBLE_CONFIG, else DefaultFile is
+// used if it exists.
+func Load(fs *flag.FlagSet, args []string) (Config, error) {
+	file := fs.String("config", "", "JSON config file (default $RUMBLE_CONFIG, or "+DefaultFile+" if present)")
+	var fromFlags []func(c *Config) error
+	for _, s := range settings {
+		s := s
+		fs.Func(s.flag, s.usage+" [$"+s.env+"]", func(v string) error {
+			fromFlags = append(fromFlags, func(c *Config) error { return s.set(c, v) })
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+	path, named := *file, true
+	if path == "" {
+		path = os.Getenv("RUMBLE_CONFIG")
+	}
+	if path == "" {
+		path, named = DefaultFile, false
+	}
+	if err := cfg.readFile(path); err != nil {
+		if named || !errors.Is(err, os.ErrNotExist) {
+			return Config{}, err
+		}
+	}
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(&cfg, v); err != nil {
+				return Config{}, fmt.Errorf("$%s: %w", s.env, err)
+			}
+		}
+	}
+	for _, apply := range fromFlags {
+		if err := apply(&cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	return cfg, cfg.resolve()
+}
+
+// FromEnv is Load without command-line flags: defaults, the config file
+// named by $RUMBLE_CONFIG (or DefaultFile) and the environment. The
+// maintenance subcommands use it for their own defaults.
+func FromEnv() (Config, error) {
+	return Load(flag.NewFlagSet("config", flag.ContinueOnError), nil)
+}
+
+// readFile overlays the settings in a JSON file. Unknown keys are errors, so
+// typos do not go unnoticed.
+func (c *Config) readFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// resolve fills the paths derived from DataDir and checks the values.
+func (c *Config) resolve() error {
+	if c.DataDir == "" {
+		c.DataDir = "data"
+	}
+	c.DataDir = filepath.Clean(c.DataDir)
+	if c.DBPath == "" {
+		c.DBPath = filepath.Join(c.DataDir, "rumble.db")
+	}
+	if c.SnapshotDir == "" {
+		c.SnapshotDir = filepath.Join(filepath.Dir(c.DataDir), "snapshots")
+	}
+	switch {
+	case c.Listen == "":
+		return errors.New("config: listen address is empty")
+	case (c.TLSCert == "") != (c.TLSKey == ""):
+		return errors.New("config: tls_cert and tls_key go together")
+	case c.TickRate < 1 || c.TickRate > 120:
+		return fmt.Errorf("config: tick_rate %d out of range 1-120", c.TickRate)
+	case c.JWTLifetime <= 0:
+		return errors.New("config: jwt_lifetime must be positive")
+	case c.ShutdownGrace < 0:
+		return errors.New("config: shutdown_grace is negative")
+	case c.CheckpointEvery < 0:
+		return errors.New("config: checkpoint_every is negative")
+	case c.ResumeWait <= 0:
+		return errors.New("config: resume_wait must be positive")
+	}
+	return nil
+}
+
+// OriginAllowed reports whether a browser page from origin may open the
+// WebSocket on host.
+func (c *Config) OriginAllowed(origin, host string) bool {
+	if origin == "" {
+		return true // not a browser
+	}
+	for _, o := range c.AllowedOrigins {
+		if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	// same host, like gorilla/websocket's default check
+	if i := strings.Index(origin, "://"); i >= 0 {
+		return strings.EqualFold(origin[i+3:], host)
+	}
+	return false
+}
+
+// ---- Durations as "15s", "24h" in JSON
+
+// Duration is a time.Duration written as a string in the config file.
+type Duration time.Duration
+
+func (d Duration) D() time.Duration { return time.Duration(d) }
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"15s\": %s", b)
+	}
+	v, err := time.ParseDuration(s)
+	*d = Duration(v)
+	return err
+}
+
+// ---- helpers
+
+func setString(field func(c *Config) *string) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func setInt(field func(c *Config) *int) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		*field(c) = n
+		return err
+	}
+}
+
+func setDuration(field func(c *Config) *Duration) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		*field(c) = Duration(d)
+		return err
+	}
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -5,7 +5,9 @@ go 1.23.0
 require (
 	github.com/golang-jwt/jwt/v5 v5.3.0
 	github.com/gorilla/websocket v1.5.1
+	go.etcd.io/bbolt v1.4.3
 	golang.org/x/crypto v0.41.0
+	golang.org/x/sys v0.35.0
 	rumble/shared v0.0.0
 )
 
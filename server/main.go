@@ -1,18 +1,25 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
-	"path/filepath"
-	"regexp"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"rumble/server/auth"
+	"rumble/server/config"
+	"rumble/server/metrics"
 	"rumble/server/srv"
+	"rumble/server/store"
 	"rumble/shared/protocol"
 
 	"github.com/gorilla/websocket"
@@ -25,13 +32,18 @@ type Player struct {
 	// ...
 }
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  2048,
-	WriteBufferSize: 2048,
-	CheckOrigin:     func(r *http.Request) bool { return true },
+// newUpgrader accepts WebSocket connections from the origins cfg allows.
+func newUpgrader(cfg *config.Config) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  2048,
+		WriteBufferSize: 2048,
+		CheckOrigin: func(r *http.Request) bool {
+			return cfg.OriginAllowed(r.Header.Get("Origin"), r.Host)
+		},
+	}
 }
 
-func wsHandler(h *srv.Hub, authz *auth.Auth) http.HandlerFunc {
+func wsHandler(h *srv.Hub, authz *auth.Auth, upgrader *websocket.Upgrader) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// --- AUTH for WebSocket (header or ?token=) ---
 		var tok string
@@ -55,32 +67,115 @@ func wsHandler(h *srv.Hub, authz *auth.Auth) http.HandlerFunc {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "migrate-profiles":
+			os.Exit(runMigrateProfiles(os.Args[2:]))
+		case "restore":
+			os.Exit(runRestore(os.Args[2:]))
+		}
+	}
+	// Defaults < config file < RUMBLE_* environment < flags
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	srv.Configure(srv.Settings{
+		DataDir:         cfg.DataDir,
+		TickRate:        cfg.TickRate,
+		AFKSeconds:      cfg.Queue.AFKSeconds,
+		LeaverWindow:    cfg.Queue.LeaverWindow.D(),
+		LeaverCooldowns: durations(cfg.Queue.LeaverCooldowns),
+		ResumeWait:      cfg.ResumeWait.D(),
+	})
+
 	// Seed RNG once at startup for any randomization (AI, XP targets, etc.)
 	rand.Seed(time.Now().UnixNano())
+
+	storePath := cfg.DataDir
+	if cfg.Store != "json" {
+		storePath = cfg.DBPath
+	}
+	st, err := store.Open(cfg.Store, storePath)
+	if err != nil {
+		log.Fatalf("store: %v", err)
+	}
+	defer st.Close()
+
+	// Background writers to the store; stopped before it is closed
+	var bg sync.WaitGroup
+	quit := make(chan struct{})
+	background := func(fn func(stop <-chan struct{})) {
+		bg.Add(1)
+		go func() {
+			defer bg.Done()
+			fn(quit)
+		}()
+	}
+
+	// Every profile read and write, HTTP and WebSocket alike, goes through
+	// the one service
+	profiles := srv.NewProfileService(st)
+	background(profiles.Run)
+
 	hub := srv.NewHub()
+	hub.SetProfiles(profiles)
+	hub.SetStore(st)
 	go hub.Run()
+	background(hub.RunMatchLog)
 
-	authz, err := auth.NewAuth("./data")
+	authz, err := auth.NewAuth(cfg.DataDir, st)
 	if err != nil {
 		panic(err)
 	}
-	guilds, err := srv.NewGuilds("./data")
+	authz.SetTokens(cfg.JWTIssuer, cfg.JWTLifetime.D())
+	// Profiles from before account IDs are re-keyed once; collisions are logged
+	rep, err := store.MigrateProfiles(st, false)
+	if err != nil {
+		log.Fatalf("profiles: migrate: %v", err)
+	}
+	reportProfileMigration(rep, log.Printf)
+	guilds, err := srv.NewGuilds(st)
 	if err != nil {
 		panic(err)
 	}
 	hub.SetGuilds(guilds)
-	social, err := srv.NewSocial("./data")
+	social, err := srv.NewSocial(st)
 	if err != nil {
 		panic(err)
 	}
 	hub.SetSocial(social)
+	tournaments, err := srv.NewTournaments(st)
+	if err != nil {
+		panic(err)
+	}
+	hub.SetTournaments(tournaments)
+	// Matches saved by the previous process wait for their players
+	if n := hub.ResumeMatches(); n > 0 {
+		log.Printf("resumed %d match(es)", n)
+	}
+	go hub.RunTournaments()
+	if cfg.CheckpointEvery > 0 {
+		background(func(stop <-chan struct{}) { hub.RunCheckpoints(cfg.CheckpointEvery.D(), stop) })
+	}
+	if cfg.SnapshotEvery > 0 {
+		background(func(stop <-chan struct{}) {
+			runSnapshots(st, profiles.Flush, cfg.DataDir, cfg.SnapshotDir, cfg.SnapshotEvery.D(), cfg.SnapshotKeep, stop)
+		})
+	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/ws", wsHandler(hub, authz))
+	mux.HandleFunc("/ws", wsHandler(hub, authz, newUpgrader(&cfg)))
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("ok")) })
 	mux.HandleFunc("/api/register", authz.HandleRegister)
 	mux.HandleFunc("/api/login", authz.HandleLogin)
-	// /api/profile — read current user's profile JSON from disk
+	mux.HandleFunc("/api/tournaments", tournaments.HandleAPI)
+	hub.SetAdminCheck(authz.IsAdmin)
+	registerAdmin(mux, hub, authz)
+	mux.Handle("/metrics", metrics.Handler())
+	// /api/profile — read current user's profile JSON from the store
 	mux.Handle("/api/profile", authz.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Extract username again (RequireAuth validated it already)
 		var tok string
@@ -95,102 +190,81 @@ func main() {
 			return
 		}
 
-		// Build path using existing helpers from persist.go
-		path := profilePath(username)
-		_ = os.MkdirAll(filepath.Dir(path), 0o755)
-
-		// Defaults for new users
-		prof := protocol.Profile{
-			Name:      username,
-			Armies:    map[string][]string{},
-			UnitXP:    map[string]int{},
-			Resources: map[string]int{},
-		}
-		if b, err := os.ReadFile(path); err == nil && len(b) > 0 {
-			_ = json.Unmarshal(b, &prof)
-		}
-		if prof.Avatar == "" {
-			prof.Avatar = "default.png"
-		}
-		if prof.PvPRating == 0 {
-			prof.PvPRating = 1200
-		}
-		if prof.PvPRank == "" {
-			prof.PvPRank = "Knight"
+		prof, err := profiles.Load(username)
+		if err != nil {
+			http.Error(w, "profile unavailable", http.StatusInternalServerError)
+			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(prof)
 	})))
 
-	srvAddr := ":8080"
 	s := &http.Server{
-		Addr:         srvAddr,
+		Addr:         cfg.Listen,
 		Handler:      mux,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.ReadTimeout.D(),
+		WriteTimeout: cfg.WriteTimeout.D(),
+		IdleTimeout:  cfg.IdleTimeout.D(),
 	}
 	log.Printf("WarRumble Server v%s starting...", protocol.GameVersion)
-	log.Println("server listening on", srvAddr)
-	log.Fatal(s.ListenAndServe())
-}
+	log.Printf("data dir %s, %s store", cfg.DataDir, cfg.Store)
 
-// ------- minimal local helpers to read profiles (mirrors srv/hub.go logic) -------
-func safeFileName(name string) string {
-	re := regexp.MustCompile(`[^a-zA-Z0-9]+`)
-	s := re.ReplaceAllString(name, "_")
-	if s == "" {
-		s = "player"
-	}
-	return s
-}
-func profilePath(name string) string {
-	return filepath.Join("data", "profiles", safeFileName(name)+".json")
-}
-func readProfile(name string) protocol.Profile {
-	_ = os.MkdirAll(filepath.Join("data", "profiles"), 0o755)
-	p := protocol.Profile{
-		Name:      name,
-		Army:      nil,
-		Armies:    map[string][]string{},
-		Gold:      0,
-		AccountXP: 0,
-		UnitXP:    map[string]int{},
-		Resources: map[string]int{},
-	}
-	b, err := os.ReadFile(profilePath(name))
-	if err != nil {
-		// defaults for new users
-		if p.PvPRating == 0 {
-			p.PvPRating = 1200
-		}
-		if p.PvPRank == "" {
-			p.PvPRank = "Knight"
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := make(chan error, 1)
+	go func() {
+		if cfg.TLSCert != "" {
+			log.Println("server listening on", cfg.Listen, "(TLS)")
+			serveErr <- s.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+			return
 		}
-		if p.Avatar == "" {
-			p.Avatar = "default.png"
+		log.Println("server listening on", cfg.Listen)
+		serveErr <- s.ListenAndServe()
+	}()
+	var failed error
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			failed = err
 		}
-		return p
+	case <-ctx.Done():
+		stop() // a second signal kills the process
+		shutdown(s, hub, cfg.ShutdownGrace.D())
 	}
-	_ = json.Unmarshal(b, &p)
-	if p.PvPRating == 0 {
-		p.PvPRating = 1200
+	close(quit)
+	bg.Wait()
+	if err := profiles.Flush(); err != nil {
+		log.Printf("shutdown: flush profiles: %v", err)
 	}
-	if p.PvPRank == "" {
-		p.PvPRank = "Knight"
+	if failed != nil {
+		st.Close() // os.Exit skips the deferred Close
+		log.Fatalf("server: %v", failed)
 	}
-	if p.Avatar == "" {
-		p.Avatar = "default.png"
-	}
-	if p.Armies == nil {
-		p.Armies = map[string][]string{}
+	log.Println("server stopped")
+}
+
+// shutdown drains the server: no new matches, running ones get grace to
+// finish and are saved for the next start after that, then every
+// connection is closed.
+func shutdown(s *http.Server, hub *srv.Hub, grace time.Duration) {
+	log.Printf("shutdown: draining, up to %s for running matches", grace)
+	hub.BeginShutdown(grace)
+	if n := hub.WaitForMatches(time.Now().Add(grace)); n > 0 {
+		log.Printf("shutdown: saved %d of %d unfinished match(es)", hub.SuspendMatches(), n)
 	}
-	if p.UnitXP == nil {
-		p.UnitXP = map[string]int{}
+	hub.DisconnectAll()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("shutdown: http: %v", err)
 	}
-	if p.Resources == nil {
-		p.Resources = map[string]int{}
+}
+
+func durations(ds []config.Duration) []time.Duration {
+	out := make([]time.Duration, len(ds))
+	for i, d := range ds {
+		out[i] = d.D()
 	}
-	return p
+	return out
 }
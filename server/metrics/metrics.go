@@ -0,0 +1,301 @@
+// Package metrics is a small registry of counters, gauges and histograms
+// served in the Prometheus text exposition format, so the server can be
+// scraped (or just curled) without pulling in a client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A metric writes its samples in exposition format.
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+var (
+	regMu    sync.Mutex
+	registry = map[string]metric{}
+)
+
+// register adds m, replacing a metric of the same name.
+func register(m metric) {
+	regMu.Lock()
+	registry[m.name()] = m
+	regMu.Unlock()
+}
+
+// Handler serves every registered metric, sorted by name.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteAll(w)
+	})
+}
+
+// WriteAll writes every registered metric to w.
+func WriteAll(w io.Writer) {
+	regMu.Lock()
+	ms := make([]metric, 0, len(registry))
+	for _, m := range registry {
+		ms = append(ms, m)
+	}
+	regMu.Unlock()
+	sort.Slice(ms, func(i, j int) bool { return ms[i].name() < ms[j].name() })
+	for _, m := range ms {
+		m.write(w)
+	}
+}
+
+// maxSeries caps the label combinations of one metric; past it new ones are
+// counted under "other", so client-chosen values (message types) cannot grow
+// a metric without bound.
+const maxSeries = 500
+
+// ---- Counters
+
+// CounterVec is a set of counters split by the values of its labels.
+type CounterVec struct {
+	n, help string
+	labels  []string
+	mu      sync.Mutex
+	vals    map[string]float64 // by joined label values
+}
+
+// NewCounterVec registers a counter with the given label names (none for a
+// plain counter).
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{n: name, help: help, labels: labels, vals: map[string]float64{}}
+	register(c)
+	return c
+}
+
+// Inc adds one to the counter for the label values.
+func (c *CounterVec) Inc(values ...string) { c.Add(1, values...) }
+
+// Add adds v to the counter for the label values.
+func (c *CounterVec) Add(v float64, values ...string) {
+	k := joinValues(values)
+	c.mu.Lock()
+	if _, ok := c.vals[k]; !ok && len(c.vals) >= maxSeries {
+		k = otherKey(len(values))
+	}
+	c.vals[k] += v
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) name() string { return c.n }
+
+func (c *CounterVec) write(w io.Writer) {
+	header(w, c.n, c.help, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.labels) == 0 && len(c.vals) == 0 {
+		fmt.Fprintf(w, "%s 0\n", c.n)
+		return
+	}
+	for _, k := range sortedKeys(c.vals) {
+		fmt.Fprintf(w, "%s%s %s\n", c.n, labelString(c.labels, splitValues(k), ""), formatFloat(c.vals[k]))
+	}
+}
+
+// ---- Gauges
+
+// GaugeFunc is a gauge read at scrape time. fn returns the value for each
+// combination of label values, joined with JoinLabels.
+type GaugeFunc struct {
+	n, help string
+	labels  []string
+	fn      func() map[string]float64
+}
+
+// NewGaugeFunc registers a gauge computed by fn on every scrape. With no
+// labels fn returns a single value under the key "".
+func NewGaugeFunc(name, help string, fn func() map[string]float64, labels ...string) *GaugeFunc {
+	g := &GaugeFunc{n: name, help: help, labels: labels, fn: fn}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) name() string { return g.n }
+
+func (g *GaugeFunc) write(w io.Writer) {
+	header(w, g.n, g.help, "gauge")
+	vals := g.fn()
+	if len(g.labels) == 0 {
+		fmt.Fprintf(w, "%s %s\n", g.n, formatFloat(vals[""]))
+		return
+	}
+	for _, k := range sortedKeys(vals) {
+		fmt.Fprintf(w, "%s%s %s\n", g.n, labelString(g.labels, splitValues(k), ""), formatFloat(vals[k]))
+	}
+}
+
+// JoinLabels builds a GaugeFunc key from label values.
+func JoinLabels(values ...string) string { return joinValues(values) }
+
+// ---- Histograms
+
+// HistogramVec counts observations into cumulative buckets, split by label
+// values.
+type HistogramVec struct {
+	n, help string
+	labels  []string
+	buckets []float64 // upper bounds, ascending; +Inf is implied
+	mu      sync.Mutex
+	series  map[string]*histSeries
+}
+
+type histSeries struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a histogram with the given bucket upper bounds.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{n: name, help: help, labels: labels, buckets: buckets, series: map[string]*histSeries{}}
+	register(h)
+	return h
+}
+
+// Observe records v for the label values.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	k := joinValues(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.series[k]
+	if s == nil && len(h.series) >= maxSeries {
+		k = otherKey(len(values))
+		s = h.series[k]
+	}
+	if s == nil {
+		s = &histSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[k] = s
+	}
+	for i, ub := range h.buckets {
+		if v <= ub {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) name() string { return h.n }
+
+func (h *HistogramVec) write(w io.Writer) {
+	header(w, h.n, h.help, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.labels) == 0 && len(h.series) == 0 {
+		h.series[""] = &histSeries{counts: make([]uint64, len(h.buckets))}
+	}
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := h.series[k]
+		values := splitValues(k)
+		var cum uint64
+		for i, ub := range h.buckets {
+			cum += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.n, labelString(h.labels, values, formatFloat(ub)), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.n, labelString(h.labels, values, "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.n, labelString(h.labels, values, ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.n, labelString(h.labels, values, ""), s.count)
+	}
+}
+
+// ExpBuckets returns n bucket bounds starting at start, each factor times
+// the last.
+func ExpBuckets(start, factor float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = start
+		start *= factor
+	}
+	return out
+}
+
+// ---- Formatting
+
+const valueSep = "\xff" // cannot appear in label values we produce
+
+func joinValues(values []string) string { return strings.Join(values, valueSep) }
+
+func otherKey(n int) string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = "other"
+	}
+	return joinValues(values)
+}
+
+func splitValues(k string) []string {
+	if k == "" {
+		return nil
+	}
+	return strings.Split(k, valueSep)
+}
+
+func header(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+// labelString renders {a="x",b="y"}, adding le when it is not empty.
+func labelString(names, values []string, le string) string {
+	var parts []string
+	for i, n := range names {
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+		parts = append(parts, n+`="`+escapeLabel(v)+`"`)
+	}
+	if le != "" {
+		parts = append(parts, `le="`+le+`"`)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
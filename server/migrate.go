@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rumble/server/config"
+	"rumble/server/store"
+)
+
+// subcommandConfig resolves the configuration from the config file and the
+// environment, so the subcommands default to the same data as the server.
+func subcommandConfig(name string) (config.Config, bool) {
+	cfg, err := config.FromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: config: %v\n", name, err)
+		return cfg, false
+	}
+	return cfg, true
+}
+
+// runMigrate implements "server migrate": copy everything from one store to
+// another, e.g. the JSON files under data/ into an embedded database. The
+// defaults follow the configured data dir and database file.
+//
+//	server migrate -from json:data -to bolt:data/rumble.db
+func runMigrate(args []string) int {
+	cfg, ok := subcommandConfig("migrate")
+	if !ok {
+		return 1
+	}
+	fl := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	from := fl.String("from", "json:"+cfg.DataDir, "source store, kind:path")
+	to := fl.String("to", "bolt:"+cfg.DBPath, "destination store, kind:path")
+	if err := fl.Parse(args); err != nil {
+		return 2
+	}
+	srcKind, srcPath, ok1 := strings.Cut(*from, ":")
+	dstKind, dstPath, ok2 := strings.Cut(*to, ":")
+	if !ok1 || !ok2 {
+		fmt.Fprintln(os.Stderr, "migrate: -from and -to take kind:path, e.g. json:data or bolt:data/rumble.db")
+		return 2
+	}
+	if srcKind == dstKind && filepath.Clean(srcPath) == filepath.Clean(dstPath) {
+		fmt.Fprintln(os.Stderr, "migrate: source and destination are the same store")
+		return 2
+	}
+
+	src, err := store.Open(srcKind, srcPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: open %s: %v\n", *from, err)
+		return 1
+	}
+	defer src.Close()
+	dst, err := store.Open(dstKind, dstPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: open %s: %v\n", *to, err)
+		return 1
+	}
+	defer dst.Close()
+
+	counts, err := store.Copy(dst, src)
+	for _, b := range store.Buckets {
+		fmt.Printf("%-12s %d\n", b, counts[b])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+		return 1
+	}
+	fmt.Printf("migrated %s -> %s\n", *from, *to)
+	return 0
+}
+
+// runMigrateProfiles implements "server migrate-profiles": re-key name-keyed
+// profiles by account ID and report names that used to share a profile.
+// The server also does this on startup; -dry-run only reports.
+//
+//	server migrate-profiles -store json:data -dry-run
+func runMigrateProfiles(args []string) int {
+	cfg, ok := subcommandConfig("migrate-profiles")
+	if !ok {
+		return 1
+	}
+	storePath := cfg.DataDir
+	if cfg.Store != "json" {
+		storePath = cfg.DBPath
+	}
+	fl := flag.NewFlagSet("migrate-profiles", flag.ContinueOnError)
+	at := fl.String("store", cfg.Store+":"+storePath, "store to migrate, kind:path")
+	dryRun := fl.Bool("dry-run", false, "report collisions without writing anything")
+	if err := fl.Parse(args); err != nil {
+		return 2
+	}
+	kind, path, ok := strings.Cut(*at, ":")
+	if !ok {
+		fmt.Fprintln(os.Stderr, "migrate-profiles: -store takes kind:path, e.g. json:data")
+		return 2
+	}
+	st, err := store.Open(kind, path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-profiles: open %s: %v\n", *at, err)
+		return 1
+	}
+	defer st.Close()
+	rep, err := store.MigrateProfiles(st, *dryRun)
+	reportProfileMigration(rep, func(format string, args ...interface{}) { fmt.Printf(format+"\n", args...) })
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-profiles: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func reportProfileMigration(rep store.ProfileMigration, printf func(format string, args ...interface{})) {
+	if rep.Moved > 0 {
+		printf("profiles: %d re-keyed by account ID", rep.Moved)
+	}
+	for _, name := range rep.Skipped {
+		printf("profiles: kept legacy profile %q, its account already has one", name)
+	}
+	for _, c := range rep.Collisions {
+		owner := c.Owner
+		if owner == "" {
+			owner = "nobody"
+		}
+		printf("profiles: collision: %s was shared by %s; %s keeps it, the others start fresh",
+			c.File, strings.Join(c.Names, ", "), owner)
+	}
+}
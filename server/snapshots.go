@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"rumble/server/store"
+)
+
+// runSnapshots takes a snapshot of dataDir every interval until stop is
+// closed. Pending profile saves are flushed first so the snapshot has them.
+func runSnapshots(st store.Store, flush func() error, dataDir, snapDir string, every time.Duration, keep int, stop <-chan struct{}) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		if err := flush(); err != nil {
+			log.Printf("snapshot: flush profiles: %v", err)
+		}
+		name, err := store.Snapshot(st, dataDir, snapDir, keep)
+		if err != nil {
+			log.Printf("snapshot: %v", err)
+			continue
+		}
+		log.Printf("snapshot: wrote %s", name)
+	}
+}
+
+// runRestore implements "server restore": list the snapshots, or roll the
+// data directory back to one. Stop the server first.
+//
+//	server restore                       # list snapshots, newest first
+//	server restore data-20240101-120000  # or "latest"
+func runRestore(args []string) int {
+	cfg, ok := subcommandConfig("restore")
+	if !ok {
+		return 1
+	}
+	fl := flag.NewFlagSet("restore", flag.ContinueOnError)
+	dataDir := fl.String("data", cfg.DataDir, "data directory to restore into")
+	dbPath := fl.String("db", cfg.DBPath, "where a database snapshotted from outside the data directory goes back")
+	snapDir := fl.String("snapshot-dir", cfg.SnapshotDir, "directory holding the snapshots")
+	if err := fl.Parse(args); err != nil {
+		return 2
+	}
+	if fl.NArg() == 0 {
+		names, err := store.ListSnapshots(*snapDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "restore: %v\n", err)
+			return 1
+		}
+		if len(names) == 0 {
+			fmt.Printf("no snapshots in %s\n", *snapDir)
+			return 0
+		}
+		for _, n := range names {
+			fmt.Println(n)
+		}
+		return 0
+	}
+	if fl.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "restore: give one snapshot name, or none to list them")
+		return 2
+	}
+
+	aside, err := store.Restore(*dataDir, *dbPath, *snapDir, fl.Arg(0))
+	for _, p := range aside {
+		fmt.Printf("previous data moved to %s\n", p)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "restore: %v\n", err)
+		return 1
+	}
+	fmt.Printf("restored %s from %s\n", *dataDir, fl.Arg(0))
+	return 0
+}
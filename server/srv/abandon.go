@@ -0,0 +1,204 @@
+package srv
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+// Defaults; the server config may change them (see Configure).
+var (
+	// afkCappedSeconds is how long a player may sit at the gold cap without
+	// deploying before counting as AFK.
+	afkCappedSeconds = 45.0
+	// leaverWindow is how long an abandon counts towards the leaver record.
+	leaverWindow = 24 * time.Hour
+	// leaverCooldowns[i] is the ranked queue ban after the (i+1)-th recent
+	// abandon: a warning first, then growing cooldowns. The last entry
+	// repeats.
+	leaverCooldowns = []time.Duration{0, 2 * time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute}
+)
+
+// leaverCooldown is the ranked queue ban after the count-th recent abandon.
+func leaverCooldown(count int) time.Duration {
+	if count <= 0 || len(leaverCooldowns) == 0 {
+		return 0
+	}
+	return leaverCooldowns[min(count, len(leaverCooldowns))-1]
+}
+
+// resolvesAbandonment reports whether a side that leaves or goes AFK loses
+// the match (ranked queues and tournament matches).
+func (r *Room) resolvesAbandonment() bool {
+	return r.isRanked() || r.tourney != nil
+}
+
+// noteDeparture keeps a copy of a player leaving a running match so the
+// result can still rate them, and records the abandon. Called from Leave
+// with hub.mu held.
+func (r *Room) noteDeparture(c *client) {
+	pl := r.g.players[c.id]
+	if !r.active || pl == nil || !r.resolvesAbandonment() || r.g.matchEnded {
+		return
+	}
+	r.addDeparted(pl)
+	log.Printf("ROOM %s: %s left mid-match", r.id, pl.Name)
+	if r.isRanked() && r.hub != nil {
+		if s := r.hub.sessions[c]; s != nil {
+			r.hub.penalizeLeaverLocked(s)
+		}
+	}
+}
+
+// addDeparted keeps a copy of pl among the players who left.
+func (r *Room) addDeparted(pl *Player) {
+	cp := *pl
+	r.departMu.Lock()
+	defer r.departMu.Unlock()
+	if r.departed == nil {
+		r.departed = map[int64]*Player{}
+	}
+	r.departed[cp.ID] = &cp
+}
+
+// departedPlayers returns the players who left, in no particular order.
+func (r *Room) departedPlayers() []*Player {
+	r.departMu.Lock()
+	defer r.departMu.Unlock()
+	out := make([]*Player, 0, len(r.departed))
+	for _, p := range r.departed {
+		out = append(out, p)
+	}
+	return out
+}
+
+// trackAFK advances each human's time at the gold cap; deploying spends
+// gold and resets it.
+func (r *Room) trackAFK(dt float64) {
+	if !r.resolvesAbandonment() {
+		return
+	}
+	max := r.g.goldMax()
+	for _, c := range r.players {
+		if pl := r.g.players[c.id]; pl != nil {
+			if pl.Gold >= max {
+				pl.CappedFor += dt
+			} else {
+				pl.CappedFor = 0
+			}
+		}
+	}
+}
+
+// abandonedTeam finds a side whose humans have all left or gone AFK while
+// the other side still has someone playing. AFK players on that side are
+// returned so they can be penalized like leavers.
+func (r *Room) abandonedTeam() (team int, afk []*client, ok bool) {
+	if !r.resolvesAbandonment() {
+		return 0, nil, false
+	}
+	var present, gone [2]int
+	var idle [2][]*client
+	for _, p := range r.departedPlayers() {
+		if p.TeamID == 0 || p.TeamID == 1 {
+			gone[p.TeamID]++
+		}
+	}
+	for _, c := range r.players {
+		p := r.g.players[c.id]
+		if p == nil || (p.TeamID != 0 && p.TeamID != 1) {
+			continue
+		}
+		if p.CappedFor >= afkCappedSeconds {
+			gone[p.TeamID]++
+			idle[p.TeamID] = append(idle[p.TeamID], c)
+		} else {
+			present[p.TeamID]++
+		}
+	}
+	for t := 0; t < 2; t++ {
+		if present[t] == 0 && gone[t] > 0 && present[1-t] > 0 {
+			return t, idle[t], true
+		}
+	}
+	return 0, nil, false
+}
+
+// announceAbandon tells the room who lost by leaving or idling and
+// penalizes the AFK players (leavers were penalized when they left).
+func (r *Room) announceAbandon(team int, afk []*client) {
+	var left []string
+	for _, p := range r.departedPlayers() {
+		if p.TeamID == team {
+			left = append(left, p.Name)
+		}
+	}
+	var idle []string
+	for _, c := range afk {
+		if p := r.g.players[c.id]; p != nil {
+			idle = append(idle, p.Name)
+		}
+	}
+	msgs := []protocol.MatchAbandoned{}
+	if len(left) > 0 {
+		msgs = append(msgs, protocol.MatchAbandoned{Names: left, Reason: "left"})
+	}
+	if len(idle) > 0 {
+		msgs = append(msgs, protocol.MatchAbandoned{Names: idle, Reason: "afk"})
+	}
+	for _, m := range msgs {
+		for _, c := range r.players {
+			sendJSON(c, "MatchAbandoned", m)
+		}
+	}
+	if r.hub == nil || !r.isRanked() || len(afk) == 0 {
+		return
+	}
+	r.hub.mu.Lock()
+	for _, c := range afk {
+		if s := r.hub.sessions[c]; s != nil {
+			r.hub.penalizeLeaverLocked(s)
+		}
+	}
+	r.hub.mu.Unlock()
+}
+
+// penalizeLeaverLocked adds an abandon to s's profile and sets the queue
+// cooldown it earns. h.mu must be held.
+func (h *Hub) penalizeLeaverLocked(s *Session) {
+	now := time.Now()
+	p := &s.Profile
+	if p.LastLeftAt > 0 && now.Sub(time.UnixMilli(p.LastLeftAt)) > leaverWindow {
+		p.LeaverCount = 0
+	}
+	p.LeaverCount++
+	p.LastLeftAt = now.UnixMilli()
+	if d := leaverCooldown(p.LeaverCount); d > 0 {
+		p.QueueBanUntil = now.Add(d).UnixMilli()
+	}
+	if err := h.profiles.Save(*p); err != nil {
+		log.Printf("profiles.Save(leaver): %v", err)
+	}
+	log.Printf("leaver: %s has %d recent abandons", p.Name, p.LeaverCount)
+}
+
+// queueBlockedLocked refuses a ranked queue request from c while its leaver
+// cooldown runs. It reports whether c was blocked. h.mu must be held.
+func (h *Hub) queueBlockedLocked(c *client) bool {
+	s := h.sessions[c]
+	if s == nil || s.Profile.QueueBanUntil == 0 {
+		return false
+	}
+	left := time.Until(time.UnixMilli(s.Profile.QueueBanUntil))
+	if left <= 0 {
+		return false
+	}
+	secs := int(left.Seconds()) + 1
+	sendJSON(c, "QueueBlocked", protocol.QueueBlocked{
+		Seconds: secs,
+		Message: fmt.Sprintf("You left a ranked match recently. You can queue again in %d:%02d.", secs/60, secs%60),
+	})
+	return true
+}
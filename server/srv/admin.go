@@ -0,0 +1,262 @@
+package srv
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+// Operator access to a running hub, for the /admin HTTP API. Reads and
+// changes of room state go through onLoop, so they happen between two ticks
+// on the Run goroutine and never race Room.Tick.
+
+// AdminRoom describes one live room.
+type AdminRoom struct {
+	ID       string   `json:"id"`
+	Mode     string   `json:"mode"`
+	Active   bool     `json:"active"`
+	Players  []string `json:"players"` // connected humans
+	InGame   []string `json:"inGame"`  // everyone in the battle, bots included, as "name (team)"
+	Watchers int      `json:"watchers"`
+	Tick     int      `json:"tick"`
+	// Seconds left on the match clock; for endless modes the run time so far
+	TimeLeft float64 `json:"timeLeft"`
+	Endless  bool    `json:"endless,omitempty"`
+}
+
+// AdminClient describes one connection.
+type AdminClient struct {
+	Name     string `json:"name"`
+	PlayerID int64  `json:"playerId"`
+	Room     string `json:"room,omitempty"`
+	Watching string `json:"watching,omitempty"`
+}
+
+// AdminQueues lists who is waiting for a match.
+type AdminQueues struct {
+	PvP      []string          `json:"pvp"`
+	Team     []string          `json:"team"`
+	Draft    []string          `json:"draft"`
+	Friendly map[string]string `json:"friendly"` // code -> host
+}
+
+// onLoop runs fn on the Run goroutine between two ticks and waits for it.
+func (h *Hub) onLoop(fn func()) {
+	done := make(chan struct{})
+	h.ops <- func() {
+		fn()
+		close(done)
+	}
+	<-done
+}
+
+// AdminRooms lists the live rooms, newest ID first.
+func (h *Hub) AdminRooms() []AdminRoom {
+	out := []AdminRoom{}
+	h.onLoop(func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for id, r := range h.rooms {
+			ar := AdminRoom{
+				ID:       id,
+				Mode:     r.Mode,
+				Active:   r.active,
+				Players:  []string{},
+				InGame:   []string{},
+				Tick:     r.tick,
+				TimeLeft: r.g.timeRemaining,
+				Endless:  r.g.endless,
+			}
+			for _, c := range r.players {
+				ar.Players = append(ar.Players, h.adminNameLocked(c))
+			}
+			for _, p := range r.g.players {
+				ar.InGame = append(ar.InGame, p.Name+" ("+teamLabel(p.TeamID)+")")
+			}
+			sort.Strings(ar.InGame)
+			r.watchMu.Lock()
+			ar.Watchers = len(r.watchers)
+			r.watchMu.Unlock()
+			out = append(out, ar)
+		}
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out
+}
+
+func teamLabel(team int) string {
+	if team == 0 {
+		return "team 1"
+	}
+	return "team 2"
+}
+
+// adminNameLocked is the best name for c. h.mu must be held.
+func (h *Hub) adminNameLocked(c *client) string {
+	if n := h.sessionNameLocked(c); n != "" {
+		return n
+	}
+	return c.name
+}
+
+// AdminClients lists the connected clients by name.
+func (h *Hub) AdminClients() []AdminClient {
+	h.mu.Lock()
+	out := make([]AdminClient, 0, len(h.clients))
+	for c := range h.clients {
+		ac := AdminClient{Name: h.adminNameLocked(c)}
+		if s := h.sessions[c]; s != nil {
+			ac.PlayerID = s.PlayerID
+		}
+		if c.room != nil {
+			ac.Room = c.room.id
+		}
+		if c.watching != nil {
+			ac.Watching = c.watching.id
+		}
+		out = append(out, ac)
+	}
+	h.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name) })
+	return out
+}
+
+// AdminQueues lists the matchmaking queues and open friendly codes.
+func (h *Hub) AdminQueues() AdminQueues {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	q := AdminQueues{PvP: []string{}, Team: []string{}, Draft: []string{}, Friendly: map[string]string{}}
+	for _, c := range h.pvpQueue {
+		q.PvP = append(q.PvP, h.adminNameLocked(c))
+	}
+	for _, c := range h.teamQueue {
+		q.Team = append(q.Team, h.adminNameLocked(c))
+	}
+	for _, c := range h.draftQueue {
+		q.Draft = append(q.Draft, h.adminNameLocked(c))
+	}
+	for code, c := range h.friendly {
+		q.Friendly[code] = h.adminNameLocked(c)
+	}
+	return q
+}
+
+// ErrNoRoom is returned for a room ID that is not live.
+var ErrNoRoom = errors.New("no such room")
+
+// AdminEndRoom ends the match in a room as a draw: no rating, XP or
+// tournament result is recorded. The players stay in the room, so a lobby
+// can still rematch.
+func (h *Hub) AdminEndRoom(id string) error {
+	var err error
+	var lobby *Room
+	h.onLoop(func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		r := h.rooms[id]
+		if r == nil {
+			err = ErrNoRoom
+			return
+		}
+		if r.endWith(operatorMsg) && r.lobby != nil {
+			lobby = r
+		}
+	})
+	if lobby != nil {
+		h.reopenLobby(lobby) // takes h.mu itself
+	}
+	return err
+}
+
+// AdminKillRoom ends the match like AdminEndRoom, then removes the room and
+// detaches its players and spectators.
+func (h *Hub) AdminKillRoom(id string) error {
+	var err error
+	h.onLoop(func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		r := h.rooms[id]
+		if r == nil {
+			err = ErrNoRoom
+			return
+		}
+		r.endWith(operatorMsg)
+		for _, c := range r.players {
+			c.room = nil
+		}
+		r.players = nil
+		r.eachWatcher(h.stopWatchingLocked)
+		delete(h.rooms, id)
+	})
+	return err
+}
+
+const operatorMsg = "Ended by a server operator"
+
+// endWith stops a running battle (or a resumed one still waiting for its
+// players) as a draw and tells everyone why; it reports whether there was
+// one. Its saved copy goes too, so a restart does not bring it back. Runs
+// on the Run goroutine.
+func (r *Room) endWith(reason string) bool {
+	if r.checkpointed {
+		r.dropCheckpoint()
+	}
+	if !r.active && r.resume == nil {
+		return false
+	}
+	r.active, r.resume = false, nil
+	mMatchesFinished.Inc(r.Mode)
+	over := protocol.GameOver{WinnerID: -1, Reason: reason}
+	for _, c := range r.players {
+		sendJSON(c, "GameOver", over)
+	}
+	r.sendWatchers("GameOver", over)
+	return true
+}
+
+// Kick closes every connection of name after telling it why, and returns
+// how many there were.
+func (h *Hub) Kick(name, reason string, banned bool) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for c := range h.clients {
+		if !strings.EqualFold(h.adminNameLocked(c), name) {
+			continue
+		}
+		sendJSON(c, "Kicked", protocol.Kicked{Reason: reason, Banned: banned})
+		conn := c.conn
+		// give the writer a moment to deliver the notice; the reader then
+		// fails and cleans up as for any disconnect
+		time.AfterFunc(500*time.Millisecond, func() { _ = conn.Close() })
+		n++
+	}
+	return n
+}
+
+// Announce shows text to every connected client for d, and to clients that
+// connect before it runs out. An empty text clears the banner.
+func (h *Hub) Announce(text string, d time.Duration) protocol.Announcement {
+	a := protocol.Announcement{Text: strings.TrimSpace(text)}
+	if a.Text != "" {
+		a.ExpiresAt = time.Now().Add(d).UnixMilli()
+	}
+	h.mu.Lock()
+	h.announcement = a
+	for c := range h.clients {
+		sendJSON(c, "Announcement", a)
+	}
+	h.mu.Unlock()
+	return a
+}
+
+// sendAnnouncementLocked gives a new connection the running announcement.
+// h.mu must be held.
+func (h *Hub) sendAnnouncementLocked(c *client) {
+	if a := h.announcement; a.Text != "" && time.Now().UnixMilli() < a.ExpiresAt {
+		sendJSON(c, "Announcement", a)
+	}
+}
@@ -0,0 +1,218 @@
+package srv
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rumble/shared/protocol"
+)
+
+// BossDef describes a PvE boss: a large single unit whose behaviour changes
+// in phases as its HP drops.
+type BossDef struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Portrait    string      `json:"portrait"`
+	Class       string      `json:"class"` // melee | range
+	SubClass    string      `json:"subclass,omitempty"`
+	HP          int         `json:"hp"`
+	DMG         int         `json:"dmg"`
+	Speed       float64     `json:"speed"`      // same tiers as minis
+	Stationary  bool        `json:"stationary"` // guards its spot instead of marching
+	Range       int         `json:"range"`
+	AttackSpeed float64     `json:"attack_speed,omitempty"`
+	Particle    string      `json:"particle,omitempty"`
+	Immune      []string    `json:"immune,omitempty"` // attacker classes/subclasses that deal no damage
+	Phases      []BossPhase `json:"phases,omitempty"` // ordered by descending AtHPPct
+}
+
+// BossPhase is entered once the boss drops to AtHPPct of its max HP.
+type BossPhase struct {
+	Name       string   `json:"name"`
+	AtHPPct    int      `json:"atHpPct"`
+	DMGMult    float64  `json:"dmgMult,omitempty"`   // relative to the boss' base DMG
+	SpeedMult  float64  `json:"speedMult,omitempty"` // relative to the boss' base speed
+	CDMult     float64  `json:"cdMult,omitempty"`    // relative to the base attack cooldown
+	Spawn      []string `json:"spawn,omitempty"`     // mini names spawned around the boss
+	AoEDamage  int      `json:"aoeDamage,omitempty"` // one-shot area ability on phase entry
+	AoERadius  float64  `json:"aoeRadius,omitempty"`
+	Immune     []string `json:"immune,omitempty"` // replaces the boss immunities while active
+	Stationary *bool    `json:"stationary,omitempty"`
+}
+
+// bossState is the per-unit runtime state of a boss.
+type bossState struct {
+	def       *BossDef
+	phase     int // index into def.Phases, -1 before the first transition
+	baseDMG   int
+	baseSpeed float64
+	baseCD    float64
+}
+
+var bossesPath = filepath.Join(dataDir, "bosses.json")
+
+// loadBossDefs reads data/bosses.json; a missing file simply means no bosses.
+func loadBossDefs() map[string]*BossDef {
+	out := map[string]*BossDef{}
+	b, err := os.ReadFile(bossesPath)
+	if err != nil {
+		return out
+	}
+	var defs []BossDef
+	if err := json.Unmarshal(b, &defs); err != nil {
+		log.Printf("failed to parse bosses from %s: %v", bossesPath, err)
+		return out
+	}
+	for i := range defs {
+		d := &defs[i]
+		if d.ID == "" {
+			d.ID = d.Name
+		}
+		out[strings.ToLower(d.ID)] = d
+	}
+	return out
+}
+
+// SpawnMapBosses places the bosses listed in the current map for ownerID.
+func (g *Game) SpawnMapBosses(ownerID int64) {
+	if g.mapDef == nil || len(g.mapDef.Bosses) == 0 {
+		return
+	}
+	defs := loadBossDefs()
+	for _, bs := range g.mapDef.Bosses {
+		def := defs[strings.ToLower(bs.ID)]
+		if def == nil {
+			log.Printf("boss %q not found in %s", bs.ID, bossesPath)
+			continue
+		}
+		g.spawnBoss(def, ownerID, bs.X*float64(g.width), bs.Y*float64(g.height))
+	}
+}
+
+func (g *Game) spawnBoss(def *BossDef, ownerID int64, x, y float64) *Unit {
+	cd := 2.0
+	if def.AttackSpeed > 0 {
+		cd = 1.0 / def.AttackSpeed
+	}
+	card := MiniCard{
+		Name: def.Name, DMG: def.DMG, HP: def.HP, Portrait: def.Portrait,
+		Class: def.Class, SubClass: def.SubClass, Role: "boss",
+		Speed: def.Speed, Range: def.Range, Particle: def.Particle, Cooldown: cd,
+	}
+	u := g.spawnUnit(card, ownerID, x, y)
+	u.Boss = &bossState{def: def, phase: -1, baseDMG: u.DMG, baseSpeed: u.Speed, baseCD: u.AttackCooldown}
+	if def.Stationary {
+		u.Speed = 0
+	}
+	return u
+}
+
+// updateBossPhases advances bosses whose HP dropped past the next threshold.
+func (g *Game) updateBossPhases() {
+	for _, u := range g.units {
+		bs := u.Boss
+		if bs == nil || u.HP <= 0 || u.MaxHP <= 0 {
+			continue
+		}
+		for bs.phase+1 < len(bs.def.Phases) {
+			next := bs.def.Phases[bs.phase+1]
+			if u.HP*100 > next.AtHPPct*u.MaxHP {
+				break
+			}
+			bs.phase++
+			g.enterBossPhase(u, next)
+		}
+	}
+}
+
+func (g *Game) enterBossPhase(u *Unit, ph BossPhase) {
+	bs := u.Boss
+	if ph.DMGMult > 0 {
+		u.DMG = int(float64(bs.baseDMG) * ph.DMGMult)
+	}
+	if ph.SpeedMult > 0 {
+		u.Speed = bs.baseSpeed * ph.SpeedMult
+	}
+	if ph.Stationary != nil {
+		if *ph.Stationary {
+			u.Speed = 0
+		} else if u.Speed == 0 {
+			u.Speed = bs.baseSpeed
+		}
+	}
+	if ph.CDMult > 0 {
+		u.AttackCooldown = bs.baseCD * ph.CDMult
+	}
+
+	// Adds spawn in a ring around the boss
+	if len(ph.Spawn) > 0 {
+		idx := map[string]MiniCard{}
+		for _, m := range g.minis {
+			idx[strings.ToLower(m.Name)] = m
+		}
+		for i, name := range ph.Spawn {
+			card, ok := idx[strings.ToLower(name)]
+			if !ok || card.HP <= 0 {
+				continue
+			}
+			a := 2 * math.Pi * float64(i) / float64(len(ph.Spawn))
+			g.spawnUnit(card, u.OwnerID, u.X+math.Cos(a)*50, u.Y+math.Sin(a)*50)
+		}
+	}
+
+	// Area ability hits every enemy unit around the boss
+	if ph.AoEDamage > 0 && ph.AoERadius > 0 {
+		for _, v := range g.units {
+			if v.TeamID == u.TeamID || v.HP <= 0 {
+				continue
+			}
+			if hypot(u.X, u.Y, v.X, v.Y) <= ph.AoERadius {
+				v.HP -= ph.AoEDamage
+				if v.HP < 0 {
+					v.HP = 0
+				}
+			}
+		}
+	}
+
+	if g.broadcastEvent != nil {
+		g.broadcastEvent("BossPhaseEvent", protocol.BossPhaseEvent{
+			UnitID:    u.ID,
+			BossName:  u.Name,
+			Phase:     bs.phase + 1,
+			PhaseName: ph.Name,
+			X:         u.X,
+			Y:         u.Y,
+			AoERadius: ph.AoERadius,
+		})
+	}
+}
+
+// immuneTo reports whether u ignores damage from an attacker of the given class/subclass.
+func (u *Unit) immuneTo(class, subclass string) bool {
+	if u.Boss == nil {
+		return false
+	}
+	list := u.Boss.def.Immune
+	if p := u.Boss.phase; p >= 0 && u.Boss.def.Phases[p].Immune != nil {
+		list = u.Boss.def.Phases[p].Immune
+	}
+	for _, im := range list {
+		if (class != "" && strings.EqualFold(im, class)) || (subclass != "" && strings.EqualFold(im, subclass)) {
+			return true
+		}
+	}
+	return false
+}
+
+// phaseInfo returns the 1-based phase number and name for UnitState.
+func (u *Unit) phaseInfo() (int, string) {
+	if u.Boss == nil || u.Boss.phase < 0 {
+		return 0, ""
+	}
+	return u.Boss.phase + 1, u.Boss.def.Phases[u.Boss.phase].Name
+}
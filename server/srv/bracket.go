@@ -0,0 +1,184 @@
+package srv
+
+import (
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+// seedOrder lists seeds by bracket position so that, if favourites win,
+// seeds 1 and 2 meet in the final: n=4 gives 1,4,2,3.
+func seedOrder(n int) []int {
+	order := []int{1, 2}
+	for len(order) < n {
+		next := make([]int, 0, 2*len(order))
+		for _, s := range order {
+			next = append(next, s, 2*len(order)+1-s)
+		}
+		order = next
+	}
+	return order
+}
+
+// buildBracket lays out every match of a single or double elimination
+// bracket for players (sorted by seed). Empty first-round slots are byes.
+func buildBracket(players []protocol.TournamentPlayer, double bool) []protocol.TournamentMatch {
+	size, k := 2, 1
+	for size < len(players) {
+		size *= 2
+		k++
+	}
+	var ms []protocol.TournamentMatch
+	add := func(bracket string, round int) int {
+		id := len(ms)
+		ms = append(ms, protocol.TournamentMatch{ID: id, Bracket: bracket, Round: round, Status: "waiting", WinnerTo: -1, LoserTo: -1})
+		return id
+	}
+	link := func(from, to, slot int, loser bool) {
+		if loser {
+			ms[from].LoserTo, ms[from].LoserSlot = to, slot
+		} else {
+			ms[from].WinnerTo, ms[from].WinnerSlot = to, slot
+		}
+	}
+
+	// Winners bracket
+	w := make([][]int, k+1)
+	for r := 1; r <= k; r++ {
+		for i := 0; i < size>>r; i++ {
+			w[r] = append(w[r], add("W", r))
+		}
+	}
+	order := seedOrder(size)
+	for i, id := range w[1] {
+		if s := order[2*i]; s <= len(players) {
+			ms[id].A = players[s-1].Name
+		}
+		if s := order[2*i+1]; s <= len(players) {
+			ms[id].B = players[s-1].Name
+		}
+	}
+	for r := 1; r < k; r++ {
+		for i, id := range w[r] {
+			link(id, w[r+1][i/2], i%2, false)
+		}
+	}
+	if !double {
+		return ms
+	}
+
+	// Losers bracket: odd rounds pair up the survivors, even rounds bring in
+	// the losers of the next winners round (in reverse order to avoid rematches).
+	var prev []int
+	for j := 1; j <= 2*(k-1); j++ {
+		var cur []int
+		for i := 0; i < size>>(2+(j-1)/2); i++ {
+			cur = append(cur, add("L", j))
+		}
+		switch {
+		case j == 1:
+			for i, id := range w[1] {
+				link(id, cur[i/2], i%2, true)
+			}
+		case j%2 == 0:
+			for i, id := range prev {
+				link(id, cur[i], 0, false)
+			}
+			drop := w[j/2+1]
+			for i, id := range drop {
+				link(id, cur[len(cur)-1-i], 1, true)
+			}
+		default:
+			for i, id := range prev {
+				link(id, cur[i/2], i%2, false)
+			}
+		}
+		prev = cur
+	}
+	gf := add("GF", 1)
+	link(w[k][0], gf, 0, false)
+	if len(prev) == 1 {
+		link(prev[0], gf, 1, false)
+	} else {
+		link(w[k][0], gf, 1, true) // two players: the final's loser gets a second chance
+	}
+	return ms
+}
+
+// slotPending reports whether an unfinished match still feeds slot of match id.
+func slotPending(t *protocol.Tournament, id, slot int) bool {
+	for _, m := range t.Matches {
+		if m.Status == "done" {
+			continue
+		}
+		if (m.WinnerTo == id && m.WinnerSlot == slot) || (m.LoserTo == id && m.LoserSlot == slot) {
+			return true
+		}
+	}
+	return false
+}
+
+func placeInSlot(t *protocol.Tournament, id, slot int, name string) {
+	if id < 0 {
+		return
+	}
+	if slot == 0 {
+		t.Matches[id].A = name
+	} else {
+		t.Matches[id].B = name
+	}
+}
+
+// resolveMatch records winner and moves both players on. A grand final won
+// by the losers-bracket player adds a deciding reset match.
+func resolveMatch(t *protocol.Tournament, id int, winner string, walkover bool) {
+	m := &t.Matches[id]
+	m.Winner, m.Status, m.Walkover, m.RoomID = winner, "done", walkover, ""
+	loser := m.A
+	if winner == m.A {
+		loser = m.B
+	}
+	if m.Bracket == "GF" && m.Round == 1 && winner != "" && winner == m.B && m.A != "" {
+		t.Matches = append(t.Matches, protocol.TournamentMatch{
+			ID: len(t.Matches), Bracket: "GF", Round: 2, A: m.A, B: m.B,
+			Status: "waiting", WinnerTo: -1, LoserTo: -1,
+		})
+		return
+	}
+	if m.WinnerTo < 0 {
+		t.Champion = winner
+		t.Status = "finished"
+		return
+	}
+	placeInSlot(t, m.WinnerTo, m.WinnerSlot, winner)
+	placeInSlot(t, m.LoserTo, m.LoserSlot, loser)
+}
+
+// settleBracket marks matches ready once both players are known and plays
+// out byes. It returns the IDs of matches that just became ready.
+func settleBracket(t *protocol.Tournament, now time.Time) []int {
+	var ready []int
+	for changed := true; changed && t.Status == "running"; {
+		changed = false
+		for id := range t.Matches {
+			m := &t.Matches[id]
+			if m.Status != "waiting" || slotPending(t, id, 0) || slotPending(t, id, 1) {
+				continue
+			}
+			if m.A != "" && m.B != "" {
+				m.Status = "ready"
+				m.ReadyAt = now.UnixMilli()
+				ready = append(ready, id)
+				continue
+			}
+			winner := m.A
+			if winner == "" {
+				winner = m.B
+			}
+			resolveMatch(t, id, winner, true)
+			changed = true
+			break // resolveMatch may append; rescan
+		}
+	}
+	return ready
+}
@@ -0,0 +1,213 @@
+package srv
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+var campaignPath = filepath.Join(dataDir, "campaign.json")
+
+// campaignMap describes one PvE map in the campaign: what unlocks it,
+// how its stars are earned and what the first clear pays out.
+type campaignMap struct {
+	MapID      string   `json:"mapId"`
+	Name       string   `json:"name"`
+	Requires   []string `json:"requires,omitempty"`
+	BaseHPPct  int      `json:"baseHpPct"`  // 2nd star threshold
+	TimeUnder  int      `json:"timeUnder"`  // 3rd star threshold (seconds)
+	RewardGold int      `json:"rewardGold"` // first clear only
+	RewardXP   int      `json:"rewardXp"`   // first clear only
+}
+
+// loadCampaign reads data/campaign.json, falling back to the built-in
+// "rumble_world" chain. Like maps, it is read fresh on every call.
+func loadCampaign() []campaignMap {
+	if b, err := os.ReadFile(campaignPath); err == nil {
+		var defs []campaignMap
+		if err := json.Unmarshal(b, &defs); err == nil && len(defs) > 0 {
+			return defs
+		}
+		log.Printf("campaign: bad %s, using defaults", campaignPath)
+	}
+	return []campaignMap{
+		{MapID: "west_keep", Name: "Western Keep", BaseHPPct: 50, TimeUnder: 120, RewardGold: 50, RewardXP: 10},
+		{MapID: "south_gate", Name: "South Gate", Requires: []string{"west_keep"}, BaseHPPct: 50, TimeUnder: 120, RewardGold: 75, RewardXP: 15},
+		{MapID: "mid_bridge", Name: "Central Bridge", Requires: []string{"south_gate"}, BaseHPPct: 60, TimeUnder: 130, RewardGold: 100, RewardXP: 20},
+		{MapID: "east_gate", Name: "Eastern Gate", Requires: []string{"mid_bridge"}, BaseHPPct: 60, TimeUnder: 140, RewardGold: 125, RewardXP: 25},
+		{MapID: "north_tower", Name: "North Tower", Requires: []string{"mid_bridge", "east_gate"}, BaseHPPct: 70, TimeUnder: 150, RewardGold: 200, RewardXP: 40},
+	}
+}
+
+func findCampaignMap(defs []campaignMap, mapID string) *campaignMap {
+	for i := range defs {
+		if strings.EqualFold(defs[i].MapID, mapID) {
+			return &defs[i]
+		}
+	}
+	return nil
+}
+
+// campaignUnlocked reports whether every prerequisite of def has at least one star.
+func campaignUnlocked(def *campaignMap, prof protocol.Profile) bool {
+	for _, req := range def.Requires {
+		if prof.Campaign[req].Stars < 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// campaignMapAllowed is the server-side gate for CreatePve. Maps outside
+// the campaign (editor test maps etc.) stay playable unless a world hotspot
+// requires something first.
+func campaignMapAllowed(mapID string, prof protocol.Profile) bool {
+	def := findCampaignMap(loadCampaign(), mapID)
+	if def != nil && !campaignUnlocked(def, prof) {
+		return false
+	}
+	return worldUnlocked(mapID, prof)
+}
+
+func buildCampaignState(prof protocol.Profile) protocol.CampaignState {
+	defs := loadCampaign()
+	st := protocol.CampaignState{Nodes: make([]protocol.CampaignNode, 0, len(defs))}
+	for i := range defs {
+		d := &defs[i]
+		var md *protocol.MapDef
+		if m, err := loadMapDef(d.MapID); err == nil {
+			md = &m
+		}
+		st.Nodes = append(st.Nodes, protocol.CampaignNode{
+			MapID:      d.MapID,
+			Name:       d.Name,
+			Requires:   d.Requires,
+			Unlocked:   campaignUnlocked(d, prof),
+			Stars:      prof.Campaign[d.MapID].Stars,
+			BaseHPPct:  d.BaseHPPct,
+			TimeUnder:  d.TimeUnder,
+			Timed:      matchTimeLimit(md) > 0,
+			RewardGold: d.RewardGold,
+			RewardXP:   d.RewardXP,
+		})
+	}
+	return st
+}
+
+// campaignStars scores a finished run: 1 for the win, +1 for keeping the
+// base at or above the HP threshold, +1 for finishing under the time limit.
+// A negative duration means the match was untimed and earns no time star.
+func campaignStars(def *campaignMap, won bool, baseHP, baseMax, duration int) int {
+	if !won {
+		return 0
+	}
+	stars := 1
+	if baseMax > 0 && baseHP*100 >= def.BaseHPPct*baseMax {
+		stars++
+	}
+	if duration >= 0 && (def.TimeUnder <= 0 || duration < def.TimeUnder) {
+		stars++
+	}
+	return stars
+}
+
+// recordCampaignResult updates each human player's campaign record after a
+// PvE match, pays first-clear rewards and pushes the new state.
+func (r *Room) recordCampaignResult(winnerID int64) {
+	if r.hub == nil || r.g.mapDef == nil {
+		return
+	}
+	defs := loadCampaign()
+	def := findCampaignMap(defs, r.g.mapDef.ID)
+	if def == nil {
+		return
+	}
+	duration := -1 // untimed maps cannot tell how long the run took
+	if r.g.timeLimit > 0 {
+		duration = r.g.elapsedSeconds()
+	}
+
+	for _, c := range r.players {
+		if r.aiActive && c.id == r.aiID {
+			continue
+		}
+		pl := r.g.players[c.id]
+		if pl == nil {
+			continue
+		}
+		won := r.g.SameTeam(c.id, winnerID)
+		stars := campaignStars(def, won, pl.Base.HP, pl.Base.MaxHP, duration)
+
+		r.hub.mu.Lock()
+		s := r.hub.sessions[c]
+		if s == nil {
+			r.hub.mu.Unlock()
+			continue
+		}
+		if s.Profile.Campaign == nil {
+			s.Profile.Campaign = map[string]protocol.CampaignRecord{}
+		}
+		before := buildCampaignState(s.Profile)
+		rec := s.Profile.Campaign[def.MapID]
+		res := protocol.CampaignResult{MapID: def.MapID, Won: won, Stars: stars}
+		if won {
+			res.FirstClear = rec.Clears == 0
+			rec.Clears++
+			if rec.BestTime == 0 || duration < rec.BestTime {
+				rec.BestTime = duration
+			}
+			if rec.ClearedAt == 0 {
+				rec.ClearedAt = time.Now().UnixMilli()
+			}
+		}
+		if stars > rec.Stars {
+			rec.Stars = stars
+		}
+		res.BestStars = rec.Stars
+		s.Profile.Campaign[def.MapID] = rec
+		if res.FirstClear {
+			s.Profile.Gold += def.RewardGold
+			s.Profile.AccountXP += def.RewardXP
+			res.RewardGold = def.RewardGold
+			res.RewardXP = def.RewardXP
+		}
+		after := buildCampaignState(s.Profile)
+		for i, n := range after.Nodes {
+			if n.Unlocked && !before.Nodes[i].Unlocked {
+				res.Unlocked = append(res.Unlocked, n.MapID)
+			}
+		}
+		if err := r.hub.profiles.Save(s.Profile); err != nil {
+			log.Printf("profiles.Save(campaign): %v", err)
+		}
+		prof := s.Profile
+		r.hub.mu.Unlock()
+
+		sendJSON(c, "CampaignResult", res)
+		sendJSON(c, "CampaignState", after)
+		sendJSON(c, "Profile", prof)
+	}
+}
+
+// nextCampaignMap picks the first unlocked map without stars, or the last
+// unlocked one when everything has been cleared.
+func nextCampaignMap(prof protocol.Profile) *campaignMap {
+	defs := loadCampaign()
+	var last *campaignMap
+	for i := range defs {
+		d := &defs[i]
+		if !campaignUnlocked(d, prof) {
+			continue
+		}
+		if prof.Campaign[d.MapID].Stars == 0 {
+			return d
+		}
+		last = d
+	}
+	return last
+}
@@ -0,0 +1,62 @@
+package srv
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// Settings are the srv tunables the server config controls. Zero fields keep
+// the defaults.
+type Settings struct {
+	DataDir  string // content and state files (maps, worlds, bosses, minis...)
+	TickRate int    // room simulation steps per second
+	// Ranked queue discipline (see abandon.go)
+	AFKSeconds      float64
+	LeaverWindow    time.Duration
+	LeaverCooldowns []time.Duration
+	// How long a match resumed after a restart waits for its players
+	ResumeWait time.Duration
+}
+
+var (
+	dataDir  = "data"
+	tickRate = 20
+)
+
+// Configure applies s. Call it once at startup, before NewHub.
+func Configure(s Settings) {
+	if s.DataDir != "" {
+		setDataDir(s.DataDir)
+	}
+	if s.TickRate > 0 {
+		tickRate = s.TickRate
+	}
+	if s.AFKSeconds > 0 {
+		afkCappedSeconds = s.AFKSeconds
+	}
+	if s.LeaverWindow > 0 {
+		leaverWindow = s.LeaverWindow
+	}
+	if len(s.LeaverCooldowns) > 0 {
+		leaverCooldowns = append([]time.Duration(nil), s.LeaverCooldowns...)
+	}
+	if s.ResumeWait > 0 {
+		resumeWait = s.ResumeWait
+	}
+}
+
+// setDataDir points every content path at dir.
+func setDataDir(dir string) {
+	dataDir = dir
+	mapsDir = filepath.Join(dir, "maps")
+	arenasDir = filepath.Join(dir, "arenas")
+	duelsDir = filepath.Join(dir, "duels")
+	worldsDir = filepath.Join(dir, "worlds")
+	bossesPath = filepath.Join(dir, "bosses.json")
+	campaignPath = filepath.Join(dir, "campaign.json")
+	mutatorsPath = filepath.Join(dir, "mutators.json")
+	matchesDir = filepath.Join(dir, "matches")
+}
+
+// tickInterval is the wall time between two room ticks.
+func tickInterval() time.Duration { return time.Second / time.Duration(tickRate) }
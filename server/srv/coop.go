@@ -0,0 +1,208 @@
+package srv
+
+import (
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+// coopInviteTTL is how long an unanswered co-op invite stays valid.
+const coopInviteTTL = 2 * time.Minute
+
+// coopInvite is a pending co-op invitation, keyed by the inviting client.
+type coopInvite struct {
+	to    string // invitee name (lower case)
+	mapID string
+	at    time.Time
+}
+
+// clientByNameLocked returns the online client logged in as name. h.mu must be held.
+func (h *Hub) clientByNameLocked(name string) *client {
+	for cl := range h.clients {
+		if s := h.sessions[cl]; s != nil && strings.EqualFold(s.Profile.Name, name) {
+			return cl
+		}
+	}
+	return nil
+}
+
+func (h *Hub) isFriend(user, other string) bool {
+	if h.social == nil {
+		return false
+	}
+	for _, f := range h.social.ListFriends(user) {
+		if strings.EqualFold(f, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// leaveIdleRoomLocked drops c from a room that has not started yet (e.g. the
+// lobby room made by clicking a map). It fails if c is mid-battle. h.mu must be held.
+func (h *Hub) leaveIdleRoomLocked(c *client) bool {
+	if c.room == nil {
+		return true
+	}
+	if c.room.active {
+		return false
+	}
+	c.room.Leave(c)
+	c.room = nil
+	if s := h.sessions[c]; s != nil {
+		s.RoomID = ""
+	}
+	return true
+}
+
+// busySeatLocked returns the first of seats that is mid-battle and so cannot
+// be moved into a new room (see leaveIdleRoomLocked). h.mu must be held.
+func (h *Hub) busySeatLocked(seats []*client) *client {
+	for _, c := range seats {
+		if c.room != nil && c.room.active {
+			return c
+		}
+	}
+	return nil
+}
+
+// CoopInvite sends a friend an invitation to play a campaign map together.
+func (h *Hub) CoopInvite(c *client, m protocol.CoopInvite) {
+	h.mu.Lock()
+	s := h.sessions[c]
+	if s == nil || s.Profile.Name == "" {
+		h.mu.Unlock()
+		return
+	}
+	from := s.Profile.Name
+	prof := s.Profile
+	target := h.clientByNameLocked(m.To)
+	h.mu.Unlock()
+
+	if !h.isFriend(from, m.To) {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "You can only invite friends to co-op"})
+		return
+	}
+	if target == nil {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: m.To + " is offline"})
+		return
+	}
+
+	mapID, mapName, msg := coopMapFor(prof, m.MapID)
+	if msg != "" {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: msg})
+		return
+	}
+
+	h.mu.Lock()
+	h.coopInvites[c] = &coopInvite{to: strings.ToLower(m.To), mapID: mapID, at: time.Now()}
+	h.mu.Unlock()
+
+	sendJSON(target, "CoopInvited", protocol.CoopInvited{From: from, MapID: mapID, MapName: mapName})
+	sendJSON(c, "CoopInviteSent", protocol.CoopInviteSent{To: m.To, MapID: mapID})
+}
+
+// CoopDecline drops the invite and lets the inviter know.
+func (h *Hub) CoopDecline(c *client, from string) {
+	h.mu.Lock()
+	host, inv := h.findCoopInviteLocked(c, from)
+	if inv == nil {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.coopInvites, host)
+	by := ""
+	if s := h.sessions[c]; s != nil {
+		by = s.Profile.Name
+	}
+	h.mu.Unlock()
+
+	sendJSON(host, "CoopDeclined", protocol.CoopDeclined{By: by})
+}
+
+// CoopAccept starts the co-op room for the invite from `from`.
+func (h *Hub) CoopAccept(c *client, from string) {
+	h.mu.Lock()
+	host, inv := h.findCoopInviteLocked(c, from)
+	if inv == nil {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Invite expired"})
+		return
+	}
+	delete(h.coopInvites, host)
+	if !h.leaveIdleRoomLocked(host) {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Host is already in a battle"})
+		return
+	}
+	if !h.leaveIdleRoomLocked(c) {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "You are already in a battle"})
+		return
+	}
+
+	r := h.startCoopRoomLocked(host, c, inv.mapID)
+	h.mu.Unlock()
+
+	launchRoom(r)
+}
+
+// coopMapFor resolves the co-op map the host asked for ("" = their next
+// campaign map). The host's campaign progress decides what can be played;
+// msg is set when the map cannot be used.
+func coopMapFor(prof protocol.Profile, want string) (mapID, mapName, msg string) {
+	var def *campaignMap
+	if want != "" {
+		def = findCampaignMap(loadCampaign(), want)
+		if def != nil && !campaignUnlocked(def, prof) {
+			return "", "", "Map locked: clear the previous maps first"
+		}
+	} else {
+		def = nextCampaignMap(prof)
+	}
+	mapID, mapName = want, want
+	if def != nil {
+		mapID, mapName = def.MapID, def.Name
+	}
+	if mapID == "" {
+		return "", "", "No map available for co-op"
+	}
+	return mapID, mapName, ""
+}
+
+// startCoopRoomLocked seats host and guest in a new co-op room on mapID.
+// h.mu must be held; the caller launches the room after unlocking.
+func (h *Hub) startCoopRoomLocked(host, guest *client, mapID string) *Room {
+	roomID := makeRoomID("coop")
+	r := NewRoom(roomID, h)
+	r.Mode = "coop"
+	h.rooms[roomID] = r
+	if mapDef, err := loadMapDef(mapID); err == nil {
+		r.g.SetMapDef(&mapDef)
+	}
+	for _, c := range []*client{host, guest} {
+		if s := h.sessions[c]; s != nil {
+			r.JoinClient(c, s)
+			s.RoomID = roomID
+		}
+	}
+	return r
+}
+
+// findCoopInviteLocked finds a live invite from `from` addressed to c. h.mu must be held.
+func (h *Hub) findCoopInviteLocked(c *client, from string) (*client, *coopInvite) {
+	s := h.sessions[c]
+	if s == nil {
+		return nil, nil
+	}
+	host := h.clientByNameLocked(from)
+	if host == nil {
+		return nil, nil
+	}
+	inv := h.coopInvites[host]
+	if inv == nil || inv.to != strings.ToLower(s.Profile.Name) || time.Since(inv.at) > coopInviteTTL {
+		return host, nil
+	}
+	return host, inv
+}
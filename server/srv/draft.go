@@ -0,0 +1,396 @@
+package srv
+
+import (
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+const (
+	draftChampions = 4 // champions in the pool
+	draftMinis     = 16
+	draftBans      = 1 // per player, skippable
+	draftBanTime   = 15 * time.Second
+	draftPickTime  = 20 * time.Second
+)
+
+// draftStep is one turn of the draft.
+type draftStep struct {
+	seat int // 0 or 1
+	ban  bool
+}
+
+// draftOrder is the bans (alternating) followed by a snake pick order
+// A B B A A B B A ... until both armies have 7 cards.
+func draftOrder() []draftStep {
+	var out []draftStep
+	for i := 0; i < 2*draftBans; i++ {
+		out = append(out, draftStep{seat: i % 2, ban: true})
+	}
+	snake := []int{0, 1, 1, 0}
+	for i := 0; i < 14; i++ {
+		out = append(out, draftStep{seat: snake[i%4]})
+	}
+	return out
+}
+
+// draftSession is a running draft between two matched players. All fields
+// are guarded by hub.mu.
+type draftSession struct {
+	id      string
+	mode    string // room mode once the draft completes: "draft" | "friendly"
+	players [2]*client
+	pool    []MiniCard
+	banned  []string
+	picks   [2][]string
+	order   []draftStep
+	step    int
+	timer   *time.Timer
+	rules   *protocol.Mutators // optional friendly ruleset
+}
+
+// newDraftPool draws a random pool of champions and non-spell minis.
+func newDraftPool() []MiniCard {
+	var champs, minis []MiniCard
+	for _, m := range NewGame().minis {
+		role, class := strings.ToLower(m.Role), strings.ToLower(m.Class)
+		if role == "champion" || class == "champion" {
+			champs = append(champs, m)
+		} else if role == "mini" && class != "spell" {
+			minis = append(minis, m)
+		}
+	}
+	rand.Shuffle(len(champs), func(i, j int) { champs[i], champs[j] = champs[j], champs[i] })
+	rand.Shuffle(len(minis), func(i, j int) { minis[i], minis[j] = minis[j], minis[i] })
+	if len(champs) > draftChampions {
+		champs = champs[:draftChampions]
+	}
+	if len(minis) > draftMinis {
+		minis = minis[:draftMinis]
+	}
+	return append(champs, minis...)
+}
+
+func isChampionCard(m MiniCard) bool {
+	return strings.EqualFold(m.Role, "champion") || strings.EqualFold(m.Class, "champion")
+}
+
+func (d *draftSession) card(name string) (MiniCard, bool) {
+	for _, m := range d.pool {
+		if strings.EqualFold(m.Name, name) {
+			return m, true
+		}
+	}
+	return MiniCard{}, false
+}
+
+func (d *draftSession) taken(name string) bool {
+	for _, b := range d.banned {
+		if strings.EqualFold(b, name) {
+			return true
+		}
+	}
+	for _, side := range d.picks {
+		for _, p := range side {
+			if strings.EqualFold(p, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// canPick reports whether seat may take card: one champion and six minis per army.
+func (d *draftSession) canPick(seat int, m MiniCard) bool {
+	if d.taken(m.Name) {
+		return false
+	}
+	champ := 0
+	for _, p := range d.picks[seat] {
+		if c, ok := d.card(p); ok && isChampionCard(c) {
+			champ++
+		}
+	}
+	if isChampionCard(m) {
+		return champ == 0
+	}
+	return len(d.picks[seat])-champ < 6
+}
+
+func (d *draftSession) seatOf(c *client) int {
+	for i, p := range d.players {
+		if p == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// army orders the picks champion first, as saved armies are.
+func (d *draftSession) army(seat int) []string {
+	out := make([]string, 0, 7)
+	for _, p := range d.picks[seat] {
+		if c, ok := d.card(p); ok && isChampionCard(c) {
+			out = append([]string{p}, out...)
+		} else {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (h *Hub) draftStateLocked(d *draftSession, seat int) protocol.DraftState {
+	st := protocol.DraftState{
+		DraftID:   d.id,
+		Pool:      make([]protocol.DraftCard, 0, len(d.pool)),
+		Banned:    append([]string{}, d.banned...),
+		YourPicks: append([]string{}, d.picks[seat]...),
+		OppPicks:  append([]string{}, d.picks[1-seat]...),
+		Action:    "done",
+		Step:      d.step,
+		Steps:     len(d.order),
+	}
+	for _, m := range d.pool {
+		st.Pool = append(st.Pool, protocol.DraftCard{Name: m.Name, Role: m.Role, Class: m.Class, Cost: m.Cost, Portrait: m.Portrait})
+	}
+	if s := h.sessions[d.players[1-seat]]; s != nil {
+		st.OppName = s.Profile.Name
+	}
+	if d.step < len(d.order) {
+		cur := d.order[d.step]
+		st.YourTurn = cur.seat == seat
+		st.Action = "pick"
+		st.SecondsLeft = int(draftPickTime / time.Second)
+		if cur.ban {
+			st.Action = "ban"
+			st.SecondsLeft = int(draftBanTime / time.Second)
+		}
+	}
+	return st
+}
+
+func (h *Hub) broadcastDraftLocked(d *draftSession) {
+	for seat, c := range d.players {
+		sendJSON(c, "DraftState", h.draftStateLocked(d, seat))
+	}
+}
+
+// startDraftLocked opens a draft between a and b. h.mu must be held.
+func (h *Hub) startDraftLocked(a, b *client, mode string) *draftSession {
+	d := &draftSession{
+		id:      makeRoomID("draft"),
+		mode:    mode,
+		players: [2]*client{a, b},
+		pool:    newDraftPool(),
+		order:   draftOrder(),
+	}
+	h.drafts[a] = d
+	h.drafts[b] = d
+	h.broadcastDraftLocked(d)
+	h.armDraftTimerLocked(d)
+	return d
+}
+
+// armDraftTimerLocked schedules the auto-action for the current step.
+func (h *Hub) armDraftTimerLocked(d *draftSession) {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	step := d.step
+	wait := draftPickTime
+	if d.order[step].ban {
+		wait = draftBanTime
+	}
+	d.timer = time.AfterFunc(wait, func() { h.draftTimeout(d, step) })
+}
+
+// draftTimeout skips a ban or picks a random legal card when a player runs
+// out of time.
+func (h *Hub) draftTimeout(d *draftSession, step int) {
+	h.mu.Lock()
+	if h.drafts[d.players[0]] != d || d.step != step {
+		h.mu.Unlock()
+		return // already moved on or cancelled
+	}
+	cur := d.order[step]
+	if cur.ban {
+		h.draftAdvanceLocked(d)
+		h.mu.Unlock()
+		return
+	}
+	var legal []string
+	for _, m := range d.pool {
+		if d.canPick(cur.seat, m) {
+			legal = append(legal, m.Name)
+		}
+	}
+	if len(legal) == 0 {
+		h.cancelDraftLocked(d, "No cards left to pick")
+		h.mu.Unlock()
+		return
+	}
+	d.picks[cur.seat] = append(d.picks[cur.seat], legal[rand.Intn(len(legal))])
+	r := h.draftAdvanceLocked(d)
+	h.mu.Unlock()
+	if r != nil {
+		launchRoom(r)
+	}
+}
+
+// DraftAct applies a ban or pick from c if it is c's turn.
+func (h *Hub) DraftAct(c *client, name string, ban bool) {
+	h.mu.Lock()
+	d := h.drafts[c]
+	if d == nil || d.step >= len(d.order) {
+		h.mu.Unlock()
+		return
+	}
+	cur := d.order[d.step]
+	if d.seatOf(c) != cur.seat || cur.ban != ban {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Not your turn"})
+		return
+	}
+	if ban {
+		if name != "" {
+			m, ok := d.card(name)
+			if !ok || d.taken(m.Name) {
+				h.mu.Unlock()
+				sendJSON(c, "Error", protocol.ErrorMsg{Message: "Card not available"})
+				return
+			}
+			d.banned = append(d.banned, m.Name)
+		}
+	} else {
+		m, ok := d.card(name)
+		if !ok || !d.canPick(cur.seat, m) {
+			h.mu.Unlock()
+			sendJSON(c, "Error", protocol.ErrorMsg{Message: "You can't pick that card"})
+			return
+		}
+		d.picks[cur.seat] = append(d.picks[cur.seat], m.Name)
+	}
+	r := h.draftAdvanceLocked(d)
+	h.mu.Unlock()
+	if r != nil {
+		launchRoom(r)
+	}
+}
+
+// draftAdvanceLocked moves to the next step; once the order is exhausted it
+// builds the match room and returns it for launching outside the lock.
+func (h *Hub) draftAdvanceLocked(d *draftSession) *Room {
+	d.step++
+	h.broadcastDraftLocked(d)
+	if d.step < len(d.order) {
+		h.armDraftTimerLocked(d)
+		return nil
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	if busy := h.busySeatLocked(d.players[:]); busy != nil {
+		name := busy.name
+		if s := h.sessions[busy]; s != nil && s.Profile.Name != "" {
+			name = s.Profile.Name
+		}
+		h.cancelDraftLocked(d, name+" is already in a battle")
+		return nil
+	}
+	delete(h.drafts, d.players[0])
+	delete(h.drafts, d.players[1])
+
+	prefix := "pvp-draft"
+	if d.mode == "friendly" {
+		prefix = "frd"
+	}
+	roomID := makeRoomID(prefix)
+	r := NewRoom(roomID, h)
+	r.Mode = d.mode
+	h.rooms[roomID] = r
+	if d.rules != nil {
+		r.g.SetRules(*d.rules)
+	}
+	if d.mode == "draft" {
+		if arena := h.selectRandomArena(); arena != "" {
+			if mapDef, err := loadMapDef(arena); err == nil {
+				r.g.SetMapDef(&mapDef)
+			} else {
+				log.Printf("Failed to load arena %s for draft: %v", arena, err)
+			}
+		}
+	}
+	for seat, c := range d.players {
+		h.leaveIdleRoomLocked(c) // cannot fail: checked above
+		s := h.sessions[c]
+		if s == nil {
+			continue
+		}
+		r.JoinClientWithArmy(c, s, d.army(seat))
+		s.RoomID = roomID
+	}
+	return r
+}
+
+// cancelDraftLocked aborts a draft and tells both players why. h.mu must be held.
+func (h *Hub) cancelDraftLocked(d *draftSession, reason string) {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	for _, c := range d.players {
+		delete(h.drafts, c)
+		sendJSON(c, "DraftCancelled", protocol.DraftCancelled{Reason: reason})
+	}
+}
+
+// ---- Draft queue
+
+// EnqueueDraft adds c to the draft queue and starts a draft for every pair.
+func (h *Hub) EnqueueDraft(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.drafts[c] != nil {
+		return
+	}
+	for _, x := range h.draftQueue {
+		if x == c {
+			return
+		}
+	}
+	h.draftQueue = append(h.draftQueue, c)
+	sendJSON(c, "DraftQueueStatus", protocol.DraftQueueStatus{Queued: true})
+	for len(h.draftQueue) >= 2 {
+		a, b := h.draftQueue[0], h.draftQueue[1]
+		h.draftQueue = h.draftQueue[2:]
+		h.startDraftLocked(a, b, "draft")
+	}
+}
+
+// DequeueDraft removes c from the draft queue.
+func (h *Hub) DequeueDraft(c *client) {
+	h.mu.Lock()
+	h.removeFromDraftQueueLocked(c)
+	h.mu.Unlock()
+	sendJSON(c, "DraftQueueStatus", protocol.DraftQueueStatus{})
+}
+
+func (h *Hub) removeFromDraftQueueLocked(c *client) {
+	for i, x := range h.draftQueue {
+		if x == c {
+			h.draftQueue = append(h.draftQueue[:i], h.draftQueue[i+1:]...)
+			return
+		}
+	}
+}
+
+// dropDraftStateLocked clears c from the draft queue and cancels its draft
+// on logout or disconnect. h.mu must be held.
+func (h *Hub) dropDraftStateLocked(c *client) {
+	h.removeFromDraftQueueLocked(c)
+	if d := h.drafts[c]; d != nil {
+		h.cancelDraftLocked(d, "Your opponent left the draft")
+	}
+}
@@ -0,0 +1,122 @@
+package srv
+
+import (
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+// duelInviteTTL is how long an unanswered duel invite stays valid.
+const duelInviteTTL = time.Minute
+
+// duelInvite is a pending duel invitation, keyed by the inviting client.
+type duelInvite struct {
+	to string // invitee name (lower case)
+	at time.Time
+}
+
+// duelRelationLocked reports how a and b may invite each other: "friend",
+// "guild" or "" when they may not. h.mu must be held.
+func (h *Hub) duelRelationLocked(a, b *Session) string {
+	if h.isFriend(a.Profile.Name, b.Profile.Name) {
+		return "friend"
+	}
+	if gid := strings.TrimSpace(a.Profile.GuildID); gid != "" && gid == strings.TrimSpace(b.Profile.GuildID) {
+		return "guild"
+	}
+	return ""
+}
+
+// InviteToDuel pushes a duel invite to an online friend or guildmate.
+func (h *Hub) InviteToDuel(c *client, m protocol.InviteToDuel) {
+	h.mu.Lock()
+	s := h.sessions[c]
+	if s == nil || s.Profile.Name == "" {
+		h.mu.Unlock()
+		return
+	}
+	from := s.Profile.Name
+	if strings.EqualFold(from, m.Friend) {
+		h.mu.Unlock()
+		return
+	}
+	target := h.clientByNameLocked(m.Friend)
+	var ts *Session
+	if target != nil {
+		ts = h.sessions[target]
+	}
+	if target == nil || ts == nil {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: m.Friend + " is offline"})
+		return
+	}
+	via := h.duelRelationLocked(s, ts)
+	if via == "" {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "You can only invite friends and guildmates to a duel"})
+		return
+	}
+	h.duelInvites[c] = &duelInvite{to: strings.ToLower(ts.Profile.Name), at: time.Now()}
+	h.mu.Unlock()
+
+	ttl := int(duelInviteTTL / time.Second)
+	sendJSON(target, "DuelInvited", protocol.DuelInvited{From: from, Via: via, ExpiresIn: ttl})
+	sendJSON(c, "DuelInviteSent", protocol.DuelInviteSent{To: ts.Profile.Name, ExpiresIn: ttl})
+}
+
+// DuelDecline drops the invite and lets the inviter know.
+func (h *Hub) DuelDecline(c *client, from string) {
+	h.mu.Lock()
+	host, inv := h.findDuelInviteLocked(c, from)
+	if inv == nil {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.duelInvites, host)
+	by := ""
+	if s := h.sessions[c]; s != nil {
+		by = s.Profile.Name
+	}
+	h.mu.Unlock()
+
+	sendJSON(host, "DuelDeclined", protocol.DuelDeclined{By: by})
+}
+
+// DuelAccept puts both players in a friendly lobby, as FriendlyJoin does.
+func (h *Hub) DuelAccept(c *client, from string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	host, inv := h.findDuelInviteLocked(c, from)
+	if inv == nil {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Invite expired"})
+		return
+	}
+	delete(h.duelInvites, host)
+	if !h.leaveIdleRoomLocked(host) {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Host is already in a battle"})
+		return
+	}
+	if !h.leaveIdleRoomLocked(c) {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "You are already in a battle"})
+		return
+	}
+	h.openFriendlyLobbyLocked(host, c, "")
+}
+
+// findDuelInviteLocked finds a live invite from `from` addressed to c. h.mu must be held.
+func (h *Hub) findDuelInviteLocked(c *client, from string) (*client, *duelInvite) {
+	s := h.sessions[c]
+	if s == nil {
+		return nil, nil
+	}
+	host := h.clientByNameLocked(from)
+	if host == nil {
+		return nil, nil
+	}
+	inv := h.duelInvites[host]
+	if inv == nil || inv.to != strings.ToLower(s.Profile.Name) || time.Since(inv.at) > duelInviteTTL {
+		return host, nil
+	}
+	return host, inv
+}
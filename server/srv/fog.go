@@ -0,0 +1,170 @@
+package srv
+
+import (
+	"rumble/shared/protocol"
+)
+
+// Fog of war (Mutators.FogOfWar): each side only receives the enemy units,
+// projectiles and events its own units and bases can see. Filtering happens
+// per recipient so hidden positions never leave the server.
+
+func (g *Game) fogEnabled() bool {
+	return g.hasRules && g.rules.FogOfWar
+}
+
+// visibleTo reports whether team has sight of the point (x, y).
+func (g *Game) visibleTo(team int, x, y float64) bool {
+	for _, u := range g.units {
+		if u.TeamID == team && hypot(u.X, u.Y, x, y) <= protocol.FogUnitSight {
+			return true
+		}
+	}
+	for _, p := range g.players {
+		if p.TeamID != team {
+			continue
+		}
+		cx := float64(p.Base.X + p.Base.W/2)
+		cy := float64(p.Base.Y + p.Base.H/2)
+		if hypot(cx, cy, x, y) <= protocol.FogBaseSight {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleUnits returns the IDs of every unit team can currently see: its own
+// plus enemies within sight.
+func (g *Game) visibleUnits(team int) map[int64]bool {
+	vis := make(map[int64]bool, len(g.units))
+	for id, u := range g.units {
+		if u.TeamID == team || g.visibleTo(team, u.X, u.Y) {
+			vis[id] = true
+		}
+	}
+	return vis
+}
+
+// eventVisible reports whether team may receive a battle event. Events that
+// carry a position are dropped when that position is in the fog.
+func (g *Game) eventVisible(team int, event interface{}) bool {
+	switch ev := event.(type) {
+	case protocol.UnitSpawnEvent:
+		return g.teamOf(ev.OwnerID) == team || g.visibleTo(team, ev.UnitX, ev.UnitY)
+	case protocol.UnitDeathEvent:
+		return g.visibleTo(team, ev.UnitX, ev.UnitY)
+	case protocol.HealingEvent:
+		return g.visibleTo(team, ev.TargetX, ev.TargetY)
+	case protocol.AoEDamageEvent:
+		return g.visibleTo(team, ev.ImpactX, ev.ImpactY)
+	}
+	return true
+}
+
+// fogSnapshot is FullSnapshot limited to what team can see.
+func (g *Game) fogSnapshot(team int) protocol.FullSnapshot {
+	snap := g.FullSnapshot()
+	vis := g.visibleUnits(team)
+	units := snap.Units[:0]
+	for _, u := range snap.Units {
+		if vis[u.ID] {
+			units = append(units, u)
+		}
+	}
+	snap.Units = units
+	return snap
+}
+
+// fogDelta filters the shared tick delta for one recipient. seen holds the
+// unit IDs the recipient knew about after the previous tick and is updated
+// in place; vis is visibleUnits for the recipient's team.
+func (g *Game) fogDelta(d protocol.StateDelta, team int, vis, seen map[int64]bool) protocol.StateDelta {
+	out := d
+	out.UnitsUpsert = make([]protocol.UnitState, 0, len(d.UnitsUpsert))
+	for _, u := range d.UnitsUpsert {
+		if vis[u.ID] {
+			out.UnitsUpsert = append(out.UnitsUpsert, u)
+			if !seen[u.ID] {
+				out.EnteredVision = append(out.EnteredVision, u.ID)
+				seen[u.ID] = true
+			}
+		}
+	}
+	// Deaths are only reported for units the recipient was tracking.
+	out.UnitsRemoved = make([]int64, 0, len(d.UnitsRemoved))
+	for _, id := range d.UnitsRemoved {
+		if seen[id] {
+			out.UnitsRemoved = append(out.UnitsRemoved, id)
+			delete(seen, id)
+		}
+	}
+	for id := range seen {
+		if !vis[id] {
+			out.LeftVision = append(out.LeftVision, id)
+			delete(seen, id)
+		}
+	}
+	out.Projectiles = make([]protocol.ProjectileState, 0, len(d.Projectiles))
+	for _, ps := range d.Projectiles {
+		if p := g.projectiles[ps.ID]; p != nil && (p.TeamID == team || g.visibleTo(team, p.X, p.Y)) {
+			out.Projectiles = append(out.Projectiles, ps)
+		}
+	}
+	return out
+}
+
+// ---- Room side: per-recipient views
+
+// viewTeam is the team whose sight c gets: its own, or for a spectator the
+// team of the player being watched.
+func (r *Room) viewTeam(c *client) int {
+	r.watchMu.Lock()
+	team, ok := r.watchTeams[c]
+	r.watchMu.Unlock()
+	if ok {
+		return team
+	}
+	return r.g.teamOf(c.id)
+}
+
+// snapshotFor is the FullSnapshot c may see. Under fog it also resets what c
+// is tracking to the snapshot contents.
+func (r *Room) snapshotFor(c *client) protocol.FullSnapshot {
+	if !r.g.fogEnabled() {
+		return r.g.FullSnapshot()
+	}
+	snap := r.g.fogSnapshot(r.viewTeam(c))
+	seen := make(map[int64]bool, len(snap.Units))
+	for _, u := range snap.Units {
+		seen[u.ID] = true
+	}
+	r.fogMu.Lock()
+	if r.fogSeen == nil {
+		r.fogSeen = map[*client]map[int64]bool{}
+	}
+	r.fogSeen[c] = seen
+	r.fogMu.Unlock()
+	return snap
+}
+
+// deltaFor is the tick delta c may see. vis caches visibleUnits per team for
+// this tick.
+func (r *Room) deltaFor(c *client, d protocol.StateDelta, vis map[int]map[int64]bool) protocol.StateDelta {
+	if !r.g.fogEnabled() {
+		return d
+	}
+	team := r.viewTeam(c)
+	if vis[team] == nil {
+		vis[team] = r.g.visibleUnits(team)
+	}
+	r.fogMu.Lock()
+	defer r.fogMu.Unlock()
+	if r.fogSeen == nil {
+		r.fogSeen = map[*client]map[int64]bool{}
+	}
+	seen := r.fogSeen[c]
+	if seen == nil {
+		seen = map[int64]bool{}
+		r.fogSeen[c] = seen
+	}
+	return r.g.fogDelta(d, team, vis[team], seen)
+}
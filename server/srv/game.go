@@ -43,6 +43,9 @@ type Player struct {
 	Base   Base
 	Rating int    // NEW: PvP Elo
 	Rank   string // NEW: derived name
+	TeamID int    // 0 = player side (bottom), 1 = enemy side (top)
+	// seconds spent at the gold cap without deploying (AFK detection)
+	CappedFor float64
 }
 
 type Base struct {
@@ -62,13 +65,15 @@ type Unit struct {
 	Hps            int
 	Speed          float64 // px/s
 	OwnerID        int64
+	TeamID         int // copied from the owner at spawn
 	Class          string
 	SubClass       string
 	Range          int
 	Particle       string
 	Facing, CD     float64
 	HealCD         float64
-	AttackCooldown float64 // Configurable attack cooldown duration
+	AttackCooldown float64    // Configurable attack cooldown duration
+	Boss           *bossState // nil for regular minis
 }
 
 type Projectile struct {
@@ -79,11 +84,14 @@ type Projectile struct {
 	Speed          float64 // Movement speed
 	Damage         int     // Damage to deal on impact
 	OwnerID        int64   // Who fired this projectile
+	TeamID         int     // Owner's team
 	TargetID       int64   // Target unit ID (0 if targeting base)
 	TargetX        float64 // Target X coordinate (for base targeting)
 	TargetY        float64 // Target Y coordinate (for base targeting)
 	Active         bool    // Whether projectile is still active
 	ProjectileType string  // Type for visual effects
+	SourceClass    string  // Class of the firing unit (for boss immunities)
+	SourceSubClass string  // Subclass of the firing unit
 }
 
 type Game struct {
@@ -95,6 +103,10 @@ type Game struct {
 	height      int
 	mapDef      *protocol.MapDef // Current map definition
 
+	// Rule mutators (see mutators.go); zero values keep the regular rules
+	rules    protocol.Mutators
+	hasRules bool
+
 	// Timer system
 	timerActive   bool
 	timeRemaining float64 // in seconds
@@ -102,6 +114,7 @@ type Game struct {
 	isPaused      bool
 	matchEnded    bool
 	timerWinnerID int64 // winner when timer expires
+	endless       bool  // survival: the timer counts up and never expires
 
 	// Event broadcasting callback
 	broadcastEvent func(eventType string, event interface{})
@@ -112,21 +125,27 @@ func NewGame() *Game {
 		units:       make(map[int64]*Unit),
 		projectiles: make(map[int64]*Projectile),
 		players:     make(map[int64]*Player),
-		width:       protocol.ScreenW,
-		height:      protocol.ScreenH,
+		width:       protocol.DefaultWorldW,
+		height:      protocol.DefaultWorldH,
 		// init maps, players, etc.
 	}
 	g.loadMinis()
 	return g
 }
 
+// SetMapDef selects the map for this match; the world takes its size.
+func (g *Game) SetMapDef(def *protocol.MapDef) {
+	g.mapDef = def
+	g.width, g.height = def.WorldSize()
+}
+
 func (g *Game) loadMinis() {
-	// Try sensible paths relative to the running binary and CWD.
+	// The configured data dir first, then paths relative to the binary.
 	exe, _ := os.Executable()
 	exeDir := filepath.Dir(exe)
 	candidates := []string{
+		filepath.Join(dataDir, "minis.json"),
 		filepath.Join(exeDir, "data", "minis.json"), // server/data/minis.json next to server binary
-		filepath.Join("data", "minis.json"),         // when running `go run .` inside server/
 		filepath.Join(exeDir, "..", "internal", "game", "assets", "minis.json"),
 		filepath.Join("..", "internal", "game", "assets", "minis.json"),
 	}
@@ -143,6 +162,19 @@ func (g *Game) loadMinis() {
 	log.Printf("WARNING: no minis.json found — using empty set (fallback cards will be used)")
 }
 
+func toUnitState(u *Unit) protocol.UnitState {
+	st := protocol.UnitState{
+		ID: u.ID, Name: u.Name, X: u.X, Y: u.Y, HP: u.HP, MaxHP: u.MaxHP,
+		OwnerID: u.OwnerID, Facing: u.Facing, Class: u.Class, Range: u.Range, Particle: u.Particle,
+	}
+	if u.Boss != nil {
+		st.Boss = true
+		st.Phase, st.PhaseName = u.phaseInfo()
+		st.Portrait = u.Boss.def.Portrait
+	}
+	return st
+}
+
 func toBaseState(b *Base) protocol.BaseState {
 	return protocol.BaseState{
 		OwnerID: b.OwnerID,
@@ -152,19 +184,37 @@ func toBaseState(b *Base) protocol.BaseState {
 	}
 }
 
-// AddPlayerWithArmy allows passing a preselected 7-card army (names).
+// Fallback base placement for maps without base positions, as fractions of
+// the world height (180 and 28 units on the default 1000-unit world).
+const (
+	baseBottomMargin = 0.18
+	baseTopMargin    = 0.028
+)
+
+// AddPlayerWithArmy adds a 1v1 participant: the first player takes the
+// player side, everyone after that the enemy side.
 func (g *Game) AddPlayerWithArmy(id int64, name string, armyNames []string) *Player {
-	p := &Player{ID: id, Name: name, Gold: 4}
+	team := 0
+	if len(g.players) > 0 {
+		team = 1
+	}
+	return g.AddPlayerOnTeam(id, name, armyNames, team)
+}
+
+// AddPlayerOnTeam adds a player to the given side. Teammates get one base
+// each, on the map's team slots or side by side around the side's base position.
+func (g *Game) AddPlayerOnTeam(id int64, name string, armyNames []string, team int) *Player {
+	p := &Player{ID: id, Name: name, Gold: g.startGold(), TeamID: team}
 
 	baseW, baseH := 96, 96
-	bottomMargin := 180 // leave space for 160px HUD + padding
-	topMargin := 28
+	bottomMargin := int(baseBottomMargin * float64(g.height))
+	topMargin := int(baseTopMargin * float64(g.height))
 
 	// Use map-defined base positions if available
 	if g.mapDef != nil {
 		var baseX, baseY int
-		if len(g.players) == 0 {
-			// First player (human) - use playerBase
+		if team == 0 {
+			// Player side - use playerBase
 			if g.mapDef.PlayerBase.X >= 0 && g.mapDef.PlayerBase.Y >= 0 {
 				baseX = int(g.mapDef.PlayerBase.X * float64(g.width))
 				baseY = int(g.mapDef.PlayerBase.Y * float64(g.height))
@@ -174,7 +224,7 @@ func (g *Game) AddPlayerWithArmy(id int64, name string, armyNames []string) *Pla
 				baseY = g.height - baseH - bottomMargin
 			}
 		} else {
-			// Second player (AI or enemy) - use enemyBase
+			// Enemy side (AI or opponent) - use enemyBase
 			if g.mapDef.EnemyBase.X >= 0 && g.mapDef.EnemyBase.Y >= 0 {
 				baseX = int(g.mapDef.EnemyBase.X * float64(g.width))
 				baseY = int(g.mapDef.EnemyBase.Y * float64(g.height))
@@ -187,7 +237,7 @@ func (g *Game) AddPlayerWithArmy(id int64, name string, armyNames []string) *Pla
 
 		p.Base = Base{
 			OwnerID: id,
-			HP:      3000, MaxHP: 3000,
+			HP:      g.baseHP(), MaxHP: g.baseHP(),
 			W: baseW, H: baseH,
 			X: baseX,
 			Y: baseY,
@@ -196,24 +246,105 @@ func (g *Game) AddPlayerWithArmy(id int64, name string, armyNames []string) *Pla
 		// Fallback to hardcoded positions when no map definition
 		p.Base = Base{
 			OwnerID: id,
-			HP:      3000, MaxHP: 3000,
+			HP:      g.baseHP(), MaxHP: g.baseHP(),
 			W: baseW, H: baseH,
 			X: g.width/2 - baseW/2,
 			Y: g.height - baseH - bottomMargin, // Player base at bottom
 		}
-		if len(g.players) == 1 {
+		if team == 1 {
 			p.Base.Y = topMargin // Enemy base at top
 		}
 	}
 
 	g.players[id] = p
+	g.layoutTeamBases(team, p.Base.X+p.Base.W/2)
 
+	if len(g.rules.FixedArmy) == 7 {
+		armyNames = g.rules.FixedArmy
+	}
 	if ok := g.tryBuildArmyByNames(p, armyNames); !ok {
 		g.dealArmy(p)
 	}
+	g.applyClassRule(p)
 	return p
 }
 
+// layoutTeamBases places the bases of a side on the map's TeamBases slots,
+// or spreads them evenly around centerX when the map has too few slots.
+func (g *Game) layoutTeamBases(team int, centerX int) {
+	mates := g.teamPlayers(team)
+	if len(mates) < 2 {
+		return
+	}
+	if g.mapDef != nil && team < len(g.mapDef.TeamBases) && len(g.mapDef.TeamBases[team]) >= len(mates) {
+		for i, p := range g.mapDef.TeamBases[team][:len(mates)] {
+			mates[i].Base.X = int(p.X * float64(g.width))
+			mates[i].Base.Y = int(p.Y * float64(g.height))
+		}
+		return
+	}
+	const gap = 16
+	w := mates[0].Base.W + gap
+	left := centerX - (len(mates)*w-gap)/2
+	for i, p := range mates {
+		p.Base.X = left + i*w
+	}
+}
+
+// teamPlayers returns the players on a side ordered by ID (stable layout).
+func (g *Game) teamPlayers(team int) []*Player {
+	var out []*Player
+	for _, p := range g.players {
+		if p.TeamID == team {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (g *Game) teamOf(id int64) int {
+	if p := g.players[id]; p != nil {
+		return p.TeamID
+	}
+	return -1
+}
+
+// SameTeam reports whether two player IDs fight on the same side.
+func (g *Game) SameTeam(a, b int64) bool {
+	if a == b {
+		return true
+	}
+	pa, pb := g.players[a], g.players[b]
+	return pa != nil && pb != nil && pa.TeamID == pb.TeamID
+}
+
+// defeatedTeam returns the first side whose bases are all destroyed.
+func (g *Game) defeatedTeam() (int, bool) {
+	alive := map[int]bool{}
+	seen := map[int]bool{}
+	for _, p := range g.players {
+		seen[p.TeamID] = true
+		if p.Base.HP > 0 {
+			alive[p.TeamID] = true
+		}
+	}
+	for _, team := range []int{0, 1} {
+		if seen[team] && !alive[team] {
+			return team, true
+		}
+	}
+	return 0, false
+}
+
+// firstOfOtherTeam picks a representative winner ID for the side opposing team.
+func (g *Game) firstOfOtherTeam(team int) int64 {
+	if mates := g.teamPlayers(1 - team); len(mates) > 0 {
+		return mates[0].ID
+	}
+	return 0
+}
+
 func (g *Game) AddPlayer(id int64, name string) *Player {
 	return g.AddPlayerWithArmy(id, name, nil)
 }
@@ -340,16 +471,40 @@ func (g *Game) InitFor(pid int64) protocol.Init {
 	if p.Next != nil {
 		nx = protocol.MiniCardView{Name: p.Next.Name, Portrait: p.Next.Portrait, Cost: p.Next.Cost, Class: p.Next.Class}
 	}
-	return protocol.Init{PlayerID: pid, MapWidth: g.width, MapHeight: g.height, Hand: hand, Next: nx}
+	var allies []int64
+	for _, mate := range g.teamPlayers(p.TeamID) {
+		if mate.ID != pid {
+			allies = append(allies, mate.ID)
+		}
+	}
+	msg := protocol.Init{PlayerID: pid, MapWidth: g.width, MapHeight: g.height, Hand: hand, Next: nx, Endless: g.endless, Allies: allies}
+	if g.hasRules {
+		rules := g.rules
+		msg.Rules = &rules
+	}
+	return msg
+}
+
+// matchTimeLimit is the clock, in seconds, a match on def starts with;
+// 0 means untimed.
+func matchTimeLimit(def *protocol.MapDef) int {
+	if def != nil && def.TimeLimit > 0 {
+		return def.TimeLimit
+	}
+	return 180 // default 3:00 minutes
 }
 
 // InitializeTimer sets up the match timer based on map configuration
 func (g *Game) InitializeTimer() {
-	if g.mapDef != nil && g.mapDef.TimeLimit > 0 {
-		g.timeLimit = g.mapDef.TimeLimit
-	} else {
-		g.timeLimit = 180 // default 3:00 minutes
+	if g.endless {
+		g.timeLimit = 0
+		g.timeRemaining = 0
+		g.timerActive = true
+		g.isPaused = false
+		g.matchEnded = false
+		return
 	}
+	g.timeLimit = matchTimeLimit(g.mapDef)
 	g.timeRemaining = float64(g.timeLimit)
 	g.timerActive = true
 	g.isPaused = false
@@ -361,6 +516,11 @@ func (g *Game) UpdateTimer(dt float64) (timerExpired bool, winnerID int64) {
 	if !g.timerActive || g.isPaused || g.matchEnded {
 		return false, 0
 	}
+	if g.endless {
+		// timeRemaining doubles as the run clock
+		g.timeRemaining += dt
+		return false, 0
+	}
 
 	g.timeRemaining -= dt
 	if g.timeRemaining <= 0 {
@@ -368,25 +528,21 @@ func (g *Game) UpdateTimer(dt float64) (timerExpired bool, winnerID int64) {
 		g.timerActive = false
 		g.matchEnded = true
 
-		// Determine winner based on base health
-		var player1, player2 *Player
+		// Determine winner based on each side's total base health
+		hp := [2]int{}
 		for _, p := range g.players {
-			if player1 == nil {
-				player1 = p
-			} else {
-				player2 = p
+			if p.TeamID == 0 || p.TeamID == 1 {
+				hp[p.TeamID] += p.Base.HP
 			}
 		}
-
-		if player1 != nil && player2 != nil {
-			if player1.Base.HP > player2.Base.HP {
-				return true, player1.ID
-			} else if player2.Base.HP > player1.Base.HP {
-				return true, player2.ID
-			} else {
-				// Draw - both lose
-				return true, -1
-			}
+		switch {
+		case hp[0] > hp[1]:
+			return true, g.firstOfOtherTeam(1)
+		case hp[1] > hp[0]:
+			return true, g.firstOfOtherTeam(0)
+		default:
+			// Draw - both lose
+			return true, -1
 		}
 	}
 	return false, 0
@@ -422,13 +578,14 @@ func (g *Game) RestartMatch() {
 
 	// Reset players' state
 	for _, p := range g.players {
-		p.Gold = 4
+		p.Gold = g.startGold()
 		p.GoldT = 0
 		p.Ready = false
 		// Re-deal army
-		if ok := g.tryBuildArmyByNames(p, nil); !ok {
+		if ok := g.tryBuildArmyByNames(p, g.rules.FixedArmy); !ok {
 			g.dealArmy(p)
 		}
+		g.applyClassRule(p)
 	}
 
 	g.matchEnded = false
@@ -439,13 +596,8 @@ func (g *Game) SurrenderMatch(playerID int64) int64 {
 	g.matchEnded = true
 	g.timerActive = false
 
-	// Find the winner (the other player)
-	for _, p := range g.players {
-		if p.ID != playerID {
-			return p.ID
-		}
-	}
-	return 0 // Should not happen
+	// Winner is someone from the other side
+	return g.firstOfOtherTeam(g.teamOf(playerID))
 }
 
 // GetTimerState returns current timer information
@@ -453,6 +605,21 @@ func (g *Game) GetTimerState() (remainingSeconds int, isPaused bool) {
 	return int(math.Ceil(g.timeRemaining)), g.isPaused
 }
 
+// elapsedSeconds returns how long the match has been running against its time limit
+func (g *Game) elapsedSeconds() int {
+	if g.endless {
+		return int(g.timeRemaining)
+	}
+	if g.timeLimit <= 0 {
+		return 0
+	}
+	d := g.timeLimit - int(g.timeRemaining)
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
 func (g *Game) Step(dt float64) protocol.StateDelta {
 	// If game is paused, don't update anything
 	if g.isPaused {
@@ -468,15 +635,18 @@ func (g *Game) Step(dt float64) protocol.StateDelta {
 	// Update projectiles
 	g.updateProjectiles(dt)
 
+	// Boss phase transitions (after this tick's damage landed)
+	g.updateBossPhases()
+
 	// gold
 	for _, p := range g.players {
 		p.GoldT += dt
-		for p.GoldT >= protocol.GoldTickSec && p.Gold < protocol.GoldMax {
+		for p.GoldT >= g.goldTickSec() && p.Gold < g.goldMax() {
 			p.Gold++
-			p.GoldT -= protocol.GoldTickSec
+			p.GoldT -= g.goldTickSec()
 		}
-		if p.Gold > protocol.GoldMax {
-			p.Gold = protocol.GoldMax
+		if p.Gold > g.goldMax() {
+			p.Gold = g.goldMax()
 		}
 	}
 
@@ -537,7 +707,7 @@ func (g *Game) Step(dt float64) protocol.StateDelta {
 		if lower(u.Class) == "range" && lower(u.SubClass) == "healer" {
 			if u.HealCD <= 0 {
 				for _, v := range g.units {
-					if v.OwnerID == u.OwnerID && v.HP < v.MaxHP && hypot(u.X, u.Y, v.X, v.Y) <= float64(u.Range) {
+					if v.TeamID == u.TeamID && v.HP < v.MaxHP && hypot(u.X, u.Y, v.X, v.Y) <= float64(u.Range) {
 						// Send healing event to all clients
 						healingEvent := protocol.HealingEvent{
 							HealerID:   u.ID,
@@ -569,10 +739,7 @@ func (g *Game) Step(dt float64) protocol.StateDelta {
 			}
 		}
 
-		upserts = append(upserts, protocol.UnitState{
-			ID: u.ID, Name: u.Name, X: u.X, Y: u.Y, HP: u.HP, MaxHP: u.MaxHP,
-			OwnerID: u.OwnerID, Facing: u.Facing, Class: u.Class, Range: u.Range, Particle: u.Particle,
-		})
+		upserts = append(upserts, toUnitState(u))
 	}
 
 	// Build projectile states for clients
@@ -604,9 +771,7 @@ func (g *Game) Step(dt float64) protocol.StateDelta {
 func (g *Game) FullSnapshot() protocol.FullSnapshot {
 	units := make([]protocol.UnitState, 0, len(g.units))
 	for _, u := range g.units {
-		units = append(units, protocol.UnitState{
-			ID: u.ID, Name: u.Name, X: u.X, Y: u.Y, HP: u.HP, MaxHP: u.MaxHP, OwnerID: u.OwnerID, Facing: u.Facing, Class: u.Class, Range: u.Range, Particle: u.Particle,
-		})
+		units = append(units, toUnitState(u))
 	}
 	bases := make([]protocol.BaseState, 0, len(g.players))
 	for _, p := range g.players {
@@ -619,7 +784,7 @@ func (g *Game) findTarget(u *Unit) (float64, float64) {
 	var best *Unit
 	bestDist := math.MaxFloat64
 	for _, v := range g.units {
-		if v.OwnerID == u.OwnerID || v.HP <= 0 {
+		if v.TeamID == u.TeamID || v.HP <= 0 {
 			continue
 		}
 		if d := hypot(u.X, u.Y, v.X, v.Y); d < bestDist {
@@ -629,11 +794,19 @@ func (g *Game) findTarget(u *Unit) (float64, float64) {
 	if best != nil {
 		return best.X, best.Y
 	}
-	// enemy base
+	// nearest standing enemy base
+	var target *Player
 	for _, p := range g.players {
-		if p.ID != u.OwnerID {
-			return float64(p.Base.X + p.Base.W/2), float64(p.Base.Y + p.Base.H/2)
+		if p.TeamID == u.TeamID || p.Base.HP <= 0 {
+			continue
 		}
+		bx, by := float64(p.Base.X+p.Base.W/2), float64(p.Base.Y+p.Base.H/2)
+		if d := hypot(u.X, u.Y, bx, by); target == nil || d < bestDist {
+			bestDist, target = d, p
+		}
+	}
+	if target != nil {
+		return float64(target.Base.X + target.Base.W/2), float64(target.Base.Y + target.Base.H/2)
 	}
 	return float64(g.width / 2), float64(g.height / 2)
 }
@@ -644,26 +817,29 @@ func (g *Game) damageAt(u *Unit, tx, ty float64, dmg int) {
 
 	// Check if targeting a unit
 	for _, v := range g.units {
-		if v.OwnerID == u.OwnerID || v.HP <= 0 {
+		if v.TeamID == u.TeamID || v.HP <= 0 {
 			continue
 		}
 		if hypot(tx, ty, v.X, v.Y) <= 30 {
 			// Create projectile targeting this unit
-			g.createProjectile(u.X, u.Y, v.X, v.Y, dmg, u.OwnerID, v.ID, 0, 0, projectileType)
+			proj := g.createProjectile(u.X, u.Y, v.X, v.Y, dmg, u.OwnerID, v.ID, 0, 0, projectileType)
+			proj.TeamID = u.TeamID
+			proj.SourceClass, proj.SourceSubClass = u.Class, u.SubClass
 			return
 		}
 	}
 
 	// Check if targeting a base
 	for _, p := range g.players {
-		if p.ID == u.OwnerID {
+		if p.TeamID == u.TeamID || p.Base.HP <= 0 {
 			continue
 		}
 		bx := float64(p.Base.X + p.Base.W/2)
 		by := float64(p.Base.Y + p.Base.H/2)
 		if hypot(tx, ty, bx, by) <= 40 {
 			// Create projectile targeting this base
-			g.createProjectile(u.X, u.Y, bx, by, dmg, u.OwnerID, 0, bx, by, projectileType)
+			proj := g.createProjectile(u.X, u.Y, bx, by, dmg, u.OwnerID, 0, bx, by, projectileType)
+			proj.TeamID = u.TeamID
 			return
 		}
 	}
@@ -726,7 +902,7 @@ func (g *Game) determineProjectileType(unitName string) string {
 }
 
 // createProjectile creates a new projectile
-func (g *Game) createProjectile(startX, startY, targetX, targetY float64, damage int, ownerID, targetID int64, targetBaseX, targetBaseY float64, projectileType string) {
+func (g *Game) createProjectile(startX, startY, targetX, targetY float64, damage int, ownerID, targetID int64, targetBaseX, targetBaseY float64, projectileType string) *Projectile {
 	projectile := &Projectile{
 		ID:             protocol.NewID(),
 		X:              startX,
@@ -735,6 +911,7 @@ func (g *Game) createProjectile(startX, startY, targetX, targetY float64, damage
 		TY:             targetY,
 		Damage:         damage,
 		OwnerID:        ownerID,
+		TeamID:         g.teamOf(ownerID),
 		TargetID:       targetID,
 		TargetX:        targetBaseX,
 		TargetY:        targetBaseY,
@@ -753,6 +930,7 @@ func (g *Game) createProjectile(startX, startY, targetX, targetY float64, damage
 	}
 
 	g.projectiles[projectile.ID] = projectile
+	return projectile
 }
 
 // updateProjectiles updates all active projectiles
@@ -797,7 +975,7 @@ func (g *Game) updateProjectiles(dt float64) {
 func (g *Game) applyProjectileDamage(proj *Projectile) {
 	if proj.TargetID != 0 {
 		// Damage primary target unit
-		if targetUnit, exists := g.units[proj.TargetID]; exists {
+		if targetUnit, exists := g.units[proj.TargetID]; exists && !targetUnit.immuneTo(proj.SourceClass, proj.SourceSubClass) {
 			targetUnit.HP -= proj.Damage
 			if targetUnit.HP < 0 {
 				targetUnit.HP = 0
@@ -809,7 +987,7 @@ func (g *Game) applyProjectileDamage(proj *Projectile) {
 	} else {
 		// Damage base
 		for _, p := range g.players {
-			if p.ID != proj.OwnerID {
+			if p.TeamID != proj.TeamID {
 				bx := float64(p.Base.X + p.Base.W/2)
 				by := float64(p.Base.Y + p.Base.H/2)
 				if math.Hypot(proj.X-bx, proj.Y-by) <= 50 {
@@ -852,7 +1030,7 @@ func (g *Game) applyAoEDamage(proj *Projectile) {
 
 	// Find all enemy units within AoE radius
 	for _, unit := range g.units {
-		if unit.OwnerID == proj.OwnerID || unit.HP <= 0 {
+		if unit.TeamID == proj.TeamID || unit.HP <= 0 || unit.immuneTo(proj.SourceClass, proj.SourceSubClass) {
 			continue
 		}
 
@@ -905,6 +1083,27 @@ func (g *Game) HandleDeploy(pid int64, d protocol.DeployMiniAt) {
 	// TODO: strict spawn zones; for now allow anywhere your client permits
 	p.Gold -= card.Cost
 
+	g.spawnUnit(card, pid, d.X, d.Y)
+
+	// rotate handnow i see bases and enemy attacks mine but i still cannot place units
+	played := p.Hand[d.CardIndex]
+	if p.Next != nil {
+		p.Hand[d.CardIndex] = *p.Next
+	}
+	if len(p.Queue) > 0 {
+		p.Queue = append(p.Queue[1:], played)
+	}
+	if len(p.Queue) > 0 {
+		nx := p.Queue[0]
+		p.Next = &nx
+	} else {
+		p.Next = nil
+	}
+
+}
+
+// spawnUnit creates a unit from a card at (x, y) and broadcasts its spawn event.
+func (g *Game) spawnUnit(card MiniCard, ownerID int64, x, y float64) *Unit {
 	// Calculate attack cooldown from JSON data
 	attackCooldown := 2.0 // default
 	if card.Cooldown > 0 {
@@ -916,19 +1115,26 @@ func (g *Game) HandleDeploy(pid int64, d protocol.DeployMiniAt) {
 	u := &Unit{
 		ID:   protocol.NewID(),
 		Name: card.Name,
-		X:    d.X, Y: d.Y,
+		X:    x, Y: y,
 		HP: max1(card.HP, 1), MaxHP: max1(card.HP, 1),
 		DMG:            card.DMG,
 		Heal:           card.Heal,
 		Hps:            card.Hps,
 		Speed:          speedPx(card.Speed),
-		OwnerID:        pid,
+		OwnerID:        ownerID,
+		TeamID:         g.teamOf(ownerID),
 		Class:          card.Class,
 		SubClass:       card.SubClass,
 		Range:          card.Range,
 		Particle:       card.Particle,
 		AttackCooldown: attackCooldown,
 	}
+	if g.rules.DamageMult > 0 {
+		u.DMG = int(float64(u.DMG) * g.rules.DamageMult)
+	}
+	if g.rules.SpeedMult > 0 {
+		u.Speed *= g.rules.SpeedMult
+	}
 	g.units[u.ID] = u
 
 	// Broadcast unit spawn event for visual effects
@@ -944,22 +1150,7 @@ func (g *Game) HandleDeploy(pid int64, d protocol.DeployMiniAt) {
 		}
 		g.broadcastEvent("UnitSpawnEvent", spawnEvent)
 	}
-
-	// rotate handnow i see bases and enemy attacks mine but i still cannot place units
-	played := p.Hand[d.CardIndex]
-	if p.Next != nil {
-		p.Hand[d.CardIndex] = *p.Next
-	}
-	if len(p.Queue) > 0 {
-		p.Queue = append(p.Queue[1:], played)
-	}
-	if len(p.Queue) > 0 {
-		nx := p.Queue[0]
-		p.Next = &nx
-	} else {
-		p.Next = nil
-	}
-
+	return u
 }
 
 // Info needed to render the army picker
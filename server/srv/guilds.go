@@ -3,20 +3,19 @@ package srv
 import (
     "encoding/json"
     "errors"
-    "os"
-    "path/filepath"
     "sort"
     "strings"
     "sync"
     "time"
 
+    "rumble/server/store"
     "rumble/shared/protocol"
 )
 
-// In-memory guild store with JSON persistence.
+// In-memory guild store, persisted one guild per key in the store.
 type Guilds struct {
     mu     sync.RWMutex
-    path   string
+    st     store.Store
     guilds map[string]*Guild
 }
 
@@ -31,31 +30,26 @@ type Guild struct {
     Created int64             `json:"created"`
 }
 
-func NewGuilds(dataDir string) (*Guilds, error) {
-    p := filepath.Join(dataDir, "guilds.json")
-    g := &Guilds{path: p, guilds: map[string]*Guild{}}
-    b, err := os.ReadFile(p)
-    if err == nil {
-        _ = json.Unmarshal(b, &g.guilds)
-    }
-    return g, nil
+func NewGuilds(st store.Store) (*Guilds, error) {
+    g := &Guilds{st: st, guilds: map[string]*Guild{}}
+    err := st.ForEach(store.Guilds, func(key string, val []byte) error {
+        var gg Guild
+        if json.Unmarshal(val, &gg) == nil {
+            g.guilds[key] = &gg
+        }
+        return nil
+    })
+    return g, err
 }
 
-// saveUnsafe marshals and writes without acquiring the RWMutex.
+// saveUnsafe writes one guild, or deletes it once it is gone from the map.
 // Caller must hold g.mu (at least read lock) or ensure external synchronization.
-func (g *Guilds) saveUnsafe() error {
-    b, _ := json.MarshalIndent(g.guilds, "", "  ")
-    _ = os.MkdirAll(filepath.Dir(g.path), 0o755)
-    return os.WriteFile(g.path, b, 0o644)
-}
-
-// save acquires a read lock and writes the file; use this when no outer lock is held.
-func (g *Guilds) save() error {
-    g.mu.RLock()
-    b, _ := json.MarshalIndent(g.guilds, "", "  ")
-    g.mu.RUnlock()
-    _ = os.MkdirAll(filepath.Dir(g.path), 0o755)
-    return os.WriteFile(g.path, b, 0o644)
+func (g *Guilds) saveUnsafe(id string) error {
+    gg := g.guilds[id]
+    if gg == nil {
+        return g.st.Delete(store.Guilds, id)
+    }
+    return store.PutJSON(g.st, store.Guilds, id, gg)
 }
 
 func (g *Guilds) Create(name, desc, privacy, region, leader string) (*Guild, error) {
@@ -67,7 +61,7 @@ func (g *Guilds) Create(name, desc, privacy, region, leader string) (*Guild, err
     g.mu.Lock()
     g.guilds[id] = gg
     // write under lock to avoid re-read races; use unsafe to prevent self-deadlock
-    _ = g.saveUnsafe()
+    _ = g.saveUnsafe(id)
     g.mu.Unlock()
     return gg, nil
 }
@@ -79,7 +73,7 @@ func (g *Guilds) Join(guildID, username string) error {
     if len(gg.Members) >= 25 { g.mu.Unlock(); return errors.New("guild is full (25)") }
     if gg.Members == nil { gg.Members = map[string]string{} }
     gg.Members[username] = "member"
-    err := g.saveUnsafe()
+    err := g.saveUnsafe(guildID)
     g.mu.Unlock()
     return err
 }
@@ -103,7 +97,7 @@ func (g *Guilds) Leave(guildID, username string) error {
     if len(gg.Members) == 0 {
         delete(g.guilds, guildID)
     }
-    err := g.saveUnsafe()
+    err := g.saveUnsafe(guildID)
     g.mu.Unlock()
     return err
 }
@@ -121,7 +115,7 @@ func (g *Guilds) SetRole(gid, actor, user, role string) error {
     if gg.Members == nil { gg.Members = map[string]string{} }
     gg.Members[user] = role
     if role == "leader" { gg.Leader = user; gg.Members[actor] = "officer" }
-    return g.saveUnsafe()
+    return g.saveUnsafe(gid)
 }
 
 func (g *Guilds) Kick(gid, actor, user string) error {
@@ -139,7 +133,7 @@ func (g *Guilds) Kick(gid, actor, user string) error {
     }
     delete(gg.Members, user)
     if user == gg.Leader { gg.Leader = actor }
-    return g.saveUnsafe()
+    return g.saveUnsafe(gid)
 }
 
 func (g *Guilds) SetDesc(gid, actor, desc string) error {
@@ -149,7 +143,7 @@ func (g *Guilds) SetDesc(gid, actor, desc string) error {
     ar := gg.Members[actor]
     if ar != "leader" && ar != "officer" { g.mu.Unlock(); return errors.New("insufficient role") }
     gg.Desc = desc
-    err := g.saveUnsafe()
+    err := g.saveUnsafe(gid)
     g.mu.Unlock()
     return err
 }
@@ -3,14 +3,12 @@ package srv
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/fs"
 	"log"
 	"math/rand"
-	"os"
 	"path/filepath"
-	"regexp"
+	"rumble/server/store"
 	"rumble/shared/protocol"
 	"sort"
 	"strings"
@@ -26,6 +24,10 @@ type client struct {
 	id   int64
 	room *Room
 	name string
+	// watch-party battle this client spectates (guarded by hub.mu)
+	watching *Room
+	// account the connection authenticated as ("" for HandleWS)
+	account string
 }
 
 type Session struct {
@@ -67,12 +69,37 @@ type Hub struct {
 	pvpQueue       []*client
 	friendly       map[string]*client
 	friendByClient map[*client]string // host client -> code (for cancel/cleanup)
+	friendlyDraft  map[string]bool    // friendly codes that start with a draft
+	friendlyRules  map[string]protocol.Mutators
+	coopInvites    map[*client]*coopInvite
+	duelInvites    map[*client]*duelInvite
+	teamQueue      []*client             // ranked 2v2
+	teamLobbies    map[string]*teamLobby // friendly 2v2 by code
+	lobbyByClient  map[*client]*teamLobby
+	draftQueue     []*client
+	drafts         map[*client]*draftSession
 
 	// Guilds and chat
 	guilds    *Guilds
 	guildSubs map[string]map[*client]struct{} // guildID -> clients subscribed
 
 	social *Social
+
+	tournaments *Tournaments
+
+	profiles *ProfileService
+	st       store.Store            // match history (see RunMatchLog)
+	matchLog chan store.MatchRecord // records waiting for RunMatchLog
+
+	parties map[string]*party // member name (lower case) -> party
+
+	// Operator access (see admin.go)
+	ops          chan func() // run on the Run goroutine between ticks
+	announcement protocol.Announcement
+	draining     bool             // shutting down: no new matches (see shutdown.go)
+	suspended    map[*client]bool // players of matches saved for the next process
+	isAdmin      func(username string) bool
+	roomsActive  map[string]int // mode -> rooms with a running battle, recounted by Run
 }
 
 func NewHub() *Hub {
@@ -85,8 +112,20 @@ func NewHub() *Hub {
 		pvpQueue:       make([]*client, 0, 64),
 		friendly:       make(map[string]*client),
 		friendByClient: make(map[*client]string),
+		friendlyDraft:  make(map[string]bool),
+		friendlyRules:  make(map[string]protocol.Mutators),
+		coopInvites:    make(map[*client]*coopInvite),
+		duelInvites:    make(map[*client]*duelInvite),
+		teamLobbies:    make(map[string]*teamLobby),
+		lobbyByClient:  make(map[*client]*teamLobby),
+		drafts:         make(map[*client]*draftSession),
 		guildSubs:      make(map[string]map[*client]struct{}),
+		parties:        make(map[string]*party),
+		ops:            make(chan func()),
+		matchLog:       make(chan store.MatchRecord, 64),
+		suspended:      make(map[*client]bool),
 	}
+	h.registerMetrics()
 	// guilds set by main() via setter to pass data dir
 	return h
 }
@@ -94,12 +133,25 @@ func NewHub() *Hub {
 func (h *Hub) SetGuilds(g *Guilds) { h.guilds = g }
 func (h *Hub) SetSocial(s *Social) { h.social = s }
 
+func (h *Hub) SetTournaments(t *Tournaments) { h.tournaments = t }
+
+func (h *Hub) SetProfiles(ps *ProfileService) { h.profiles = ps }
+
+func (h *Hub) SetStore(st store.Store) { h.st = st }
+
+// SetAdminCheck tells the hub which accounts may use the editor messages.
+func (h *Hub) SetAdminCheck(isAdmin func(username string) bool) { h.isAdmin = isAdmin }
+
 func makeRoomID(prefix string) string {
 	return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
 }
 
 func (h *Hub) EnqueuePvp(c *client) {
 	h.mu.Lock()
+	if h.queueBlockedLocked(c) {
+		h.mu.Unlock()
+		return
+	}
 	// prevent duplicate
 	for _, x := range h.pvpQueue {
 		if x == c {
@@ -123,7 +175,7 @@ func (h *Hub) EnqueuePvp(c *client) {
 		selectedArena := h.selectRandomArena()
 		if selectedArena != "" {
 			if mapDef, err := loadMapDef(selectedArena); err == nil {
-				r.g.mapDef = &mapDef
+				r.g.SetMapDef(&mapDef)
 				log.Printf("Selected arena %s for PvP: playerBase=%.2f,%.2f enemyBase=%.2f,%.2f",
 					selectedArena, mapDef.PlayerBase.X, mapDef.PlayerBase.Y, mapDef.EnemyBase.X, mapDef.EnemyBase.Y)
 			} else {
@@ -163,8 +215,7 @@ func (h *Hub) DequeuePvp(c *client) {
 // selectRandomArena randomly selects an arena for PvP games
 func (h *Hub) selectRandomArena() string {
 	// Get all available arenas
-	arenasDir := filepath.Join("data", "arenas")
-	_ = os.MkdirAll(arenasDir, 0o755)
+	_ = ensureArenasDir()
 
 	var arenaIDs []string
 	_ = filepath.WalkDir(arenasDir, func(path string, d fs.DirEntry, err error) error {
@@ -198,29 +249,51 @@ func (h *Hub) selectRandomArena() string {
 }
 
 func (h *Hub) Run() {
-	ticker := time.NewTicker(time.Second / 20)
+	ticker := time.NewTicker(tickInterval())
 	defer ticker.Stop()
-	for range ticker.C {
+	for {
+		select {
+		case fn := <-h.ops:
+			fn()
+			continue
+		case <-ticker.C:
+		}
+
 		// snapshot
 		h.mu.Lock()
 		rooms := make([]*Room, 0, len(h.rooms))
 		for _, r := range h.rooms {
 			rooms = append(rooms, r)
+			if r.active && r.watchedFor != r.g {
+				r.watchedFor = r.g
+				h.attachWatchersLocked(r)
+			}
 		}
 		h.mu.Unlock()
 
 		// tick
 		for _, r := range rooms {
-			r.Tick()
+			tickRoom(r)
 		}
 
-		// prune empties
+		// prune empties; forget the saved copies of finished matches
 		h.mu.Lock()
+		active := map[string]int{}
 		for id, r := range h.rooms {
-			if len(r.players) == 0 {
+			if r.active {
+				active[r.Mode]++
+			}
+			if r.resume != nil && time.Now().After(r.resume.until) {
+				r.resumeNow()
+			}
+			if r.checkpointed && !r.active && r.resume == nil {
+				r.dropCheckpoint()
+			}
+			if len(r.players) == 0 && r.resume == nil {
 				delete(h.rooms, id)
 			}
 		}
+		h.roomsActive = active
 		h.mu.Unlock()
 	}
 }
@@ -241,46 +314,33 @@ func (h *Hub) HandleWS(conn *websocket.Conn) {
 // HandleWSAuth upgrades a connection that is already authenticated and binds the session to 'username'.
 // It also sends the Profile immediately so the client doesn't have to send SetName first.
 func (h *Hub) HandleWSAuth(conn *websocket.Conn, username string) {
-	c := &client{conn: conn, send: make(chan []byte, 64), name: username}
+	c := &client{conn: conn, send: make(chan []byte, 64), name: username, account: username}
 	h.mu.Lock()
 	h.clients[c] = struct{}{}
 	if h.sessions[c] == nil {
 		s := NewSession()
 		s.Name = username
 		// Load existing profile (or defaults), bind identity
-		prof, err := loadProfile(username)
+		prof, err := h.profiles.Load(username)
 		if err == nil {
 			prof.PlayerID = s.PlayerID
 			prof.Name = s.Name
-			if prof.Avatar == "" {
-				prof.Avatar = "default.png"
-			}
-			if prof.PvPRating == 0 {
-				prof.PvPRating = 1200
-			}
-			if prof.PvPRank == "" {
-				prof.PvPRank = rankName(prof.PvPRating)
-			}
-			if prof.Armies == nil {
-				prof.Armies = map[string][]string{}
-			}
-			if prof.UnitXP == nil {
-				prof.UnitXP = map[string]int{}
-			}
-			if prof.Resources == nil {
-				prof.Resources = map[string]int{}
-			}
 			s.Profile = prof
 			s.Army = append([]string{}, prof.Army...)
 		}
 		h.sessions[c] = s
 	}
+	h.resumePartyLocked(c)
 	h.mu.Unlock()
 
 	go c.writer()
 	if s := h.sessions[c]; s != nil {
 		sendJSON(c, "Profile", s.Profile)
 	}
+	h.mu.Lock()
+	h.sendAnnouncementLocked(c)
+	h.mu.Unlock()
+	h.rejoinResumedMatch(c)
 	c.reader(h)
 }
 
@@ -305,7 +365,15 @@ func (c *client) reader(h *Hub) {
 		if code, ok := h.friendByClient[c]; ok {
 			delete(h.friendByClient, c)
 			delete(h.friendly, code)
+			delete(h.friendlyDraft, code)
+			delete(h.friendlyRules, code)
 		}
+		delete(h.coopInvites, c)
+		delete(h.duelInvites, c)
+		h.dropTeamStateLocked(c)
+		h.dropDraftStateLocked(c)
+		h.stopWatchingLocked(c)
+		h.dropPartyLocked(h.sessionNameLocked(c))
 		// remove from guild subscriptions
 		for gid, set := range h.guildSubs {
 			if _, ok := set[c]; ok {
@@ -331,6 +399,10 @@ func (c *client) reader(h *Hub) {
 			continue
 		}
 		log.Printf("WS msg type=%s", env.Type)
+		mMsgsIn.Inc(env.Type)
+		if h.refusedWhileDraining(c, env.Type) {
+			continue
+		}
 
 		switch env.Type {
 
@@ -346,29 +418,20 @@ func (c *client) reader(h *Hub) {
 				h.sessions[c] = s
 			}
 			s.Name = msg.Name
-			prof, err := loadProfile(s.Name)
+			prof, err := h.profiles.Load(s.Name)
 			if err != nil {
-				log.Printf("loadProfile: %v", err)
+				log.Printf("profiles.Load: %v", err)
 			}
 			// bind server-issued ID + name
 			prof.PlayerID = s.PlayerID
 			prof.Name = s.Name
 			s.Profile = prof
-			if s.Profile.Avatar == "" {
-				s.Profile.Avatar = "default.png"
-			}
-			if s.Profile.PvPRating == 0 {
-				s.Profile.PvPRating = 1200
-			}
-			if s.Profile.PvPRank == "" {
-				s.Profile.PvPRank = rankName(s.Profile.PvPRating)
-			}
 			// keep legacy field in sync
 			s.Army = append([]string{}, prof.Army...)
 			h.mu.Unlock()
-			// Ensure a profile file exists for new users
-			if err := saveProfile(s.Profile); err != nil {
-				log.Printf("saveProfile(SetName): %v", err)
+			// Ensure a profile exists for new users
+			if err := h.profiles.Save(s.Profile); err != nil {
+				log.Printf("profiles.Save(SetName): %v", err)
 			}
 
 			sendJSON(c, "Profile", s.Profile)
@@ -431,7 +494,7 @@ func (c *client) reader(h *Hub) {
 			h.mu.Lock()
 			if s := h.sessions[c]; s != nil {
 				s.Profile.GuildID = g.GuildID
-				_ = saveProfile(s.Profile)
+				_ = h.profiles.Save(s.Profile)
 			}
 			h.mu.Unlock()
 			if gp, ok := h.guilds.BuildProfile(g.GuildID); ok {
@@ -458,7 +521,7 @@ func (c *client) reader(h *Hub) {
 			h.mu.Lock()
 			if s := h.sessions[c]; s != nil {
 				s.Profile.GuildID = m.GuildID
-				_ = saveProfile(s.Profile)
+				_ = h.profiles.Save(s.Profile)
 			}
 			h.mu.Unlock()
 			if gp, ok := h.guilds.BuildProfile(m.GuildID); ok {
@@ -491,7 +554,7 @@ func (c *client) reader(h *Hub) {
 				h.mu.Lock()
 				if s := h.sessions[c]; s != nil {
 					s.Profile.GuildID = ""
-					_ = saveProfile(s.Profile)
+					_ = h.profiles.Save(s.Profile)
 				}
 				h.mu.Unlock()
 			}
@@ -699,9 +762,8 @@ func (c *client) reader(h *Hub) {
 				}
 				h.mu.Unlock()
 				if !exists {
-					// Check persisted profile file without creating defaults
-					path := profilePath(target)
-					if _, err := os.Stat(path); err == nil {
+					// Check the persisted profile without creating defaults
+					if h.profiles.Exists(target) {
 						exists = true
 					}
 				}
@@ -808,7 +870,7 @@ func (c *client) reader(h *Hub) {
 			h.mu.Lock()
 			if s := h.sessions[c]; s != nil {
 				s.Profile.Avatar = a
-				_ = saveProfile(s.Profile)
+				_ = h.profiles.Save(s.Profile)
 				// send updated profile back so client refreshes UI
 				prof := s.Profile
 				h.mu.Unlock()
@@ -834,19 +896,7 @@ func (c *client) reader(h *Hub) {
 			if name == "" {
 				break
 			}
-			prof, err := loadProfile(name)
-			if err == nil {
-				// fill defaults like elsewhere
-				if prof.PvPRating == 0 {
-					prof.PvPRating = 1200
-				}
-				if prof.PvPRank == "" {
-					prof.PvPRank = rankName(prof.PvPRating)
-				}
-				if prof.Avatar == "" {
-					prof.Avatar = "default.png"
-				}
-			}
+			prof, _ := h.profiles.Load(name)
 			sendJSON(c, "UserProfile", protocol.UserProfile{Profile: prof})
 
 		case "SaveArmy":
@@ -874,8 +924,8 @@ func (c *client) reader(h *Hub) {
 			// legacy sync
 			s.Army = append([]string{}, msg.Cards...)
 			// persist
-			if err := saveProfile(s.Profile); err != nil {
-				log.Printf("saveProfile: %v", err)
+			if err := h.profiles.Save(s.Profile); err != nil {
+				log.Printf("profiles.Save: %v", err)
 			}
 			prof := s.Profile
 			h.mu.Unlock()
@@ -886,6 +936,15 @@ func (c *client) reader(h *Hub) {
 			minis := LoadLobbyMinis()
 			sendJSON(c, "Minis", protocol.Minis{Items: minis})
 
+		case "GetCampaign":
+			h.mu.Lock()
+			var prof protocol.Profile
+			if s := h.sessions[c]; s != nil {
+				prof = s.Profile
+			}
+			h.mu.Unlock()
+			sendJSON(c, "CampaignState", buildCampaignState(prof))
+
 		case "ListMaps":
 			maps := listMaps()
 			sendJSON(c, "Maps", protocol.Maps{Items: maps})
@@ -915,6 +974,39 @@ func (c *client) reader(h *Hub) {
 			sendJSON(c, "MapDef", protocol.MapDefMsg{Def: m.Def})
 			maps := listMaps()
 			sendJSON(c, "Maps", protocol.Maps{Items: maps})
+		case "ListWorlds":
+			sendJSON(c, "Worlds", protocol.Worlds{Items: listWorlds()})
+		case "GetWorld":
+			var m protocol.GetWorld
+			_ = json.Unmarshal(env.Data, &m)
+			id := strings.TrimSpace(m.ID)
+			if id == "" {
+				id = defaultWorldID
+			}
+			if def, err := loadWorldDef(id); err == nil {
+				sendJSON(c, "WorldDef", protocol.WorldDefMsg{Def: def})
+			} else {
+				sendJSON(c, "Error", protocol.ErrorMsg{Message: "World not found"})
+			}
+		case "SaveWorld":
+			var m protocol.SaveWorld
+			_ = json.Unmarshal(env.Data, &m)
+			// worlds gate campaign maps (see worldUnlocked), so only admins edit them
+			if h.isAdmin == nil || c.account == "" || !h.isAdmin(c.account) {
+				sendJSON(c, "Error", protocol.ErrorMsg{Message: "Only admins can save worlds"})
+				break
+			}
+			if strings.TrimSpace(m.Def.ID) == "" && strings.TrimSpace(m.Def.Name) == "" {
+				sendJSON(c, "Error", protocol.ErrorMsg{Message: "World requires id or name"})
+				break
+			}
+			def, err := saveWorldDef(m.Def)
+			if err != nil {
+				sendJSON(c, "Error", protocol.ErrorMsg{Message: err.Error()})
+				break
+			}
+			sendJSON(c, "WorldDef", protocol.WorldDefMsg{Def: def})
+			sendJSON(c, "Worlds", protocol.Worlds{Items: listWorlds()})
 
 		// ---------- Room / PvE ----------
 		case "CreatePve":
@@ -924,17 +1016,23 @@ func (c *client) reader(h *Hub) {
 			roomID := fmt.Sprintf("pve-%d", protocol.NewID())
 
 			h.mu.Lock()
-			r := NewRoom(roomID, h)
-			r.Mode = "pve"
-			h.rooms[roomID] = r
 			s := h.sessions[c]
 			if s == nil {
 				s = NewSession()
 				h.sessions[c] = s
 			}
+			// Campaign maps stay locked until their prerequisites are cleared
+			if !campaignMapAllowed(m.MapID, s.Profile) {
+				h.mu.Unlock()
+				sendJSON(c, "Error", protocol.ErrorMsg{Message: "Map locked: clear the previous maps first"})
+				break
+			}
+			r := NewRoom(roomID, h)
+			r.Mode = "pve"
+			h.rooms[roomID] = r
 			// Load map definition fresh each time (no caching)
 			if mapDef, err := loadMapDef(m.MapID); err == nil {
-				r.g.mapDef = &mapDef
+				r.g.SetMapDef(&mapDef)
 				log.Printf("Loaded map %s for PvE: playerBase=%.2f,%.2f enemyBase=%.2f,%.2f",
 					m.MapID, mapDef.PlayerBase.X, mapDef.PlayerBase.Y, mapDef.EnemyBase.X, mapDef.EnemyBase.Y)
 			} else {
@@ -946,18 +1044,168 @@ func (c *client) reader(h *Hub) {
 			h.mu.Unlock()
 
 			sendJSON(c, "RoomCreated", protocol.RoomCreated{RoomID: roomID})
+		case "CreateSurvival":
+			var m protocol.CreateSurvival
+			_ = json.Unmarshal(env.Data, &m)
+			mapID := m.MapID
+			if mapID == "" {
+				mapID = defaultSurvivalMap
+			}
+
+			roomID := fmt.Sprintf("surv-%d", protocol.NewID())
+
+			h.mu.Lock()
+			s := h.sessions[c]
+			if s == nil {
+				s = NewSession()
+				h.sessions[c] = s
+			}
+			r := NewRoom(roomID, h)
+			r.Mode = "survival"
+			r.surv = newSurvivalRun(armyLevel(s.Army, s.Profile.UnitXP))
+			h.rooms[roomID] = r
+			if mapDef, err := loadMapDef(mapID); err == nil {
+				r.g.SetMapDef(&mapDef)
+			} else {
+				log.Printf("Failed to load map %s for survival: %v", mapID, err)
+			}
+			r.JoinClient(c, s)
+			s.RoomID = roomID
+			h.mu.Unlock()
+
+			sendJSON(c, "RoomCreated", protocol.RoomCreated{RoomID: roomID})
+			r.StartBattle()
+		case "CreateChallenge":
+			week, rules := weeklyChallenge(time.Now())
+			roomID := fmt.Sprintf("chal-%d", protocol.NewID())
+
+			h.mu.Lock()
+			s := h.sessions[c]
+			if s == nil {
+				s = NewSession()
+				h.sessions[c] = s
+			}
+			r := NewRoom(roomID, h)
+			r.Mode = "challenge"
+			h.rooms[roomID] = r
+			r.g.SetRules(rules)
+			if mapDef, err := loadMapDef(challengeMap); err == nil {
+				r.g.SetMapDef(&mapDef)
+			} else {
+				log.Printf("Failed to load map %s for challenge %s: %v", challengeMap, week, err)
+			}
+			r.JoinClient(c, s)
+			s.RoomID = roomID
+			h.mu.Unlock()
+
+			sendJSON(c, "RoomCreated", protocol.RoomCreated{RoomID: roomID})
+			r.StartBattle()
+		case "GetMutators":
+			sendJSON(c, "MutatorList", buildMutatorList())
+		case "GetChallengeLeaderboard":
+			sendJSON(c, "ChallengeLeaderboard", h.buildChallengeLeaderboardTop50())
+		case "GetSurvivalLeaderboard":
+			sendJSON(c, "SurvivalLeaderboard", h.buildSurvivalLeaderboardTop50())
 		case "JoinPvpQueue":
 			h.EnqueuePvp(c)
 		case "LeavePvpQueue":
 			h.DequeuePvp(c)
+		case "JoinTeamQueue":
+			h.EnqueueTeam(c)
+		case "LeaveTeamQueue":
+			h.DequeueTeam(c)
+		case "TeamLobbyCreate":
+			h.TeamLobbyCreate(c)
+		case "TeamLobbyJoin":
+			var m protocol.TeamLobbyJoin
+			_ = json.Unmarshal(env.Data, &m)
+			h.TeamLobbyJoin(c, m)
+		case "TeamLobbyLeave":
+			h.TeamLobbyLeave(c)
+		case "JoinDraftQueue":
+			h.EnqueueDraft(c)
+		case "LeaveDraftQueue":
+			h.DequeueDraft(c)
+		case "DraftBan":
+			var m protocol.DraftBan
+			_ = json.Unmarshal(env.Data, &m)
+			h.DraftAct(c, m.Name, true)
+		case "DraftPick":
+			var m protocol.DraftPick
+			_ = json.Unmarshal(env.Data, &m)
+			h.DraftAct(c, m.Name, false)
 		case "FriendlyCreate":
-			h.FriendlyCreate(c)
+			var m protocol.FriendlyCreate
+			_ = json.Unmarshal(env.Data, &m)
+			h.FriendlyCreate(c, m)
 		case "FriendlyCancel":
 			h.FriendlyCancel(c)
 		case "FriendlyJoin":
 			var m protocol.FriendlyJoin
 			_ = json.Unmarshal(env.Data, &m)
 			h.FriendlyJoin(c, m.Code)
+		case "CoopInvite":
+			var m protocol.CoopInvite
+			_ = json.Unmarshal(env.Data, &m)
+			h.CoopInvite(c, m)
+		case "CoopAccept":
+			var m protocol.CoopAccept
+			_ = json.Unmarshal(env.Data, &m)
+			h.CoopAccept(c, m.From)
+		case "CoopDecline":
+			var m protocol.CoopDecline
+			_ = json.Unmarshal(env.Data, &m)
+			h.CoopDecline(c, m.From)
+		case "InviteToDuel":
+			var m protocol.InviteToDuel
+			_ = json.Unmarshal(env.Data, &m)
+			h.InviteToDuel(c, m)
+		case "DuelAccept":
+			var m protocol.DuelAccept
+			_ = json.Unmarshal(env.Data, &m)
+			h.DuelAccept(c, m.From)
+		case "DuelDecline":
+			var m protocol.DuelDecline
+			_ = json.Unmarshal(env.Data, &m)
+			h.DuelDecline(c, m.From)
+		case "PartyCreate":
+			h.PartyCreate(c)
+		case "PartyInvite":
+			var m protocol.PartyInvite
+			_ = json.Unmarshal(env.Data, &m)
+			h.PartyInvite(c, m.Name)
+		case "PartyAccept":
+			var m protocol.PartyAccept
+			_ = json.Unmarshal(env.Data, &m)
+			h.PartyAccept(c, m.Leader)
+		case "PartyDecline":
+			var m protocol.PartyDecline
+			_ = json.Unmarshal(env.Data, &m)
+			h.PartyDecline(c, m.Leader)
+		case "PartyLeave":
+			h.PartyLeave(c)
+		case "PartyQueue":
+			var m protocol.PartyQueue
+			_ = json.Unmarshal(env.Data, &m)
+			h.PartyQueue(c, m)
+		case "ListTournaments":
+			h.ListTournaments(c)
+		case "GetTournament":
+			var m protocol.GetTournament
+			_ = json.Unmarshal(env.Data, &m)
+			h.GetTournament(c, m.ID)
+		case "CreateTournament":
+			var m protocol.CreateTournament
+			_ = json.Unmarshal(env.Data, &m)
+			h.CreateTournament(c, m)
+		case "JoinTournament":
+			var m protocol.JoinTournament
+			_ = json.Unmarshal(env.Data, &m)
+			h.JoinTournament(c, m.ID)
+		case "LeaveTournament":
+			var m protocol.LeaveTournament
+			_ = json.Unmarshal(env.Data, &m)
+			h.LeaveTournament(c, m.ID)
 		case "GetLeaderboard":
 			lb := h.buildLeaderboardTop50()
 			sendJSON(c, "Leaderboard", lb)
@@ -981,6 +1229,7 @@ func (c *client) reader(h *Hub) {
 
 		case "LeaveRoom":
 			h.mu.Lock()
+			h.stopWatchingLocked(c)
 			if c.room != nil {
 				c.room.Leave(c)
 				c.room = nil
@@ -990,9 +1239,9 @@ func (c *client) reader(h *Hub) {
 			}
 			h.mu.Unlock()
 
-		// ---------- Timer and Pause Controls (PvE only) ----------
+		// ---------- Timer and Pause Controls (PvE and survival only) ----------
 		case "PauseGame":
-			if c.room != nil && c.room.Mode == "pve" {
+			if c.room != nil && c.room.controlsMatch(c) {
 				c.room.g.PauseTimer()
 				// Send timer update to all players in room
 				for _, p := range c.room.players {
@@ -1004,7 +1253,7 @@ func (c *client) reader(h *Hub) {
 				}
 			}
 		case "ResumeGame":
-			if c.room != nil && c.room.Mode == "pve" {
+			if c.room != nil && c.room.controlsMatch(c) {
 				c.room.g.ResumeTimer()
 				// Send timer update to all players in room
 				for _, p := range c.room.players {
@@ -1016,12 +1265,16 @@ func (c *client) reader(h *Hub) {
 				}
 			}
 		case "RestartMatch":
-			if c.room != nil && c.room.Mode == "pve" {
+			if c.room != nil && c.room.controlsMatch(c) {
 				c.room.g.RestartMatch()
+				if c.room.surv != nil {
+					c.room.surv = newSurvivalRun(c.room.surv.level)
+				} else if c.room.aiActive && c.room.Mode != "survival" {
+					c.room.g.SpawnMapBosses(c.room.aiID)
+				}
 				// Send updated snapshots to all players
 				for _, p := range c.room.players {
-					snap := c.room.g.FullSnapshot()
-					sendJSON(p, "FullSnapshot", snap)
+					sendJSON(p, "FullSnapshot", c.room.snapshotFor(p))
 					if pl := c.room.g.players[p.id]; pl != nil {
 						sendJSON(p, "GoldUpdate", protocol.GoldUpdate{
 							PlayerID: pl.ID,
@@ -1036,13 +1289,26 @@ func (c *client) reader(h *Hub) {
 				}
 			}
 		case "SurrenderMatch":
-			if c.room != nil && c.room.Mode == "pve" {
-				winnerID := c.room.g.SurrenderMatch(c.id)
-				for _, p := range c.room.players {
+			r := c.room
+			if r == nil || !r.controlsMatch(c) {
+				break
+			}
+			// on the Run goroutine, so it cannot race the room's own tick
+			h.onLoop(func() {
+				if !r.active || r.g.matchEnded {
+					return
+				}
+				if r.Mode == "survival" {
+					r.endSurvival()
+					return
+				}
+				winnerID := r.g.SurrenderMatch(c.id)
+				for _, p := range r.players {
 					sendJSON(p, "GameOver", protocol.GameOver{WinnerID: winnerID})
 				}
-				c.room.active = false
-			}
+				r.active = false
+				mMatchesFinished.Inc(r.Mode)
+			})
 
 		// ---------- Gameplay ----------
 		case "DeployMiniAt":
@@ -1056,6 +1322,14 @@ func (c *client) reader(h *Hub) {
 			if c.room != nil {
 				c.room.MarkReady(c)
 			}
+		case "SetReady":
+			var m protocol.SetReady
+			_ = json.Unmarshal(env.Data, &m)
+			h.SetLobbyReady(c, m.Ready)
+		case "LobbySettings":
+			var m protocol.LobbySettings
+			_ = json.Unmarshal(env.Data, &m)
+			h.UpdateLobbySettings(c, m)
 
 		case "Logout":
 			h.mu.Lock()
@@ -1074,8 +1348,17 @@ func (c *client) reader(h *Hub) {
 			if code, ok := h.friendByClient[c]; ok {
 				delete(h.friendByClient, c)
 				delete(h.friendly, code)
-			}
+				delete(h.friendlyDraft, code)
+				delete(h.friendlyRules, code)
+			}
+			delete(h.coopInvites, c)
+			delete(h.duelInvites, c)
+			h.dropTeamStateLocked(c)
+			h.dropDraftStateLocked(c)
+			h.stopWatchingLocked(c)
+			name := h.sessionNameLocked(c)
 			delete(h.sessions, c) // drop session so next login gets a fresh one
+			h.dropPartyLocked(name)
 			h.mu.Unlock()
 
 			// tell the client it's ok to close from their side
@@ -1105,89 +1388,12 @@ func sendJSON(c *client, typ string, v interface{}) {
 	out, _ := json.Marshal(env)
 	select {
 	case c.send <- out:
+		mMsgsOut.Inc(typ)
 	default:
+		mMsgsDropped.Inc(typ)
 	}
 }
 
-/* ------------------- simple JSON persistence (per account) ------------------- */
-
-var profilesDir = filepath.Join("data", "profiles")
-
-func safeFileName(name string) string {
-	re := regexp.MustCompile(`[^a-zA-Z0-9]+`)
-	s := re.ReplaceAllString(name, "_")
-	if s == "" {
-		s = "player"
-	}
-	return s
-}
-
-func ensureProfilesDir() error {
-	return os.MkdirAll(profilesDir, 0o755)
-}
-
-func profilePath(name string) string {
-	return filepath.Join(profilesDir, safeFileName(name)+".json")
-}
-
-func loadProfile(name string) (protocol.Profile, error) {
-	_ = ensureProfilesDir()
-	path := profilePath(name)
-	b, err := os.ReadFile(path)
-	if errors.Is(err, os.ErrNotExist) {
-		return protocol.Profile{
-			Name:      name,
-			Army:      nil,
-			Armies:    map[string][]string{},
-			Gold:      0,
-			AccountXP: 0,
-			UnitXP:    map[string]int{},
-			Resources: map[string]int{},
-		}, nil
-	}
-	if err != nil {
-		return protocol.Profile{}, err
-	}
-	var p protocol.Profile
-	if err := json.Unmarshal(b, &p); err != nil {
-		return protocol.Profile{}, err
-	}
-	if p.Avatar == "" {
-		p.Avatar = "default.png"
-	}
-	// PvP defaults if missing/zero
-	if p.PvPRating == 0 {
-		p.PvPRating = 1200
-	}
-	if p.PvPRank == "" {
-		p.PvPRank = rankName(p.PvPRating)
-	}
-	if p.Armies == nil {
-		p.Armies = map[string][]string{}
-	}
-	if p.UnitXP == nil {
-		p.UnitXP = map[string]int{}
-	}
-	if p.Resources == nil {
-		p.Resources = map[string]int{}
-	}
-	return p, nil
-}
-
-func saveProfile(p protocol.Profile) error {
-	_ = ensureProfilesDir()
-	path := profilePath(p.Name)
-	b, err := json.MarshalIndent(p, "", "  ")
-	if err != nil {
-		return err
-	}
-	tmp := path + ".tmp"
-	if err := os.WriteFile(tmp, b, 0o644); err != nil {
-		return err
-	}
-	return os.Rename(tmp, path)
-}
-
 func genCode(n int) string {
 	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // no 0/O/1/I to avoid confusion
 	b := make([]byte, n)
@@ -1197,7 +1403,7 @@ func genCode(n int) string {
 	return string(b)
 }
 
-func (h *Hub) FriendlyCreate(c *client) {
+func (h *Hub) FriendlyCreate(c *client, m protocol.FriendlyCreate) {
 	h.mu.Lock()
 	// already hosting? re-send same code
 	if code, ok := h.friendByClient[c]; ok {
@@ -1216,6 +1422,10 @@ func (h *Hub) FriendlyCreate(c *client) {
 	}
 	h.friendly[code] = c
 	h.friendByClient[c] = code
+	h.friendlyDraft[code] = m.Draft
+	if p := findMutatorPreset(m.Rules); p != nil {
+		h.friendlyRules[code] = *p
+	}
 	h.mu.Unlock()
 
 	sendJSON(c, "FriendlyCode", protocol.FriendlyCode{Code: code})
@@ -1226,6 +1436,8 @@ func (h *Hub) FriendlyCancel(c *client) {
 	if code, ok := h.friendByClient[c]; ok {
 		delete(h.friendByClient, c)
 		delete(h.friendly, code)
+		delete(h.friendlyDraft, code)
+		delete(h.friendlyRules, code)
 	}
 	h.mu.Unlock()
 }
@@ -1253,32 +1465,31 @@ func (h *Hub) FriendlyJoin(c *client, code string) {
 	}
 
 	// consume the code
+	draft := h.friendlyDraft[code]
+	rules, hasRules := h.friendlyRules[code]
 	delete(h.friendly, code)
+	delete(h.friendlyDraft, code)
+	delete(h.friendlyRules, code)
 	delete(h.friendByClient, host)
 
-	// create & register room
-	roomID := makeRoomID("frd")
-	r := NewRoom(roomID, h)
-	r.Mode = "friendly"
-	h.rooms[roomID] = r
-
-	// join both with session identity (IDs, names, saved armies)
-	sa := h.sessions[host]
-	sb := h.sessions[c]
-	r.JoinClient(host, sa)
-	r.JoinClient(c, sb)
-	if sa != nil {
-		sa.RoomID = roomID
+	// draft duels pick their armies first; the room is made when the draft ends
+	if draft {
+		d := h.startDraftLocked(host, c, "friendly")
+		if hasRules {
+			rules.FixedArmy = nil // the draft decides the armies
+			d.rules = &rules
+		}
+		h.mu.Unlock()
+		return
 	}
-	if sb != nil {
-		sb.RoomID = roomID
+
+	// the pair waits in a lobby until both are ready
+	rulesID := ""
+	if hasRules {
+		rulesID = rules.ID
 	}
+	h.openFriendlyLobbyLocked(host, c, rulesID)
 	h.mu.Unlock()
-
-	// notify & start
-	sendJSON(host, "RoomCreated", protocol.RoomCreated{RoomID: roomID})
-	sendJSON(c, "RoomCreated", protocol.RoomCreated{RoomID: roomID})
-	r.StartBattle()
 }
 
 var friendlyHosts = map[string]*client{}
@@ -1303,27 +1514,11 @@ func (h *Hub) send(id int64, typ string, v interface{}) {
 		}
 	}
 }
+
 func (h *Hub) buildLeaderboardTop50() protocol.Leaderboard {
-	_ = ensureProfilesDir()
 	entries := []protocol.LeaderboardEntry{}
 
-	// Walk the profiles dir (flat)
-	fs.WalkDir(os.DirFS(profilesDir), ".", func(p string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
-			return nil
-		}
-		if !strings.HasSuffix(strings.ToLower(p), ".json") {
-			return nil
-		}
-		b, err := os.ReadFile(filepath.Join(profilesDir, p))
-		if err != nil {
-			return nil
-		}
-		var prof protocol.Profile
-		if json.Unmarshal(b, &prof) != nil {
-			return nil
-		}
-
+	h.profiles.ForEach(func(name string, prof protocol.Profile) {
 		// Safety defaults (older files)
 		if prof.PvPRating == 0 {
 			prof.PvPRating = 1200
@@ -1332,17 +1527,11 @@ func (h *Hub) buildLeaderboardTop50() protocol.Leaderboard {
 			prof.PvPRank = rankName(prof.PvPRating)
 		}
 
-		name := prof.Name
-		if name == "" {
-			name = strings.TrimSuffix(filepath.Base(p), ".json")
-		}
-
 		entries = append(entries, protocol.LeaderboardEntry{
 			Name:   name,
 			Rating: prof.PvPRating,
 			Rank:   prof.PvPRank,
 		})
-		return nil
 	})
 
 	sort.Slice(entries, func(i, j int) bool {
@@ -0,0 +1,243 @@
+package srv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rumble/shared/protocol"
+)
+
+// lobbyTimeLimits are the match lengths the host can pick (0 = map default).
+var lobbyTimeLimits = []int{0, 120, 180, 240, 300, 420, 600}
+
+// friendlyLobby is the pre-match state of a 1v1 friendly room. The room
+// stays open after each match so the same pair can rematch. Guarded by hub.mu.
+type friendlyLobby struct {
+	hostID    int64
+	ready     map[int64]bool
+	mapID     string // "" = random duel map
+	timeLimit int
+	rules     string // mutator preset ID
+	played    int
+}
+
+// lobbyMapChoices lists the duel and arena maps a lobby can be played on.
+func lobbyMapChoices() []protocol.MapInfo {
+	_ = ensureDuelsDir()
+	_ = ensureArenasDir()
+	out := []protocol.MapInfo{}
+	seen := map[string]bool{}
+	for _, dir := range []string{duelsDir, arenasDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(strings.ToLower(e.Name()), ".json") {
+				continue
+			}
+			id := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+			if seen[id] {
+				continue
+			}
+			def, err := loadMapDef(id)
+			if err != nil {
+				continue
+			}
+			seen[id] = true
+			out = append(out, protocol.MapInfo{ID: id, Name: def.Name})
+		}
+	}
+	return out
+}
+
+func validLobbyMap(id string) bool {
+	if id == "" {
+		return true
+	}
+	for _, m := range lobbyMapChoices() {
+		if m.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Hub) lobbyStatusLocked(r *Room) protocol.RoomStatus {
+	l := r.lobby
+	st := protocol.RoomStatus{
+		RoomID:    r.id,
+		Players:   []int64{},
+		Ready:     map[int64]bool{},
+		HostID:    l.hostID,
+		Names:     map[int64]string{},
+		MapID:     l.mapID,
+		TimeLimit: l.timeLimit,
+		Rules:     l.rules,
+		Maps:      lobbyMapChoices(),
+		Played:    l.played,
+	}
+	for _, c := range r.players {
+		st.Players = append(st.Players, c.id)
+		st.Ready[c.id] = l.ready[c.id]
+		st.Names[c.id] = c.name
+	}
+	return st
+}
+
+func (h *Hub) broadcastLobbyLocked(r *Room) {
+	st := h.lobbyStatusLocked(r)
+	for _, c := range r.players {
+		sendJSON(c, "RoomStatus", st)
+	}
+}
+
+// openFriendlyLobbyLocked seats host and guest in a new friendly room and
+// sends them the lobby. h.mu must be held.
+func (h *Hub) openFriendlyLobbyLocked(host, guest *client, rules string) {
+	roomID := makeRoomID("frd")
+	r := NewRoom(roomID, h)
+	r.Mode = "friendly"
+	h.rooms[roomID] = r
+	for _, c := range []*client{host, guest} {
+		s := h.sessions[c]
+		if s == nil {
+			continue
+		}
+		r.JoinClient(c, s)
+		s.RoomID = roomID
+	}
+	r.lobby = &friendlyLobby{hostID: host.id, ready: map[int64]bool{}, rules: rules}
+	h.broadcastLobbyLocked(r)
+}
+
+// SetLobbyReady toggles c's ready flag and starts the match once both
+// players are ready.
+func (h *Hub) SetLobbyReady(c *client, ready bool) {
+	h.mu.Lock()
+	r := c.room
+	if r == nil || r.lobby == nil || r.active {
+		h.mu.Unlock()
+		return
+	}
+	r.lobby.ready[c.id] = ready
+	if len(r.players) < 2 {
+		h.broadcastLobbyLocked(r)
+		h.mu.Unlock()
+		return
+	}
+	for _, p := range r.players {
+		if !r.lobby.ready[p.id] {
+			h.broadcastLobbyLocked(r)
+			h.mu.Unlock()
+			return
+		}
+	}
+	h.startLobbyMatchLocked(r)
+	h.mu.Unlock()
+
+	launchRoom(r)
+}
+
+// UpdateLobbySettings applies the host's map, time limit and rules choice.
+func (h *Hub) UpdateLobbySettings(c *client, m protocol.LobbySettings) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r := c.room
+	if r == nil || r.lobby == nil || r.active {
+		return
+	}
+	if r.lobby.hostID != c.id {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Only the host can change the settings"})
+		return
+	}
+	if !validLobbyMap(m.MapID) {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Unknown map"})
+		return
+	}
+	valid := false
+	for _, t := range lobbyTimeLimits {
+		valid = valid || t == m.TimeLimit
+	}
+	if !valid {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Invalid time limit"})
+		return
+	}
+	if m.Rules != "" && findMutatorPreset(m.Rules) == nil {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Unknown rules preset"})
+		return
+	}
+	r.lobby.mapID = m.MapID
+	r.lobby.timeLimit = m.TimeLimit
+	r.lobby.rules = m.Rules
+	r.lobby.ready = map[int64]bool{}
+	h.broadcastLobbyLocked(r)
+}
+
+// startLobbyMatchLocked rebuilds the room's game from the lobby settings
+// and re-seats both players, so every match (and rematch) starts fresh with
+// their current armies. h.mu must be held.
+func (h *Hub) startLobbyMatchLocked(r *Room) {
+	l := r.lobby
+	l.ready = map[int64]bool{}
+	r.resetGame()
+	if p := findMutatorPreset(l.rules); p != nil {
+		r.g.SetRules(*p)
+	}
+	if l.mapID != "" {
+		if def, err := loadMapDef(l.mapID); err == nil {
+			r.g.SetMapDef(&def)
+		}
+	}
+	if l.timeLimit > 0 {
+		if r.g.mapDef == nil {
+			r.g.SetMapDef(randomDuelMap())
+		}
+		if r.g.mapDef != nil {
+			r.g.mapDef.TimeLimit = l.timeLimit
+		}
+	}
+	seats := r.players
+	r.players = nil
+	for _, c := range seats {
+		c.room = nil
+		if s := h.sessions[c]; s != nil {
+			r.JoinClient(c, s)
+		}
+	}
+}
+
+// reopenLobby puts a friendly room back into its lobby once a match ends.
+func (h *Hub) reopenLobby(r *Room) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if r.lobby == nil {
+		return
+	}
+	r.lobby.played++
+	r.lobby.ready = map[int64]bool{}
+	h.broadcastLobbyLocked(r)
+}
+
+// closeLobbyLocked is called when c leaves a lobby room: the others are
+// told, and sent home unless a match is still running. h.mu must be held.
+func (h *Hub) closeLobbyLocked(r *Room, leaver *client) {
+	r.lobby = nil
+	for _, c := range r.players {
+		if c == leaver {
+			continue
+		}
+		sendJSON(c, "LobbyClosed", protocol.LobbyClosed{Reason: "Your opponent left the lobby"})
+		if r.active {
+			continue
+		}
+		c.room = nil
+		if s := h.sessions[c]; s != nil {
+			s.RoomID = ""
+		}
+	}
+	if !r.active {
+		r.players = []*client{leaver}
+	}
+}
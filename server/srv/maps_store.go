@@ -7,12 +7,13 @@ import (
 	"path/filepath"
 	"strings"
 
+	"rumble/server/store"
 	"rumble/shared/protocol"
 )
 
-var mapsDir = filepath.Join("data", "maps")
-var arenasDir = filepath.Join("data", "arenas")
-var duelsDir = filepath.Join("data", "duels")
+var mapsDir = filepath.Join(dataDir, "maps")
+var arenasDir = filepath.Join(dataDir, "arenas")
+var duelsDir = filepath.Join(dataDir, "duels")
 
 func ensureMapsDir() error   { return os.MkdirAll(mapsDir, 0o755) }
 func ensureArenasDir() error { return os.MkdirAll(arenasDir, 0o755) }
@@ -280,6 +281,15 @@ func mirrorArenaMap(def protocol.MapDef) protocol.MapDef {
 		}
 	}
 
+	// Team base slots: an arena only needs the player side, the enemy side is its mirror
+	if len(def.TeamBases) == 1 {
+		enemy := make([]protocol.PointF, len(def.TeamBases[0]))
+		for i, p := range def.TeamBases[0] {
+			enemy[i] = protocol.PointF{X: p.X, Y: 1.0 - p.Y}
+		}
+		mirrored.TeamBases = [][]protocol.PointF{def.TeamBases[0], enemy}
+	}
+
 	return mirrored
 }
 
@@ -297,9 +307,5 @@ func saveMapDef(def protocol.MapDef) error {
 	if err != nil {
 		return err
 	}
-	tmp := mapPath(id) + ".tmp"
-	if err := os.WriteFile(tmp, b, 0o644); err != nil {
-		return err
-	}
-	return os.Rename(tmp, mapPath(id))
+	return store.WriteFile(mapPath(id), b, 0o644)
 }
@@ -1,98 +1,208 @@
 package srv
 
-import "rumble/shared/protocol"
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"time"
 
+	"rumble/server/store"
+	"rumble/shared/protocol"
+)
+
+// applyQueueRating settles Elo after a ranked match. Each human is rated
+// against the average rating of the opposing side, so 1v1 and 2v2 share the
+// same math; teammates' changes differ only by their own rating.
 func applyQueueRating(room *Room, winnerID int64, hub *Hub) {
-		// Collect the two human players (ignore AI if present)
-		var ids []int64
-				for _, c := range room.players {
-						// clients in room, bound to player ids via JoinClient
-						if c != nil && c.id != 0 {
-								ids = append(ids, c.id)
-						}
-				}
-		if len(ids) < 2 {
-				return // need two humans for rating
+	// Collect the human players per side (ignore AI if present)
+	var sides [2][]*Player
+	for _, c := range room.players {
+		if c == nil || c.id == 0 || (room.aiActive && c.id == room.aiID) {
+			continue
+		}
+		if p := room.g.players[c.id]; p != nil && (p.TeamID == 0 || p.TeamID == 1) {
+			sides[p.TeamID] = append(sides[p.TeamID], p)
+		}
+	}
+	// players who abandoned the match are rated too
+	for _, p := range room.departedPlayers() {
+		if p.TeamID == 0 || p.TeamID == 1 {
+			sides[p.TeamID] = append(sides[p.TeamID], p)
 		}
+	}
+	if len(sides[0]) == 0 || len(sides[1]) == 0 {
+		return // need humans on both sides for rating
+	}
 
-		aID, bID := ids[0], ids[1]
-				a, aok := room.g.players[aID]
-				b, bok := room.g.players[bID]
-				if !aok || !bok || a == nil || b == nil {
-						return
-				}
+	// Opponent strength is taken from the ratings before this match
+	var avg [2]int
+	var names [2]string
+	for t, side := range sides {
+		sum := 0
+		nm := make([]string, 0, len(side))
+		for _, p := range side {
+			sum += p.Rating
+			nm = append(nm, p.Name)
+		}
+		avg[t] = sum / len(side)
+		names[t] = strings.Join(nm, " & ")
+	}
 
-		// Compute Elo deltas
-		var dA, dB int
-				if winnerID == a.ID {
-						a.Rating, dA = eloApply(a.Rating, b.Rating, true)
-								b.Rating, dB = eloApply(b.Rating, a.Rating-dA, false)
-				} else {
-						b.Rating, dB = eloApply(b.Rating, a.Rating, true)
-								a.Rating, dA = eloApply(a.Rating, b.Rating-dB, false)
-				}
-		a.Rank = rankName(a.Rating)
-				b.Rank = rankName(b.Rating)
+	winTeam := room.g.teamOf(winnerID) // -1 when the timer ran out on a draw
+	type result struct {
+		p     *Player
+		delta int
+		opp   int // opposing side index
+	}
+	var results []result
+	for t, side := range sides {
+		for _, p := range side {
+			score := 0.0
+			switch {
+			case winTeam < 0:
+				score = 0.5
+			case t == winTeam:
+				score = 1
+			}
+			var d int
+			p.Rating, d = eloApply(p.Rating, avg[1-t], score)
+			p.Rank = rankName(p.Rating)
+			results = append(results, result{p: p, delta: d, opp: 1 - t})
+		}
+	}
 
-				// Update in-memory Sessions and persist to disk by name
-				hub.mu.Lock()
-				var ac, bc *client
-				for c := range hub.clients {
-						if c.id == a.ID { ac = c }
-						if c.id == b.ID { bc = c }
-				}
-		if ac != nil {
-				if s := hub.sessions[ac]; s != nil {
-						s.Profile.PvPRating = a.Rating
-								s.Profile.PvPRank   = a.Rank
-								_ = saveProfile(s.Profile)
-				}
+	// Keep a record of the match alongside the profiles
+	rec := store.MatchRecord{ID: makeRoomID("m"), Mode: room.Mode, At: time.Now().UnixMilli(), Deltas: map[string]int{}}
+	for _, res := range results {
+		rec.Deltas[res.p.Name] = res.delta
+		if winTeam < 0 {
+			continue // a draw has no winners or losers
 		}
-		if bc != nil {
-				if s := hub.sessions[bc]; s != nil {
-						s.Profile.PvPRating = b.Rating
-								s.Profile.PvPRank   = b.Rank
-								_ = saveProfile(s.Profile)
-				}
+		if res.p.TeamID == winTeam {
+			rec.Winners = append(rec.Winners, res.p.Name)
+		} else {
+			rec.Losers = append(rec.Losers, res.p.Name)
+		}
+	}
+	hub.recordMatch(rec)
+
+	// Update in-memory Sessions and persist to disk by name
+	profiles := map[int64]protocol.Profile{}
+	hub.mu.Lock()
+	for c, s := range hub.sessions {
+		if s == nil {
+			continue
+		}
+		for _, res := range results {
+			if c.id == res.p.ID {
+				s.Profile.PvPRating = res.p.Rating
+				s.Profile.PvPRank = res.p.Rank
+				_ = hub.profiles.Save(s.Profile)
+				profiles[res.p.ID] = s.Profile
+			}
 		}
-		hub.mu.Unlock()
+	}
+	// Leavers: their connection is gone (or they came back with a new
+	// session), so update them by name
+	for _, res := range results {
+		if _, ok := profiles[res.p.ID]; ok {
+			continue
+		}
+		if c := hub.clientByNameLocked(res.p.Name); c != nil {
+			s := hub.sessions[c]
+			s.Profile.PvPRating = res.p.Rating
+			s.Profile.PvPRank = res.p.Rank
+			_ = hub.profiles.Save(s.Profile)
+			sendJSON(c, "Profile", s.Profile)
+		} else if prof, err := hub.profiles.Load(res.p.Name); err == nil {
+			prof.PvPRating = res.p.Rating
+			prof.PvPRank = res.p.Rank
+			_ = hub.profiles.Save(prof)
+		}
+	}
+	hub.mu.Unlock()
 
-				// Notify both players
-				hub.send(a.ID, "RatingUpdate", protocol.RatingUpdate{
-NewRating: a.Rating,
-Delta:     dA,
-Rank:      a.Rank,
-OppName:   b.Name,
-OppRating: b.Rating,
-MatchType: "queue",
-})
-hub.send(b.ID, "RatingUpdate", protocol.RatingUpdate{
-NewRating: b.Rating,
-Delta:     dB,
-Rank:      b.Rank,
-OppName:   a.Name,
-OppRating: a.Rating,
-MatchType: "queue",
-})
+	// Notify every rated player, then push their fresh Profile
+	for _, res := range results {
+		hub.send(res.p.ID, "RatingUpdate", protocol.RatingUpdate{
+			NewRating: res.p.Rating,
+			Delta:     res.delta,
+			Rank:      res.p.Rank,
+			OppName:   names[res.opp],
+			OppRating: avg[res.opp],
+			MatchType: room.Mode,
+		})
+		if prof, ok := profiles[res.p.ID]; ok {
+			hub.send(res.p.ID, "Profile", prof)
+		}
+	}
+}
 
-// Push fresh Profile to both (from the sessions we just updated)
-if ac != nil {
-		hub.mu.Lock()
-				if s := hub.sessions[ac]; s != nil {
-						hub.mu.Unlock()
-								hub.send(a.ID, "Profile", s.Profile)
-				} else {
-						hub.mu.Unlock()
-				}
+// matchHistoryKeep caps the ranked match history; older records are dropped.
+const matchHistoryKeep = 2000
+
+// recordMatch queues rec for RunMatchLog. It never blocks the tick loop; a
+// record that does not fit the queue is logged and dropped.
+func (h *Hub) recordMatch(rec store.MatchRecord) {
+	select {
+	case h.matchLog <- rec:
+	default:
+		log.Printf("match record %s dropped: writer is behind", rec.ID)
+	}
 }
-if bc != nil {
-		hub.mu.Lock()
-				if s := hub.sessions[bc]; s != nil {
-						hub.mu.Unlock()
-								hub.send(b.ID, "Profile", s.Profile)
-				} else {
-						hub.mu.Unlock()
+
+// RunMatchLog writes the queued match records until stop is closed, then
+// writes what is still queued; start it once, after SetStore. The history
+// is indexed in memory, oldest first, so pruning does not rescan the bucket.
+func (h *Hub) RunMatchLog(stop <-chan struct{}) {
+	if h.st == nil {
+		return
+	}
+	type entry struct {
+		id string
+		at int64
+	}
+	var index []entry
+	err := h.st.ForEach(store.Matches, func(key string, val []byte) error {
+		var m store.MatchRecord
+		if json.Unmarshal(val, &m) == nil {
+			index = append(index, entry{key, m.At})
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("match record: %v", err)
+	}
+	sort.Slice(index, func(i, j int) bool { return index[i].at < index[j].at })
+
+	write := func(rec store.MatchRecord) {
+		if err := store.PutJSON(h.st, store.Matches, rec.ID, rec); err != nil {
+			log.Printf("match record: %v", err)
+			return
+		}
+		index = append(index, entry{rec.ID, rec.At})
+		for len(index) > matchHistoryKeep {
+			if err := h.st.Delete(store.Matches, index[0].id); err != nil {
+				log.Printf("match record: prune %s: %v", index[0].id, err)
+				break
+			}
+			index = index[1:]
+		}
+	}
+	for {
+		select {
+		case rec := <-h.matchLog:
+			write(rec)
+		case <-stop:
+			for {
+				select {
+				case rec := <-h.matchLog:
+					write(rec)
+				default:
+					return
 				}
+			}
+		}
+	}
 }
-}
-
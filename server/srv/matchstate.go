@@ -0,0 +1,453 @@
+package srv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"rumble/server/store"
+	"rumble/shared/protocol"
+)
+
+// Match persistence: a running match can be frozen into a versioned JSON
+// snapshot (the Game plus the Room around it). The hub checkpoints live
+// matches into data/matches/ and the next server process resumes them,
+// re-binding players by username as they log back in. A snapshot is also a
+// self-contained bug-report attachment (GET /admin/rooms?id=...).
+
+// matchStateVersion is bumped whenever the snapshot layout changes
+// incompatibly; older snapshots are refused rather than half-restored.
+const matchStateVersion = 1
+
+var matchesDir = filepath.Join(dataDir, "matches")
+
+// Defaults; the server config may change them (see Configure).
+var (
+	// resumeWait is how long a resumed match waits for its players before it
+	// carries on without the missing ones.
+	resumeWait = 2 * time.Minute
+)
+
+type matchState struct {
+	Version int       `json:"version"`
+	Server  string    `json:"server"`  // GameVersion of the server that saved it
+	SavedAt int64     `json:"savedAt"` // unix ms
+	Room    roomState `json:"room"`
+	Game    *Game     `json:"game"`
+}
+
+type roomState struct {
+	ID       string           `json:"id"`
+	Mode     string           `json:"mode"`
+	Tick     int              `json:"tick"`
+	Humans   map[string]int64 `json:"humans"` // username -> player ID
+	AIActive bool             `json:"aiActive,omitempty"`
+	AIID     int64            `json:"aiId,omitempty"`
+	AITimer  float64          `json:"aiTimer,omitempty"`
+	Departed []*Player        `json:"departed,omitempty"`
+
+	Survival   *survivalSave `json:"survival,omitempty"`
+	Tournament *tourneySave  `json:"tournament,omitempty"`
+	Lobby      *lobbySave    `json:"lobby,omitempty"`
+}
+
+type survivalSave struct {
+	Level    int     `json:"level"`
+	Wave     int     `json:"wave"`
+	NextWave float64 `json:"nextWave"`
+}
+
+type tourneySave struct {
+	ID    string `json:"id"`
+	Match int    `json:"match"`
+}
+
+type lobbySave struct {
+	HostID    int64  `json:"hostId"`
+	MapID     string `json:"mapId,omitempty"`
+	TimeLimit int    `json:"timeLimit,omitempty"`
+	Rules     string `json:"rules,omitempty"`
+	Played    int    `json:"played"`
+}
+
+// gameState is the serialized form of a Game. The card catalogue and the
+// event callback are not part of it: they come from the restoring server.
+type gameState struct {
+	Width       int                `json:"width"`
+	Height      int                `json:"height"`
+	MapDef      *protocol.MapDef   `json:"mapDef,omitempty"`
+	Rules       *protocol.Mutators `json:"rules,omitempty"`
+	Players     []*Player          `json:"players"`
+	Units       []*Unit            `json:"units"`
+	Projectiles []*Projectile      `json:"projectiles"`
+
+	TimerActive   bool    `json:"timerActive"`
+	TimeRemaining float64 `json:"timeRemaining"`
+	TimeLimit     int     `json:"timeLimit"`
+	Paused        bool    `json:"paused,omitempty"`
+	MatchEnded    bool    `json:"matchEnded,omitempty"`
+	TimerWinnerID int64   `json:"timerWinnerId,omitempty"`
+	Endless       bool    `json:"endless,omitempty"`
+}
+
+// MarshalJSON saves the whole simulation state, sorted by ID so two
+// snapshots of the same state are byte-identical.
+func (g *Game) MarshalJSON() ([]byte, error) {
+	st := gameState{
+		Width: g.width, Height: g.height, MapDef: g.mapDef,
+		Players:     make([]*Player, 0, len(g.players)),
+		Units:       make([]*Unit, 0, len(g.units)),
+		Projectiles: make([]*Projectile, 0, len(g.projectiles)),
+
+		TimerActive: g.timerActive, TimeRemaining: g.timeRemaining, TimeLimit: g.timeLimit,
+		Paused: g.isPaused, MatchEnded: g.matchEnded, TimerWinnerID: g.timerWinnerID, Endless: g.endless,
+	}
+	if g.hasRules {
+		rules := g.rules
+		st.Rules = &rules
+	}
+	for _, p := range g.players {
+		st.Players = append(st.Players, p)
+	}
+	for _, u := range g.units {
+		st.Units = append(st.Units, u)
+	}
+	for _, p := range g.projectiles {
+		st.Projectiles = append(st.Projectiles, p)
+	}
+	sort.Slice(st.Players, func(i, j int) bool { return st.Players[i].ID < st.Players[j].ID })
+	sort.Slice(st.Units, func(i, j int) bool { return st.Units[i].ID < st.Units[j].ID })
+	sort.Slice(st.Projectiles, func(i, j int) bool { return st.Projectiles[i].ID < st.Projectiles[j].ID })
+	return json.Marshal(st)
+}
+
+// UnmarshalJSON replaces the simulation state of g with a saved one.
+func (g *Game) UnmarshalJSON(b []byte) error {
+	var st gameState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return err
+	}
+	if st.Width <= 0 || st.Height <= 0 {
+		return fmt.Errorf("bad world size %dx%d", st.Width, st.Height)
+	}
+	g.width, g.height, g.mapDef = st.Width, st.Height, st.MapDef
+	g.rules, g.hasRules = protocol.Mutators{}, st.Rules != nil
+	if st.Rules != nil {
+		g.rules = *st.Rules
+	}
+	g.players = make(map[int64]*Player, len(st.Players))
+	for _, p := range st.Players {
+		g.players[p.ID] = p
+	}
+	g.units = make(map[int64]*Unit, len(st.Units))
+	for _, u := range st.Units {
+		g.units[u.ID] = u
+	}
+	g.projectiles = make(map[int64]*Projectile, len(st.Projectiles))
+	for _, p := range st.Projectiles {
+		g.projectiles[p.ID] = p
+	}
+	g.timerActive, g.timeRemaining, g.timeLimit = st.TimerActive, st.TimeRemaining, st.TimeLimit
+	g.isPaused, g.matchEnded, g.timerWinnerID, g.endless = st.Paused, st.MatchEnded, st.TimerWinnerID, st.Endless
+	if g.minis == nil {
+		g.loadMinis()
+	}
+	return nil
+}
+
+// maxID is the largest unit, projectile or player ID in g, so new IDs can
+// be kept clear of the restored ones.
+func (g *Game) maxID() int64 {
+	var top int64
+	for id := range g.players {
+		top = max(top, id)
+	}
+	for id := range g.units {
+		top = max(top, id)
+	}
+	for id := range g.projectiles {
+		top = max(top, id)
+	}
+	return top
+}
+
+// A boss is saved by its definition ID and looked up again on restore.
+type bossStateJSON struct {
+	Def       string  `json:"def"`
+	Phase     int     `json:"phase"`
+	BaseDMG   int     `json:"baseDmg"`
+	BaseSpeed float64 `json:"baseSpeed"`
+	BaseCD    float64 `json:"baseCd"`
+}
+
+func (b *bossState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bossStateJSON{Def: b.def.ID, Phase: b.phase, BaseDMG: b.baseDMG, BaseSpeed: b.baseSpeed, BaseCD: b.baseCD})
+}
+
+func (b *bossState) UnmarshalJSON(data []byte) error {
+	var st bossStateJSON
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	def := loadBossDefs()[strings.ToLower(st.Def)]
+	if def == nil {
+		return fmt.Errorf("unknown boss %q", st.Def)
+	}
+	*b = bossState{def: def, phase: st.Phase, baseDMG: st.BaseDMG, baseSpeed: st.BaseSpeed, baseCD: st.BaseCD}
+	return nil
+}
+
+// resumeState tracks a restored match until its players are back.
+type resumeState struct {
+	waiting map[string]int64 // username -> player ID, not reconnected yet
+	until   time.Time
+}
+
+// encodeMatch snapshots the room. Runs on the Run goroutine with h.mu held.
+func (r *Room) encodeMatch() ([]byte, error) {
+	ms := matchState{
+		Version: matchStateVersion,
+		Server:  protocol.GameVersion,
+		SavedAt: time.Now().UnixMilli(),
+		Game:    r.g,
+	}
+	rs := &ms.Room
+	rs.ID, rs.Mode, rs.Tick = r.id, r.Mode, r.tick
+	rs.AIActive, rs.AIID, rs.AITimer = r.aiActive, r.aiID, r.aiTimer
+	rs.Humans = map[string]int64{}
+	for _, c := range r.players {
+		rs.Humans[c.name] = c.id
+	}
+	if r.resume != nil {
+		for name, id := range r.resume.waiting {
+			rs.Humans[name] = id
+		}
+	}
+	rs.Departed = r.departedPlayers()
+	sort.Slice(rs.Departed, func(i, j int) bool { return rs.Departed[i].ID < rs.Departed[j].ID })
+	if s := r.surv; s != nil {
+		rs.Survival = &survivalSave{Level: s.level, Wave: s.wave, NextWave: s.nextWave}
+	}
+	if t := r.tourney; t != nil {
+		rs.Tournament = &tourneySave{ID: t.id, Match: t.match}
+	}
+	if l := r.lobby; l != nil {
+		rs.Lobby = &lobbySave{HostID: l.hostID, MapID: l.mapID, TimeLimit: l.timeLimit, Rules: l.rules, Played: l.played}
+	}
+	return json.MarshalIndent(ms, "", "  ")
+}
+
+// decodeMatch rebuilds a room from a snapshot. It has no clients yet: the
+// match waits in r.resume until they reconnect.
+func (h *Hub) decodeMatch(b []byte) (*Room, error) {
+	var head struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(b, &head); err != nil {
+		return nil, err
+	}
+	if head.Version != matchStateVersion {
+		return nil, fmt.Errorf("snapshot version %d, want %d", head.Version, matchStateVersion)
+	}
+	r := NewRoom("", h)
+	ms := matchState{Game: r.g}
+	if err := json.Unmarshal(b, &ms); err != nil {
+		return nil, err
+	}
+	rs := ms.Room
+	if rs.ID == "" || strings.ContainsAny(rs.ID, `/\`) || len(rs.Humans) == 0 {
+		return nil, errors.New("snapshot has no room ID or players")
+	}
+	r.id, r.Mode, r.tick = rs.ID, rs.Mode, rs.Tick
+	r.aiActive, r.aiID, r.aiTimer = rs.AIActive, rs.AIID, rs.AITimer
+	for _, p := range rs.Departed {
+		r.addDeparted(p)
+	}
+	if s := rs.Survival; s != nil {
+		r.surv = &survivalRun{level: s.Level, wave: s.Wave, nextWave: s.NextWave}
+	}
+	if t := rs.Tournament; t != nil {
+		r.tourney = &tourneyRef{id: t.ID, match: t.Match}
+	}
+	if l := rs.Lobby; l != nil {
+		r.lobby = &friendlyLobby{hostID: l.HostID, ready: map[int64]bool{}, mapID: l.MapID,
+			timeLimit: l.TimeLimit, rules: l.Rules, played: l.Played}
+	}
+	r.resume = &resumeState{waiting: rs.Humans, until: time.Now().Add(resumeWait)}
+	r.checkpointed = true
+	protocol.ReserveIDs(r.g.maxID())
+	return r, nil
+}
+
+func matchPath(roomID string) string { return filepath.Join(matchesDir, roomID+".json") }
+
+// checkpointMatches saves every running or resuming match. Runs on the Run
+// goroutine, so the rooms cannot change underneath it.
+func (h *Hub) checkpointMatches() (saved int, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.rooms {
+		if !r.active && r.resume == nil {
+			continue
+		}
+		if e := r.saveCheckpoint(); e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+		saved++
+	}
+	return saved, err
+}
+
+func (r *Room) saveCheckpoint() error {
+	b, err := r.encodeMatch()
+	if err == nil {
+		if err = os.MkdirAll(matchesDir, 0o755); err == nil {
+			err = store.WriteFile(matchPath(r.id), b, 0o644)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("checkpoint room %s: %w", r.id, err)
+	}
+	r.checkpointed = true
+	return nil
+}
+
+// dropCheckpoint forgets the saved copy of a match that is over, so a
+// later restart does not replay it.
+func (r *Room) dropCheckpoint() {
+	if err := os.Remove(matchPath(r.id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("checkpoint room %s: %v", r.id, err)
+	}
+	r.checkpointed = false
+}
+
+// RunCheckpoints saves the running matches every interval until stop is
+// closed; start it once.
+func (h *Hub) RunCheckpoints(every time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		var err error
+		h.onLoop(func() { _, err = h.checkpointMatches() })
+		if err != nil {
+			log.Printf("checkpoint: %v", err)
+		}
+	}
+}
+
+// ResumeMatches restores the matches a previous server process saved. Call
+// it once at startup, before clients connect. Snapshots that cannot be
+// restored are renamed to *.bad and kept for inspection.
+func (h *Hub) ResumeMatches() int {
+	paths, _ := filepath.Glob(filepath.Join(matchesDir, "*.json"))
+	n := 0
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		var r *Room
+		if err == nil {
+			r, err = h.decodeMatch(b)
+		}
+		if err == nil && filepath.Base(p) != r.id+".json" {
+			err = fmt.Errorf("file name does not match room %s", r.id)
+		}
+		if err != nil {
+			log.Printf("resume %s: %v", p, err)
+			_ = os.Rename(p, p+".bad")
+			continue
+		}
+		h.mu.Lock()
+		h.rooms[r.id] = r
+		h.mu.Unlock()
+		log.Printf("resume: room %s (%s), waiting for %d player(s)", r.id, r.Mode, len(r.resume.waiting))
+		n++
+	}
+	return n
+}
+
+// rejoinResumedMatch puts a player who just logged in back into the
+// resumed match waiting for them, if there is one.
+func (h *Hub) rejoinResumedMatch(c *client) {
+	h.onLoop(func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		s := h.sessions[c]
+		if s == nil || c.room != nil {
+			return
+		}
+		for _, r := range h.rooms {
+			if r.resume == nil {
+				continue
+			}
+			id, ok := r.resume.waiting[s.Name]
+			if !ok {
+				continue
+			}
+			delete(r.resume.waiting, s.Name)
+			// The match knows the player by the ID of the earlier session
+			s.PlayerID, s.Profile.PlayerID, s.RoomID = id, id, r.id
+			c.id, c.name, c.room = id, s.Name, r
+			r.players = append(r.players, c)
+			sendJSON(c, "Profile", s.Profile)
+			sendJSON(c, "RoomCreated", protocol.RoomCreated{RoomID: r.id})
+			r.sendBattleStart(c)
+			log.Printf("resume: %s is back in room %s", s.Name, r.id)
+			if len(r.resume.waiting) == 0 {
+				r.resumeNow()
+			} else {
+				sendJSON(c, "Announcement", protocol.Announcement{
+					Text:      "Your match resumes when everyone is back",
+					ExpiresAt: r.resume.until.UnixMilli(),
+				})
+			}
+			return
+		}
+	})
+}
+
+// resumeNow restarts the clock of a restored match; players still missing
+// count as having left. Runs on the Run goroutine with h.mu held.
+func (r *Room) resumeNow() {
+	for name, id := range r.resume.waiting {
+		if pl := r.g.players[id]; pl != nil && r.resolvesAbandonment() {
+			r.addDeparted(pl)
+		}
+		r.g.RemovePlayer(id)
+		log.Printf("resume: %s did not come back to room %s", name, r.id)
+	}
+	r.resume = nil
+	if len(r.players) == 0 {
+		return // pruned by Run, which also drops the checkpoint
+	}
+	r.active = true
+	r.lastSnap = time.Now()
+	for _, c := range r.players {
+		sendJSON(c, "Announcement", protocol.Announcement{Text: "Match resumed", ExpiresAt: time.Now().Add(3 * time.Second).UnixMilli()})
+	}
+}
+
+// MatchSnapshot returns the snapshot of a live room, e.g. to attach to a
+// bug report.
+func (h *Hub) MatchSnapshot(roomID string) ([]byte, error) {
+	var b []byte
+	err := ErrNoRoom
+	h.onLoop(func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if r := h.rooms[roomID]; r != nil {
+			b, err = r.encodeMatch()
+		}
+	})
+	return b, err
+}
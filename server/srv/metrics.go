@@ -0,0 +1,66 @@
+package srv
+
+import (
+	"time"
+
+	"rumble/server/metrics"
+)
+
+// Server metrics, served at /metrics (see package metrics).
+var (
+	mMatchesStarted = metrics.NewCounterVec("rumble_matches_started_total",
+		"Battles started, by room mode.", "mode")
+	mMatchesFinished = metrics.NewCounterVec("rumble_matches_finished_total",
+		"Battles that reached a result, by room mode.", "mode")
+	mTickSeconds = metrics.NewHistogramVec("rumble_room_tick_seconds",
+		"Time one room tick took, by room mode.", metrics.ExpBuckets(0.0001, 2, 12), "mode")
+	mMsgsIn = metrics.NewCounterVec("rumble_ws_messages_in_total",
+		"WebSocket messages received, by envelope type.", "type")
+	mMsgsOut = metrics.NewCounterVec("rumble_ws_messages_out_total",
+		"WebSocket messages queued for sending, by envelope type.", "type")
+	mMsgsDropped = metrics.NewCounterVec("rumble_ws_messages_dropped_total",
+		"Messages dropped because the client's send buffer was full, by envelope type.", "type")
+	mProfileSaveSeconds = metrics.NewHistogramVec("rumble_profile_save_seconds",
+		"Time to write one profile to the store.", metrics.ExpBuckets(0.0005, 2, 12))
+	mProfileSaveErrors = metrics.NewCounterVec("rumble_profile_save_errors_total",
+		"Profile writes to the store that failed.")
+)
+
+// registerMetrics adds the gauges read from the hub at scrape time.
+func (h *Hub) registerMetrics() {
+	metrics.NewGaugeFunc("rumble_clients_connected", "Open WebSocket connections.", func() map[string]float64 {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return map[string]float64{"": float64(len(h.clients))}
+	})
+	metrics.NewGaugeFunc("rumble_rooms_active", "Rooms with a running battle, by mode.", func() map[string]float64 {
+		// Run recounts these after every tick; r.active is its to read.
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		out := map[string]float64{}
+		for mode, n := range h.roomsActive {
+			out[mode] = float64(n)
+		}
+		return out
+	}, "mode")
+	metrics.NewGaugeFunc("rumble_queue_length", "Players waiting in each matchmaking queue.", func() map[string]float64 {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return map[string]float64{
+			"pvp":   float64(len(h.pvpQueue)),
+			"team":  float64(len(h.teamQueue)),
+			"draft": float64(len(h.draftQueue)),
+		}
+	}, "queue")
+}
+
+// tickRoom runs one room tick, timing it when the battle is running.
+func tickRoom(r *Room) {
+	if !r.active {
+		r.Tick()
+		return
+	}
+	start := time.Now()
+	r.Tick()
+	mTickSeconds.Observe(time.Since(start).Seconds(), r.Mode)
+}
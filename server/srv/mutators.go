@@ -0,0 +1,250 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+var mutatorsPath = filepath.Join(dataDir, "mutators.json")
+
+// challengeMap is the arena the weekly challenge is played on.
+const challengeMap = "colosseum"
+
+// loadMutatorPresets reads data/mutators.json, falling back to a built-in
+// list. Like maps and the campaign, it is read fresh on every call.
+func loadMutatorPresets() []protocol.Mutators {
+	if b, err := os.ReadFile(mutatorsPath); err == nil {
+		var defs []protocol.Mutators
+		if err := json.Unmarshal(b, &defs); err == nil && len(defs) > 0 {
+			for i := range defs {
+				defs[i] = sanitizeMutators(defs[i])
+			}
+			return defs
+		}
+		log.Printf("mutators: bad %s, using defaults", mutatorsPath)
+	}
+	return []protocol.Mutators{
+		{ID: "gold_rush", Name: "Gold Rush", Description: "Double gold income, higher cap", GoldTickSec: 0.5, GoldMax: 15, StartGold: 8},
+		{ID: "glass_cannon", Name: "Glass Cannon", Description: "Units hit twice as hard, bases are fragile", DamageMult: 2, BaseHP: 1500},
+		{ID: "blitz", Name: "Blitz", Description: "Everything moves fast", SpeedMult: 1.6, GoldTickSec: 0.75},
+		{ID: "steel_only", Name: "Steel Only", Description: "Melee units only", AllowedClasses: []string{"melee"}},
+		{ID: "long_siege", Name: "Long Siege", Description: "Fortified bases, slow gold", BaseHP: 6000, GoldTickSec: 1.5},
+		{ID: "fog_of_war", Name: "Fog of War", Description: "You only see what your units and bases see", FogOfWar: true},
+	}
+}
+
+func findMutatorPreset(id string) *protocol.Mutators {
+	for _, m := range loadMutatorPresets() {
+		if strings.EqualFold(m.ID, id) {
+			return &m
+		}
+	}
+	return nil
+}
+
+// sanitizeMutators clamps a ruleset to values the sim can handle.
+func sanitizeMutators(m protocol.Mutators) protocol.Mutators {
+	clampF := func(v, lo, hi float64) float64 {
+		if v == 0 {
+			return 0
+		}
+		return min(max(v, lo), hi)
+	}
+	clampI := func(v, lo, hi int) int {
+		if v == 0 {
+			return 0
+		}
+		return min(max(v, lo), hi)
+	}
+	m.GoldTickSec = clampF(m.GoldTickSec, 0.2, 5)
+	m.GoldMax = clampI(m.GoldMax, 1, 30)
+	m.StartGold = clampI(m.StartGold, 1, 30)
+	m.BaseHP = clampI(m.BaseHP, 100, 50000)
+	m.SpeedMult = clampF(m.SpeedMult, 0.25, 4)
+	m.DamageMult = clampF(m.DamageMult, 0.1, 10)
+	if len(m.FixedArmy) != 7 {
+		m.FixedArmy = nil
+	}
+	return m
+}
+
+// isoWeek returns the ISO week key, e.g. "2026-W42".
+func isoWeek(t time.Time) string {
+	y, w := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", y, w)
+}
+
+// weeklyChallenge rotates through the presets, one per ISO week.
+func weeklyChallenge(now time.Time) (string, protocol.Mutators) {
+	presets := loadMutatorPresets()
+	y, w := now.ISOWeek()
+	return isoWeek(now), presets[(y*53+w)%len(presets)]
+}
+
+func buildMutatorList() protocol.MutatorList {
+	week, weekly := weeklyChallenge(time.Now())
+	return protocol.MutatorList{Presets: loadMutatorPresets(), Weekly: weekly, Week: week}
+}
+
+// ---- Game accessors: the mutator value when set, otherwise the regular rule
+
+// SetRules attaches a ruleset. Call before players join: base HP, starting
+// gold and fixed armies are applied in AddPlayerOnTeam.
+func (g *Game) SetRules(m protocol.Mutators) {
+	g.rules = sanitizeMutators(m)
+	g.hasRules = true
+}
+
+func (g *Game) goldTickSec() float64 {
+	if g.rules.GoldTickSec > 0 {
+		return g.rules.GoldTickSec
+	}
+	return protocol.GoldTickSec
+}
+
+func (g *Game) goldMax() int {
+	if g.rules.GoldMax > 0 {
+		return g.rules.GoldMax
+	}
+	return protocol.GoldMax
+}
+
+func (g *Game) startGold() int {
+	if g.rules.StartGold > 0 {
+		return g.rules.StartGold
+	}
+	return 4
+}
+
+func (g *Game) baseHP() int {
+	if g.rules.BaseHP > 0 {
+		return g.rules.BaseHP
+	}
+	return 3000
+}
+
+func (g *Game) classAllowed(class string) bool {
+	if len(g.rules.AllowedClasses) == 0 {
+		return true
+	}
+	for _, c := range g.rules.AllowedClasses {
+		if strings.EqualFold(c, class) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyClassRule swaps cards of disallowed classes in p's deck for allowed
+// ones of the same role, so every hand stays playable.
+func (g *Game) applyClassRule(p *Player) {
+	if len(g.rules.AllowedClasses) == 0 {
+		return
+	}
+	inDeck := map[string]bool{}
+	deck := append(append([]MiniCard{}, p.Hand...), p.Queue...)
+	for _, c := range deck {
+		inDeck[strings.ToLower(c.Name)] = true
+	}
+	replace := func(c MiniCard) MiniCard {
+		if g.classAllowed(c.Class) {
+			return c
+		}
+		var opts []MiniCard
+		for _, m := range g.minis {
+			if strings.EqualFold(m.Role, c.Role) && !strings.EqualFold(m.Class, "spell") && g.classAllowed(m.Class) && !inDeck[strings.ToLower(m.Name)] {
+				opts = append(opts, m)
+			}
+		}
+		if len(opts) == 0 {
+			return c
+		}
+		pick := opts[rand.Intn(len(opts))]
+		inDeck[strings.ToLower(pick.Name)] = true
+		return pick
+	}
+	for i := range p.Hand {
+		p.Hand[i] = replace(p.Hand[i])
+	}
+	for i := range p.Queue {
+		p.Queue[i] = replace(p.Queue[i])
+	}
+	if len(p.Queue) > 0 {
+		nx := p.Queue[0]
+		p.Next = &nx
+	}
+}
+
+// ---- Weekly challenge
+
+// recordChallengeResult stores the player's fastest win of this week's
+// challenge and sends the result.
+func (r *Room) recordChallengeResult(winnerID int64) {
+	if r.hub == nil {
+		return
+	}
+	seconds := r.g.elapsedSeconds()
+	week := isoWeek(time.Now())
+	for _, c := range r.players {
+		if r.aiActive && c.id == r.aiID {
+			continue
+		}
+		res := protocol.ChallengeResult{Won: r.g.SameTeam(c.id, winnerID), Seconds: seconds}
+		r.hub.mu.Lock()
+		if s := r.hub.sessions[c]; s != nil {
+			best := s.Profile.WeeklyBest
+			if best != nil && best.Week != week {
+				best = nil
+			}
+			if res.Won && (best == nil || seconds < best.Seconds) {
+				best = &protocol.ChallengeRecord{Week: week, Seconds: seconds, At: time.Now().UnixMilli()}
+				s.Profile.WeeklyBest = best
+				res.NewBest = true
+				if err := r.hub.profiles.Save(s.Profile); err != nil {
+					log.Printf("profiles.Save(challenge): %v", err)
+				}
+			}
+			res.Best = best
+		}
+		r.hub.mu.Unlock()
+		sendJSON(c, "ChallengeResult", res)
+	}
+}
+
+// buildChallengeLeaderboardTop50 ranks this week's fastest clears.
+func (h *Hub) buildChallengeLeaderboardTop50() protocol.ChallengeLeaderboard {
+	week, rules := weeklyChallenge(time.Now())
+	entries := []protocol.ChallengeLeaderboardEntry{}
+	h.profiles.ForEach(func(name string, prof protocol.Profile) {
+		if prof.WeeklyBest == nil || prof.WeeklyBest.Week != week {
+			return
+		}
+		entries = append(entries, protocol.ChallengeLeaderboardEntry{Name: name, Seconds: prof.WeeklyBest.Seconds})
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Seconds != entries[j].Seconds {
+			return entries[i].Seconds < entries[j].Seconds
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	if len(entries) > 50 {
+		entries = entries[:50]
+	}
+
+	return protocol.ChallengeLeaderboard{
+		Week:        week,
+		Rules:       rules,
+		Items:       entries,
+		GeneratedAt: time.Now().UnixMilli(),
+	}
+}
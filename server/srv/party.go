@@ -0,0 +1,464 @@
+package srv
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+const (
+	partyMaxSize   = 4
+	partyInviteTTL = time.Minute
+	// partyLeaderGrace is how long a party waits for its leader to reconnect.
+	partyLeaderGrace = time.Minute
+)
+
+// party is a group of players queueing or watching together. Members are
+// kept by profile name so the leader can reconnect on a new connection.
+// Guarded by hub.mu.
+type party struct {
+	leader    string               // profile name (as typed at login)
+	members   []string             // leader first
+	invites   map[string]time.Time // invitee (lower case) -> sent at
+	queue     string               // "" | "team" | "watch"
+	awayUntil time.Time            // leader offline: the party dissolves at this time
+	awayTimer *time.Timer
+}
+
+func (h *Hub) sessionNameLocked(c *client) string {
+	if s := h.sessions[c]; s != nil && s.Profile.Name != "" {
+		return s.Profile.Name
+	}
+	return ""
+}
+
+// partyOfLocked returns the party c belongs to. h.mu must be held.
+func (h *Hub) partyOfLocked(c *client) *party {
+	name := h.sessionNameLocked(c)
+	if name == "" {
+		return nil
+	}
+	return h.parties[strings.ToLower(name)]
+}
+
+func (h *Hub) partyStateLocked(p *party) protocol.PartyState {
+	st := protocol.PartyState{Leader: p.leader, Queue: p.queue, Members: []protocol.PartyMember{}}
+	for _, m := range p.members {
+		st.Members = append(st.Members, protocol.PartyMember{Name: m, Online: h.clientByNameLocked(m) != nil})
+	}
+	for name, at := range p.invites {
+		if time.Since(at) <= partyInviteTTL {
+			st.Invited = append(st.Invited, name)
+		}
+	}
+	if !p.awayUntil.IsZero() {
+		st.LeaderGrace = int(time.Until(p.awayUntil).Seconds())
+	}
+	return st
+}
+
+func (h *Hub) broadcastPartyLocked(p *party) {
+	st := h.partyStateLocked(p)
+	for _, m := range p.members {
+		if c := h.clientByNameLocked(m); c != nil {
+			sendJSON(c, "PartyState", st)
+		}
+	}
+}
+
+// dissolvePartyLocked breaks p up and tells the members still online.
+func (h *Hub) dissolvePartyLocked(p *party, reason string) {
+	h.cancelPartyQueueLocked(p)
+	if p.awayTimer != nil {
+		p.awayTimer.Stop()
+	}
+	for _, m := range p.members {
+		delete(h.parties, strings.ToLower(m))
+		if c := h.clientByNameLocked(m); c != nil {
+			sendJSON(c, "PartyClosed", protocol.PartyClosed{Reason: reason})
+		}
+	}
+	log.Printf("party of %s dissolved: %s", p.leader, reason)
+}
+
+// PartyCreate starts a party led by c (re-sends it if c is already in one).
+func (h *Hub) PartyCreate(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	name := h.sessionNameLocked(c)
+	if name == "" {
+		return
+	}
+	if p := h.parties[strings.ToLower(name)]; p != nil {
+		sendJSON(c, "PartyState", h.partyStateLocked(p))
+		return
+	}
+	p := &party{leader: name, members: []string{name}, invites: map[string]time.Time{}}
+	h.parties[strings.ToLower(name)] = p
+	sendJSON(c, "PartyState", h.partyStateLocked(p))
+}
+
+// PartyInvite lets the leader invite an online friend or guildmate.
+func (h *Hub) PartyInvite(c *client, target string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p := h.partyOfLocked(c)
+	if p == nil || !strings.EqualFold(p.leader, h.sessionNameLocked(c)) {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Only the party leader can invite"})
+		return
+	}
+	tc := h.clientByNameLocked(target)
+	if tc == nil {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: target + " is offline"})
+		return
+	}
+	if tc == c {
+		return
+	}
+	if h.duelRelationLocked(h.sessions[c], h.sessions[tc]) == "" {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "You can only invite friends and guildmates to a party"})
+		return
+	}
+	if h.partyOfLocked(tc) != nil {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: target + " is already in a party"})
+		return
+	}
+	if len(p.members) >= partyMaxSize {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "The party is full"})
+		return
+	}
+	p.invites[strings.ToLower(target)] = time.Now()
+	sendJSON(tc, "PartyInvited", protocol.PartyInvited{Leader: p.leader, ExpiresIn: int(partyInviteTTL / time.Second)})
+	h.broadcastPartyLocked(p)
+}
+
+// PartyAccept joins the party of leader if c holds a live invite.
+func (h *Hub) PartyAccept(c *client, leader string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	name := h.sessionNameLocked(c)
+	p := h.parties[strings.ToLower(leader)]
+	if name == "" || p == nil {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Invite expired"})
+		return
+	}
+	at, ok := p.invites[strings.ToLower(name)]
+	delete(p.invites, strings.ToLower(name))
+	if !ok || time.Since(at) > partyInviteTTL {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Invite expired"})
+		return
+	}
+	if h.parties[strings.ToLower(name)] != nil {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Leave your party first"})
+		return
+	}
+	if len(p.members) >= partyMaxSize {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "The party is full"})
+		return
+	}
+	h.cancelPartyQueueLocked(p)
+	p.members = append(p.members, name)
+	h.parties[strings.ToLower(name)] = p
+	h.broadcastPartyLocked(p)
+}
+
+// PartyDecline drops the invite and lets the leader know.
+func (h *Hub) PartyDecline(c *client, leader string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	name := h.sessionNameLocked(c)
+	p := h.parties[strings.ToLower(leader)]
+	if name == "" || p == nil {
+		return
+	}
+	delete(p.invites, strings.ToLower(name))
+	if lc := h.clientByNameLocked(p.leader); lc != nil {
+		sendJSON(lc, "PartyDeclined", protocol.PartyDeclined{By: name})
+	}
+	h.broadcastPartyLocked(p)
+}
+
+// PartyLeave takes c out of its party; the party ends when the leader leaves.
+func (h *Hub) PartyLeave(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	name := h.sessionNameLocked(c)
+	p := h.partyOfLocked(c)
+	if p == nil {
+		return
+	}
+	sendJSON(c, "PartyClosed", protocol.PartyClosed{})
+	h.leavePartyLocked(p, name, "The leader left the party")
+}
+
+func (h *Hub) leavePartyLocked(p *party, name, reason string) {
+	if strings.EqualFold(p.leader, name) {
+		h.dissolvePartyLocked(p, reason)
+		return
+	}
+	h.cancelPartyQueueLocked(p)
+	for i, m := range p.members {
+		if strings.EqualFold(m, name) {
+			p.members = append(p.members[:i], p.members[i+1:]...)
+			break
+		}
+	}
+	delete(h.parties, strings.ToLower(name))
+	h.broadcastPartyLocked(p)
+}
+
+// ---- Queueing
+
+// PartyQueue is the leader starting (or, with Mode "", cancelling) a
+// party activity.
+func (h *Hub) PartyQueue(c *client, m protocol.PartyQueue) {
+	h.mu.Lock()
+	p := h.partyOfLocked(c)
+	if p == nil || !strings.EqualFold(p.leader, h.sessionNameLocked(c)) {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Only the party leader can queue"})
+		return
+	}
+	var rooms []*Room
+	switch m.Mode {
+	case "":
+		h.cancelPartyQueueLocked(p)
+	case "watch":
+		if p.queue == "watch" {
+			p.queue = ""
+		} else {
+			h.cancelPartyQueueLocked(p)
+			p.queue = "watch"
+		}
+	case "team", "coop":
+		pair, msg := h.partyPairLocked(p)
+		if msg != "" {
+			h.mu.Unlock()
+			sendJSON(c, "Error", protocol.ErrorMsg{Message: msg})
+			return
+		}
+		if m.Mode == "coop" {
+			mapID, _, msg := coopMapFor(h.sessions[pair[0]].Profile, m.MapID)
+			if msg != "" {
+				h.mu.Unlock()
+				sendJSON(c, "Error", protocol.ErrorMsg{Message: msg})
+				return
+			}
+			h.cancelPartyQueueLocked(p)
+			for _, x := range pair {
+				h.leaveIdleRoomLocked(x)
+			}
+			rooms = append(rooms, h.startCoopRoomLocked(pair[0], pair[1], mapID))
+			break
+		}
+		for _, x := range pair {
+			if h.queueBlockedLocked(x) {
+				name := h.sessionNameLocked(x)
+				h.mu.Unlock()
+				sendJSON(c, "Error", protocol.ErrorMsg{Message: name + " has a leaver cooldown"})
+				return
+			}
+		}
+		h.cancelPartyQueueLocked(p)
+		for _, x := range pair {
+			h.leaveTeamLobbyLocked(x)
+			h.removeFromTeamQueueLocked(x)
+		}
+		p.queue = "team"
+		h.teamQueue = append(h.teamQueue, pair...)
+		rooms = h.matchTeamQueueLocked()
+		h.sendTeamQueueStatusLocked()
+	default:
+		h.mu.Unlock()
+		return
+	}
+	h.broadcastPartyLocked(p)
+	h.mu.Unlock()
+
+	for _, r := range rooms {
+		launchRoom(r)
+	}
+}
+
+// partyPairLocked returns the two members of a party that is ready to queue
+// as a pair, or a message saying why it is not.
+func (h *Hub) partyPairLocked(p *party) ([]*client, string) {
+	if len(p.members) != 2 {
+		return nil, "This mode needs a party of exactly 2"
+	}
+	var pair []*client
+	for _, m := range p.members {
+		c := h.clientByNameLocked(m)
+		if c == nil {
+			return nil, m + " is offline"
+		}
+		if (c.room != nil && c.room.active) || h.drafts[c] != nil {
+			return nil, m + " is already in a battle"
+		}
+		pair = append(pair, c)
+	}
+	return pair, ""
+}
+
+// cancelPartyQueueLocked stops whatever the party queued for.
+func (h *Hub) cancelPartyQueueLocked(p *party) {
+	if p.queue == "team" {
+		removed := false
+		for _, m := range p.members {
+			if c := h.clientByNameLocked(m); c != nil && h.removeFromTeamQueueLocked(c) {
+				sendJSON(c, "TeamQueueStatus", protocol.TeamQueueStatus{})
+				removed = true
+			}
+		}
+		if removed {
+			h.sendTeamQueueStatusLocked()
+		}
+	}
+	p.queue = ""
+}
+
+// queuedPartyMateLocked returns the party partner queueing for 2v2 with c.
+func (h *Hub) queuedPartyMateLocked(c *client) *client {
+	p := h.partyOfLocked(c)
+	if p == nil || p.queue != "team" {
+		return nil
+	}
+	for _, m := range p.members {
+		if mc := h.clientByNameLocked(m); mc != nil && mc != c {
+			for _, x := range h.teamQueue {
+				if x == mc {
+					return mc
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ---- Watch party
+
+// attachWatchersLocked seats idle members of a watch party as spectators of
+// r when one of them is playing in it. Called from Run for rooms that just
+// started. h.mu must be held.
+func (h *Hub) attachWatchersLocked(r *Room) {
+	for _, pc := range r.players {
+		p := h.partyOfLocked(pc)
+		if p == nil || p.queue != "watch" {
+			continue
+		}
+		watched := h.sessionNameLocked(pc)
+		for _, m := range p.members {
+			c := h.clientByNameLocked(m)
+			if c == nil || c.room != nil || c.watching != nil || h.drafts[c] != nil {
+				continue
+			}
+			c.watching = r
+			r.addWatcher(c, pc.id, watched)
+		}
+	}
+}
+
+func (r *Room) addWatcher(c *client, watchedID int64, watched string) {
+	sendJSON(c, "SpectateStart", protocol.SpectateStart{RoomID: r.id, Watching: watched})
+	sendJSON(c, "Init", r.g.InitFor(watchedID))
+	if r.g.mapDef != nil {
+		sendJSON(c, "MapDef", protocol.MapDefMsg{Def: *r.g.mapDef})
+	}
+	r.watchMu.Lock()
+	if r.watchTeams == nil {
+		r.watchTeams = map[*client]int{}
+	}
+	r.watchTeams[c] = r.g.teamOf(watchedID)
+	r.watchMu.Unlock()
+	sendJSON(c, "FullSnapshot", r.snapshotFor(c))
+	r.watchMu.Lock()
+	r.watchers = append(r.watchers, c)
+	r.watchMu.Unlock()
+}
+
+func (r *Room) removeWatcher(c *client) {
+	r.fogMu.Lock()
+	delete(r.fogSeen, c)
+	r.fogMu.Unlock()
+	r.watchMu.Lock()
+	defer r.watchMu.Unlock()
+	delete(r.watchTeams, c)
+	for i, x := range r.watchers {
+		if x == c {
+			r.watchers = append(r.watchers[:i], r.watchers[i+1:]...)
+			return
+		}
+	}
+}
+
+// sendWatchers forwards a battle message to the room's spectators.
+func (r *Room) sendWatchers(typ string, v interface{}) {
+	r.eachWatcher(func(c *client) { sendJSON(c, typ, v) })
+}
+
+// eachWatcher calls fn for every spectator, outside watchMu.
+func (r *Room) eachWatcher(fn func(c *client)) {
+	r.watchMu.Lock()
+	watchers := append([]*client(nil), r.watchers...)
+	r.watchMu.Unlock()
+	for _, c := range watchers {
+		fn(c)
+	}
+}
+
+// stopWatchingLocked detaches c from the battle it spectates. h.mu must be held.
+func (h *Hub) stopWatchingLocked(c *client) {
+	if c.watching != nil {
+		c.watching.removeWatcher(c)
+		c.watching = nil
+	}
+}
+
+// ---- Connection lifecycle
+
+// dropPartyLocked runs when name's connection goes away. A member simply
+// leaves; the leader gets partyLeaderGrace to come back before the party
+// dissolves. h.mu must be held.
+func (h *Hub) dropPartyLocked(name string) {
+	if name == "" || h.clientByNameLocked(name) != nil {
+		return // still online on another connection
+	}
+	p := h.parties[strings.ToLower(name)]
+	if p == nil {
+		return
+	}
+	if !strings.EqualFold(p.leader, name) {
+		h.leavePartyLocked(p, name, "")
+		return
+	}
+	if p.awayTimer != nil {
+		return
+	}
+	h.cancelPartyQueueLocked(p)
+	p.awayUntil = time.Now().Add(partyLeaderGrace)
+	p.awayTimer = time.AfterFunc(partyLeaderGrace, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.parties[strings.ToLower(p.leader)] == p && h.clientByNameLocked(p.leader) == nil {
+			h.dissolvePartyLocked(p, "The leader disconnected")
+		}
+	})
+	h.broadcastPartyLocked(p)
+}
+
+// resumePartyLocked re-sends the party to a member who just logged in and
+// cancels the leader's grace timer. h.mu must be held.
+func (h *Hub) resumePartyLocked(c *client) {
+	p := h.partyOfLocked(c)
+	if p == nil {
+		return
+	}
+	if strings.EqualFold(p.leader, h.sessionNameLocked(c)) && p.awayTimer != nil {
+		p.awayTimer.Stop()
+		p.awayTimer = nil
+		p.awayUntil = time.Time{}
+	}
+	h.broadcastPartyLocked(p)
+}
@@ -0,0 +1,310 @@
+package srv
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"rumble/server/store"
+	"rumble/shared/protocol"
+)
+
+// ProfileService is the one place profiles are loaded, defaulted, upgraded
+// and saved. Saves land in an in-memory cache and are written to the store
+// in the background (write-behind); Flush forces them out.
+type ProfileService struct {
+	st      store.Store
+	mu      sync.Mutex
+	cache   map[string]*cachedProfile // by account ID
+	flushMu sync.Mutex                // one Flush at a time, so writes stay ordered
+}
+
+type cachedProfile struct {
+	data  []byte    // JSON as last saved or loaded; decoded fresh on every Load
+	gen   int       // bumped by every Save
+	saved int       // gen that reached the store
+	used  time.Time // last Load or Save, for eviction
+}
+
+const (
+	profileFlushEvery = 2 * time.Second
+	profileCacheTTL   = 10 * time.Minute // clean entries unused this long are dropped
+)
+
+// profileSchemaVersion is the Profile.SchemaVersion written by this server.
+var profileSchemaVersion = len(profileUpgrades)
+
+// profileUpgrades[i] upgrades a profile from schema version i to i+1.
+var profileUpgrades = []func(p *protocol.Profile){
+	// 0 -> 1: older profiles only kept the active army; file it under its
+	// champion, and derive the rank from the rating instead of trusting the
+	// stored one.
+	func(p *protocol.Profile) {
+		if len(p.Army) == 7 {
+			if p.Armies == nil {
+				p.Armies = map[string][]string{}
+			}
+			if _, ok := p.Armies[p.Army[0]]; !ok {
+				p.Armies[p.Army[0]] = append([]string{}, p.Army[1:]...)
+			}
+		}
+		if p.PvPRating > 0 {
+			p.PvPRank = rankName(p.PvPRating)
+		}
+	},
+}
+
+func NewProfileService(st store.Store) *ProfileService {
+	return &ProfileService{st: st, cache: map[string]*cachedProfile{}}
+}
+
+// profileDefaults fills what every profile must have.
+func profileDefaults(p *protocol.Profile, name string) {
+	if p.Name == "" {
+		p.Name = name
+	}
+	if p.Avatar == "" {
+		p.Avatar = "default.png"
+	}
+	if p.PvPRating == 0 {
+		p.PvPRating = 1200
+	}
+	if p.PvPRank == "" {
+		p.PvPRank = rankName(p.PvPRating)
+	}
+	if p.Armies == nil {
+		p.Armies = map[string][]string{}
+	}
+	if p.UnitXP == nil {
+		p.UnitXP = map[string]int{}
+	}
+	if p.Resources == nil {
+		p.Resources = map[string]int{}
+	}
+	if p.Campaign == nil {
+		p.Campaign = map[string]protocol.CampaignRecord{}
+	}
+}
+
+// decode turns stored JSON into a defaulted profile at the current schema
+// version; upgraded reports whether an upgrade ran.
+func decodeProfile(b []byte, name string) (p protocol.Profile, upgraded bool, err error) {
+	if err := json.Unmarshal(b, &p); err != nil {
+		return protocol.Profile{}, false, err
+	}
+	for p.SchemaVersion < profileSchemaVersion {
+		if p.SchemaVersion >= 0 {
+			profileUpgrades[p.SchemaVersion](&p)
+		}
+		p.SchemaVersion++
+		upgraded = true
+	}
+	profileDefaults(&p, name)
+	return p, upgraded, nil
+}
+
+// Load returns the profile of the account name belongs to, or a fresh one
+// with defaults. The result is a private copy the caller may modify.
+func (ps *ProfileService) Load(name string) (protocol.Profile, error) {
+	id, err := store.LookupAccount(ps.st, name)
+	if errors.Is(err, store.ErrNotFound) {
+		p := protocol.Profile{Name: name, SchemaVersion: profileSchemaVersion}
+		profileDefaults(&p, name)
+		return p, nil
+	}
+	if err != nil {
+		return protocol.Profile{}, err
+	}
+	return ps.loadID(id, name)
+}
+
+func (ps *ProfileService) loadID(id, name string) (protocol.Profile, error) {
+	ps.mu.Lock()
+	if e := ps.cache[id]; e != nil {
+		e.used = time.Now()
+		b := e.data
+		ps.mu.Unlock()
+		p, _, err := decodeProfile(b, name)
+		p.AccountID = id
+		return p, err
+	}
+	ps.mu.Unlock()
+
+	b, err := ps.st.Get(store.Players, id)
+	if errors.Is(err, store.ErrNotFound) {
+		p := protocol.Profile{AccountID: id, Name: name, SchemaVersion: profileSchemaVersion}
+		profileDefaults(&p, name)
+		return p, nil
+	}
+	if err != nil {
+		return protocol.Profile{}, err
+	}
+	p, upgraded, err := decodeProfile(b, name)
+	if err != nil {
+		return protocol.Profile{}, err
+	}
+	p.AccountID = id
+	e := &cachedProfile{data: b, used: time.Now()}
+	if upgraded {
+		// write the upgrade back with the next flush
+		if nb, err := json.Marshal(p); err == nil {
+			e.data, e.gen = nb, 1
+		}
+	}
+	ps.mu.Lock()
+	if ps.cache[id] == nil { // a concurrent Save wins
+		ps.cache[id] = e
+	}
+	ps.mu.Unlock()
+	return p, nil
+}
+
+// errNoAccount is returned when saving a profile for a name no account was
+// registered under.
+var errNoAccount = errors.New("profiles: no account for this name")
+
+// Save records p under its account; it reaches the store with the next
+// flush. Accounts are only bound by auth (registration and login), so a
+// name that never registered has nothing to save to.
+func (ps *ProfileService) Save(p protocol.Profile) error {
+	if p.AccountID == "" {
+		id, err := store.LookupAccount(ps.st, p.Name)
+		if errors.Is(err, store.ErrNotFound) {
+			return errNoAccount
+		}
+		if err != nil {
+			return err
+		}
+		p.AccountID = id
+	}
+	p.SchemaVersion = profileSchemaVersion
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	e := ps.cache[p.AccountID]
+	if e == nil {
+		e = &cachedProfile{}
+		ps.cache[p.AccountID] = e
+	}
+	e.data = b
+	e.gen++
+	e.used = time.Now()
+	return nil
+}
+
+// Exists reports whether a profile was ever saved for name.
+func (ps *ProfileService) Exists(name string) bool {
+	id, err := store.LookupAccount(ps.st, name)
+	if err != nil {
+		return false
+	}
+	ps.mu.Lock()
+	e := ps.cache[id]
+	ps.mu.Unlock()
+	if e != nil {
+		return true
+	}
+	_, err = ps.st.Get(store.Players, id)
+	return err == nil
+}
+
+// ForEach calls fn for every profile, including ones not flushed yet.
+func (ps *ProfileService) ForEach(fn func(name string, prof protocol.Profile)) {
+	ps.mu.Lock()
+	pending := make(map[string][]byte, len(ps.cache))
+	for id, e := range ps.cache {
+		pending[id] = e.data
+	}
+	ps.mu.Unlock()
+
+	visit := func(id string, b []byte) {
+		p, _, err := decodeProfile(b, "")
+		if err != nil || p.Name == "" {
+			return
+		}
+		p.AccountID = id
+		fn(p.Name, p)
+	}
+	_ = ps.st.ForEach(store.Players, func(id string, b []byte) error {
+		if cached, ok := pending[id]; ok {
+			b = cached
+			delete(pending, id)
+		}
+		visit(id, b)
+		return nil
+	})
+	for id, b := range pending {
+		visit(id, b)
+	}
+}
+
+// Flush writes every pending save and drops idle cache entries.
+func (ps *ProfileService) Flush() error {
+	ps.flushMu.Lock()
+	defer ps.flushMu.Unlock()
+
+	type job struct {
+		id   string
+		data []byte
+		gen  int
+	}
+	var jobs []job
+	ps.mu.Lock()
+	for id, e := range ps.cache {
+		if e.gen != e.saved {
+			jobs = append(jobs, job{id, e.data, e.gen})
+		}
+	}
+	ps.mu.Unlock()
+
+	var firstErr error
+	for _, j := range jobs {
+		start := time.Now()
+		err := ps.st.Put(store.Players, j.id, j.data)
+		mProfileSaveSeconds.Observe(time.Since(start).Seconds())
+		if err != nil {
+			mProfileSaveErrors.Inc()
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		ps.mu.Lock()
+		if e := ps.cache[j.id]; e != nil && e.saved < j.gen {
+			e.saved = j.gen
+		}
+		ps.mu.Unlock()
+	}
+
+	cutoff := time.Now().Add(-profileCacheTTL)
+	ps.mu.Lock()
+	for id, e := range ps.cache {
+		if e.gen == e.saved && e.used.Before(cutoff) {
+			delete(ps.cache, id)
+		}
+	}
+	ps.mu.Unlock()
+	return firstErr
+}
+
+// Run flushes pending saves every profileFlushEvery until stop is closed;
+// start it once.
+func (ps *ProfileService) Run(stop <-chan struct{}) {
+	t := time.NewTicker(profileFlushEvery)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		if err := ps.Flush(); err != nil {
+			log.Printf("profiles: flush: %v", err)
+		}
+	}
+}
@@ -10,12 +10,9 @@ func eloExpected(ra, rb int) float64 {
 	return 1 / (1 + math.Pow(10, float64(rb-ra)/400))
 }
 
-func eloApply(ra, rb int, win bool) (newA, delta int) {
+// eloApply rates a player scoring S (1 win, 0.5 draw, 0 loss) against rb.
+func eloApply(ra, rb int, S float64) (newA, delta int) {
 	E := eloExpected(ra, rb)
-	S := 0.0
-	if win {
-		S = 1.0
-	}
 	d := int(math.Round(eloK * (S - E)))
 	nr := ra + d
 	if nr < 0 {
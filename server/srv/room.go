@@ -4,6 +4,7 @@ import (
 	"log"
 	"math/rand"
 	"rumble/shared/protocol"
+	"sync"
 	"time"
 )
 
@@ -13,33 +14,89 @@ type Room struct {
 	lastSnap time.Time
 	players  []*client
 	active   bool   // gameplay ticks only when true
-	Mode     string // "queue" | "friendly" | "pve"
+	Mode     string // "queue" | "team" | "draft" | "friendly" | "pve" | "survival" | "coop" | "challenge" | "tournament"
 	hub      *Hub   // back-reference so we can send and persist at game end
 	// ---- PvE bot
 	aiActive bool
 	aiID     int64
 	aiTimer  float64
+	// ---- Endless survival waves (Mode == "survival")
+	surv *survivalRun
+	// ---- Friendly duel lobby (1v1 friendlies; nil otherwise)
+	lobby *friendlyLobby
+	// ---- Tournament match this room decides (nil otherwise)
+	tourney *tourneyRef
+	// ---- Watch-party spectators (see party.go)
+	watchMu    sync.Mutex
+	watchers   []*client
+	watchTeams map[*client]int // team each watcher sees through (guarded by watchMu)
+	watchedFor *Game           // game the watchers were attached for (guarded by hub.mu)
+	// ---- Fog of war: unit IDs each recipient is tracking (see fog.go)
+	fogMu   sync.Mutex
+	fogSeen map[*client]map[int64]bool
+	// ---- Players who left a running ranked/tournament match (see abandon.go)
+	departMu sync.Mutex
+	departed map[int64]*Player // guarded by departMu; Leave writes it off the tick loop
+	// ---- Saved copy in data/matches and resume after a restart (see matchstate.go)
+	checkpointed bool
+	resume       *resumeState
 
 	tick int
 }
 
 func NewRoom(id string, h *Hub) *Room {
-	r := &Room{id: id, g: NewGame(), hub: h, Mode: "pve"}
+	r := &Room{id: id, hub: h, Mode: "pve"}
+	r.resetGame()
+	return r
+}
+
+// resetGame gives the room a fresh Game (players must re-join).
+func (r *Room) resetGame() {
+	r.g = NewGame()
+	r.tick = 0
+	r.departMu.Lock()
+	r.departed = nil
+	r.departMu.Unlock()
+	r.fogMu.Lock()
+	r.fogSeen = nil
+	r.fogMu.Unlock()
 	// Set up event broadcasting callback
 	r.g.broadcastEvent = func(eventType string, event interface{}) {
+		if r.g.fogEnabled() {
+			send := func(c *client) {
+				if r.g.eventVisible(r.viewTeam(c), event) {
+					sendJSON(c, eventType, event)
+				}
+			}
+			for _, c := range r.players {
+				send(c)
+			}
+			r.eachWatcher(send)
+			return
+		}
 		for _, c := range r.players {
 			sendJSON(c, eventType, event)
 		}
+		r.sendWatchers(eventType, event)
 	}
-	return r
 }
 
 // ---- Lobby join without starting the battle
 // Uses the player's saved profile (ID/Name/Army) and DOES NOT send Init/snapshot yet.
 func (r *Room) JoinClient(c *client, s *Session) {
+	r.JoinClientWithArmy(c, s, s.Army)
+}
+
+// JoinClientWithArmy is JoinClient with an army other than the saved one
+// (e.g. the result of a draft).
+func (r *Room) JoinClientWithArmy(c *client, s *Session, army []string) {
 	if c.room != nil {
 		return
 	}
+	if c.watching != nil { // a spectator whose own match starts
+		c.watching.removeWatcher(c)
+		c.watching = nil
+	}
 	c.room = r
 	r.players = append(r.players, c)
 
@@ -48,7 +105,7 @@ func (r *Room) JoinClient(c *client, s *Session) {
 	c.name = s.Name
 
 	// Add the player into the game with their saved army (fallback inside if invalid)
-	r.g.AddPlayerWithArmy(c.id, s.Name, s.Army)
+	r.g.AddPlayerOnTeam(c.id, s.Name, army, r.joinTeam())
 	// Scale player's cards by level (10% per level over base) using UnitXP from session
 	if pl := r.g.players[c.id]; pl != nil {
 		scaleFor := func(name string) float64 {
@@ -84,22 +141,8 @@ func (r *Room) JoinClient(c *client, s *Session) {
 		}
 		// Scale base HP by average army level (rounded .5 up)
 		// Average includes champion + 6 minis from player's saved Army
-		if len(s.Army) == 7 {
-			sum := 0.0
-			for _, nm := range s.Army {
-				xp := s.Profile.UnitXP[nm]
-				lvl, _, _ := computeLevel(xp)
-				if lvl < 1 {
-					lvl = 1
-				}
-				sum += float64(lvl)
-			}
-			avg := sum / 7.0
-			// round .5 up
-			round := int(avg + 0.5)
-			if round < 1 {
-				round = 1
-			}
+		if len(army) == 7 {
+			round := armyLevel(army, s.Profile.UnitXP)
 			f := 1.0 + 0.10*float64(round-1)
 			if pl.Base.MaxHP > 0 {
 				pl.Base.MaxHP = int(float64(pl.Base.MaxHP) * f)
@@ -116,6 +159,15 @@ func (r *Room) JoinClient(c *client, s *Session) {
 	}
 }
 
+// joinTeam picks the side for the next human: co-op partners share the
+// player side, otherwise players alternate sides in join order (1v1 and 2v2).
+func (r *Room) joinTeam() int {
+	if r.Mode == "coop" {
+		return 0
+	}
+	return len(r.g.players) % 2
+}
+
 func (r *Room) Join(c *client) {
 	if r.g == nil {
 		r.g = NewGame()
@@ -140,55 +192,97 @@ func (r *Room) Join(c *client) {
 func (r *Room) StartBattle() {
 	log.Printf("ROOM %s StartBattle: players=%d", r.id, len(r.players))
 
-	// Load map for friendly duels
-	if r.Mode == "friendly" {
-		duelMaps := []string{"friendly_duel1", "friendly_duel2"}
-		randomIndex := rand.Intn(len(duelMaps))
-		selectedMap := duelMaps[randomIndex]
-		if mapDef, err := loadMapDef(selectedMap); err == nil {
-			r.g.mapDef = &mapDef
-			log.Printf("Loaded friendly duel map: %s", selectedMap)
-		} else {
-			log.Printf("Failed to load friendly duel map %s: %v", selectedMap, err)
-		}
+	// Load map for friendly duels (unless the lobby host picked one)
+	if r.Mode == "friendly" && r.g.mapDef == nil {
+		r.g.SetMapDef(randomDuelMap())
 	}
 
-	// Initialize timer
+	// Initialize timer (survival runs count up instead)
+	r.g.endless = r.Mode == "survival"
 	r.g.InitializeTimer()
 
-	// Send Init + initial Gold + immediate snapshot
 	for _, p := range r.players {
-		sendJSON(p, "Init", r.g.InitFor(p.id))
-
-		if pl := r.g.players[p.id]; pl != nil {
-			sendJSON(p, "GoldUpdate", protocol.GoldUpdate{
-				PlayerID: pl.ID,
-				Gold:     pl.Gold, // send 4 immediately so UI shows it right away
-			})
-		}
-
-		// Send map definition if available
-		if r.g.mapDef != nil {
-			sendJSON(p, "MapDef", protocol.MapDefMsg{Def: *r.g.mapDef})
-		}
-
-		snap := r.g.FullSnapshot()
-		sendJSON(p, "FullSnapshot", snap)
+		r.sendBattleStart(p)
 	}
 
 	r.active = true
 	r.lastSnap = time.Now()
+	mMatchesStarted.Inc(r.Mode)
 
 	// If only 1 human, spawn a simple AI opponent
 	ids := make([]int64, 0, len(r.g.players))
 	for id := range r.g.players {
 		ids = append(ids, id)
 	}
-	if len(ids) == 1 {
+	// (co-op rooms always get one: both humans are on the player side)
+	if len(ids) == 1 || r.Mode == "coop" {
 		r.aiActive = true
 		r.aiID = protocol.NewID()
-		r.g.AddPlayerWithArmy(r.aiID, "AI", r.g.DefaultAIArmy())
+		r.g.AddPlayerOnTeam(r.aiID, "AI", r.g.DefaultAIArmy(), 1)
+		// PvE maps may place bosses on the AI side
+		if r.playsCampaign() {
+			r.g.SpawnMapBosses(r.aiID)
+		}
+	}
+}
+
+// sendBattleStart puts p on the battle screen: Init + initial Gold +
+// immediate snapshot.
+func (r *Room) sendBattleStart(p *client) {
+	sendJSON(p, "Init", r.g.InitFor(p.id))
+
+	if pl := r.g.players[p.id]; pl != nil {
+		sendJSON(p, "GoldUpdate", protocol.GoldUpdate{
+			PlayerID: pl.ID,
+			Gold:     pl.Gold, // send 4 immediately so UI shows it right away
+		})
+	}
+
+	// Send map definition if available
+	if r.g.mapDef != nil {
+		sendJSON(p, "MapDef", protocol.MapDefMsg{Def: *r.g.mapDef})
+	}
+
+	sendJSON(p, "FullSnapshot", r.snapshotFor(p))
+}
+
+// randomDuelMap loads one of the friendly duel maps (nil if none loads).
+func randomDuelMap() *protocol.MapDef {
+	duelMaps := []string{"friendly_duel1", "friendly_duel2"}
+	selectedMap := duelMaps[rand.Intn(len(duelMaps))]
+	mapDef, err := loadMapDef(selectedMap)
+	if err != nil {
+		log.Printf("Failed to load friendly duel map %s: %v", selectedMap, err)
+		return nil
 	}
+	log.Printf("Loaded friendly duel map: %s", selectedMap)
+	return &mapDef
+}
+
+// playsCampaign reports whether the room is a campaign map against the AI
+// (solo or co-op): bosses spawn, XP and campaign progress are awarded.
+func (r *Room) playsCampaign() bool {
+	return r.Mode == "pve" || r.Mode == "coop"
+}
+
+// isPvE reports whether the room is a match against the server, solo or
+// co-op (pause, restart and surrender are only allowed there).
+func (r *Room) isPvE() bool {
+	return r.Mode == "pve" || r.Mode == "survival" || r.Mode == "coop" || r.Mode == "challenge"
+}
+
+// controlsMatch reports whether c may pause, restart or surrender the match:
+// the player in a solo PvE room, the host (first to join) in co-op.
+func (r *Room) controlsMatch(c *client) bool {
+	if !r.isPvE() {
+		return false
+	}
+	return r.Mode != "coop" || (len(r.players) > 0 && r.players[0] == c)
+}
+
+// isRanked reports whether the match changes PvP ratings (1v1 and 2v2 queues).
+func (r *Room) isRanked() bool {
+	return r.Mode == "queue" || r.Mode == "team"
 }
 
 // Optional (kept for future PvP readiness toggles)
@@ -198,6 +292,9 @@ func (r *Room) MarkReady(c *client) {
 
 // Leave room & remove from game
 func (r *Room) Leave(leaver *client) {
+	if r.lobby != nil && r.hub != nil && r.resume == nil {
+		r.hub.closeLobbyLocked(r, leaver) // callers hold hub.mu
+	}
 	// remove from players slice
 	newList := r.players[:0]
 	for _, p := range r.players {
@@ -207,7 +304,14 @@ func (r *Room) Leave(leaver *client) {
 	}
 	r.players = newList
 
-	// remove from authoritative game
+	// a restored match still waiting for everyone keeps the seat free
+	if r.resume != nil {
+		r.resume.waiting[leaver.name] = leaver.id
+		return
+	}
+
+	// remove from authoritative game (keeping a copy for the result)
+	r.noteDeparture(leaver)
 	r.g.RemovePlayer(leaver.id)
 
 	// If empty, stop ticking
@@ -267,50 +371,69 @@ func (r *Room) Tick() {
 		return
 	}
 
-	const tickRate = 20.0
-	dt := 1.0 / tickRate
+	dt := 1.0 / float64(tickRate)
 
 	// Update timer and check for expiration
 	if timerExpired, timerWinnerID := r.g.UpdateTimer(dt); timerExpired {
 		// Timer expired - end game based on timer winner
-		if r.Mode == "pve" && timerWinnerID != -1 {
+		if r.playsCampaign() && timerWinnerID != -1 {
 			r.awardPveXPServer(timerWinnerID)
 		}
+		if r.playsCampaign() {
+			r.recordCampaignResult(timerWinnerID)
+		}
+		if r.Mode == "challenge" {
+			r.recordChallengeResult(timerWinnerID)
+		}
 
 		// Send victory/defeat events before GameOver
 		r.sendVictoryDefeatEvents(timerWinnerID)
 
 		for _, c := range r.players {
 			sendJSON(c, "GameOver", protocol.GameOver{WinnerID: timerWinnerID})
-			// Rating only for Open Queue (two humans)
-			if r.Mode == "queue" && r.hub != nil {
-				applyQueueRating(r, timerWinnerID, r.hub)
-			}
+		}
+		r.sendWatchers("GameOver", protocol.GameOver{WinnerID: timerWinnerID})
+		if r.isRanked() && r.hub != nil {
+			applyQueueRating(r, timerWinnerID, r.hub)
 		}
 		r.active = false
+		mMatchesFinished.Inc(r.Mode)
+		if r.lobby != nil {
+			r.hub.reopenLobby(r)
+		}
+		if r.tourney != nil {
+			r.hub.recordTournamentResult(r, timerWinnerID)
+		}
 		return
 	}
 
-	// detect game over by base destruction
-	var loser *Player
-	for _, p := range r.g.players {
-		if p.Base.HP <= 0 {
-			loser = p
-			break
-		}
-	}
-	if loser != nil {
-		// winner = the other one (if single-player with AI, that’ll be the bot)
-		var winnerID int64
-		for id := range r.g.players {
-			if id != loser.ID {
-				winnerID = id
-				break
-			}
+	// detect game over by base destruction: a side loses once all its bases fall
+	loserTeam, lost := r.g.defeatedTeam()
+	// ...or once everyone on it has left or gone AFK (ranked and tournaments)
+	r.trackAFK(dt)
+	if !lost {
+		if team, afk, ok := r.abandonedTeam(); ok {
+			r.announceAbandon(team, afk)
+			loserTeam, lost = team, true
 		}
+	}
+	if lost && r.Mode == "survival" && loserTeam == 1 {
+		lost = false // the wave spawner's fortress cannot fall
+	}
+	if lost && r.Mode == "survival" {
+		r.endSurvival()
+		return
+	}
+	if lost {
+		// winner = someone from the other side (if single-player with AI, that’ll be the bot)
+		winnerID := r.g.firstOfOtherTeam(loserTeam)
 		// Server-authoritative XP for PvE
-		if r.Mode == "pve" {
+		if r.playsCampaign() {
 			r.awardPveXPServer(winnerID)
+			r.recordCampaignResult(winnerID)
+		}
+		if r.Mode == "challenge" {
+			r.recordChallengeResult(winnerID)
 		}
 
 		// Send victory/defeat events before GameOver
@@ -318,31 +441,48 @@ func (r *Room) Tick() {
 
 		for _, c := range r.players {
 			sendJSON(c, "GameOver", protocol.GameOver{WinnerID: winnerID})
-			// Rating only for Open Queue (two humans)
-			if r.Mode == "queue" && r.hub != nil {
-				applyQueueRating(r, winnerID, r.hub)
-			}
+		}
+		r.sendWatchers("GameOver", protocol.GameOver{WinnerID: winnerID})
+		if r.isRanked() && r.hub != nil {
+			applyQueueRating(r, winnerID, r.hub)
 		}
 		r.active = false
+		mMatchesFinished.Inc(r.Mode)
+		if r.lobby != nil {
+			r.hub.reopenLobby(r)
+		}
+		if r.tourney != nil {
+			r.hub.recordTournamentResult(r, winnerID)
+		}
 		return
 	}
 
 	r.tick++
 
+	// --- Survival waves replace the bot
+	if r.Mode == "survival" {
+		r.updateSurvival(dt)
+	}
+
 	// --- Simple AI: slower & capped
-	if r.aiActive {
+	if r.aiActive && r.Mode != "survival" {
 		r.aiTimer += dt
-		if r.aiTimer >= 3.5 { // spawn every ~3.5s
+		// co-op faces two armies, so the bot plays faster and fields more units
+		every, capUnits := 3.5, 5
+		if r.Mode == "coop" {
+			every, capUnits = 2.5, 8
+		}
+		if r.aiTimer >= every {
 			r.aiTimer = 0
 
-			// cap AI units at 5
+			// cap AI units
 			aiCount := 0
 			for _, u := range r.g.units {
 				if u.OwnerID == r.aiID {
 					aiCount++
 				}
 			}
-			if aiCount < 5 {
+			if aiCount < capUnits {
 				if pl := r.g.players[r.aiID]; pl != nil {
 					idx := -1
 					for i, c := range pl.Hand {
@@ -352,8 +492,9 @@ func (r *Room) Tick() {
 						}
 					}
 					if idx >= 0 {
-						x := float64(r.g.width/2 + (rand.Intn(120) - 60))
-						y := float64(90 + rand.Intn(40))
+						// In front of the AI base (the old fixed 90-130 band on the default world)
+						x := float64(pl.Base.X + pl.Base.W/2 + (rand.Intn(120) - 60))
+						y := float64(pl.Base.Y + pl.Base.H/2 + 14 + rand.Intn(40))
 						r.g.HandleDeploy(r.aiID, protocol.DeployMiniAt{CardIndex: idx, X: x, Y: y})
 					}
 				}
@@ -364,21 +505,28 @@ func (r *Room) Tick() {
 	// --- Sim step
 	delta := r.g.Step(dt)
 
-	// --- Broadcast delta (includes bases every tick from g.Step)
+	// --- Broadcast delta (includes bases every tick from g.Step). Under fog
+	// of war every recipient gets its own filtered copy.
+	vis := map[int]map[int64]bool{}
 	for _, c := range r.players {
-		sendJSON(c, "StateDelta", delta)
+		sendJSON(c, "StateDelta", r.deltaFor(c, delta, vis))
 		// also send each player's gold
 		if p := r.g.players[c.id]; p != nil {
 			sendJSON(c, "GoldUpdate", protocol.GoldUpdate{PlayerID: p.ID, Gold: p.Gold})
 		}
 	}
+	r.eachWatcher(func(c *client) {
+		sendJSON(c, "StateDelta", r.deltaFor(c, delta, vis))
+	})
 
 	// Optional: resync occasionally
-	if r.tick%60 == 0 { // every ~3s
-		snap := r.g.FullSnapshot()
+	if r.tick%(3*tickRate) == 0 { // every ~3s
 		for _, c := range r.players {
-			sendJSON(c, "FullSnapshot", snap)
+			sendJSON(c, "FullSnapshot", r.snapshotFor(c))
 		}
+		r.eachWatcher(func(c *client) {
+			sendJSON(c, "FullSnapshot", r.snapshotFor(c))
+		})
 	}
 }
 
@@ -402,14 +550,14 @@ func (r *Room) awardPveXPServer(winnerID int64) {
 			s.Profile.UnitXP = map[string]int{}
 		}
 		rate := 0.02
-		if c.id == winnerID {
+		if r.g.SameTeam(c.id, winnerID) {
 			rate = 0.05
 		}
 		// Use saved active army and award to champion + random minis
 		army := s.Profile.Army
 		if len(army) == 0 {
 			// nothing to award
-			_ = saveProfile(s.Profile)
+			_ = r.hub.profiles.Save(s.Profile)
 			prof := s.Profile
 			r.hub.mu.Unlock()
 			sendJSON(c, "Profile", prof)
@@ -427,7 +575,7 @@ func (r *Room) awardPveXPServer(winnerID int64) {
 		// Determine how many minis to award alongside champion
 		// Keep it light: 1 mini on loss, 2 minis on win (or fewer if not enough minis)
 		k := 1
-		if c.id == winnerID {
+		if r.g.SameTeam(c.id, winnerID) {
 			k = 2
 		}
 		if k > len(minis) {
@@ -453,7 +601,7 @@ func (r *Room) awardPveXPServer(winnerID int64) {
 				s.Profile.UnitXP[name] = cur + delta
 			}
 		}
-		_ = saveProfile(s.Profile)
+		_ = r.hub.profiles.Save(s.Profile)
 		prof := s.Profile
 		r.hub.mu.Unlock()
 		sendJSON(c, "Profile", prof)
@@ -464,11 +612,8 @@ func (r *Room) awardPveXPServer(winnerID int64) {
 func (r *Room) sendVictoryDefeatEvents(winnerID int64) {
 	// Calculate match duration
 	duration := 0
-	if r.g != nil && r.g.timeLimit > 0 {
-		duration = r.g.timeLimit - int(r.g.timeRemaining)
-		if duration < 0 {
-			duration = 0
-		}
+	if r.g != nil {
+		duration = r.g.elapsedSeconds()
 	}
 
 	// Find winner and loser info
@@ -480,11 +625,11 @@ func (r *Room) sendVictoryDefeatEvents(winnerID int64) {
 		if p.ID == winnerID {
 			winnerName = p.Name
 			// Calculate rewards for PvE
-			if r.Mode == "pve" {
+			if r.playsCampaign() {
 				goldEarned = 10 // Base gold reward
 				xpGained = 50   // Base XP reward
 			}
-		} else {
+		} else if !r.g.SameTeam(p.ID, winnerID) {
 			loserID = p.ID
 			loserName = p.Name
 		}
@@ -512,7 +657,7 @@ func (r *Room) sendVictoryDefeatEvents(winnerID int64) {
 
 	// Broadcast events to all players
 	for _, c := range r.players {
-		if c.id == winnerID {
+		if r.g.SameTeam(c.id, winnerID) {
 			sendJSON(c, "VictoryEvent", victoryEvent)
 		} else {
 			sendJSON(c, "DefeatEvent", defeatEvent)
@@ -0,0 +1,134 @@
+package srv
+
+import (
+	"log"
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+// Graceful shutdown: BeginShutdown stops new matches, WaitForMatches lets the
+// running ones play out, SuspendMatches saves whatever is left for the next
+// server process (see matchstate.go), and DisconnectAll closes the
+// connections. main then flushes the stores.
+
+// drainRefused are the messages that would start a match or queue for one.
+var drainRefused = map[string]bool{
+	"CreatePve": true, "CreateSurvival": true, "CreateChallenge": true,
+	"JoinPvpQueue": true, "JoinTeamQueue": true, "JoinDraftQueue": true,
+	"TeamLobbyCreate": true, "TeamLobbyJoin": true,
+	"FriendlyCreate": true, "FriendlyJoin": true,
+	"CoopInvite": true, "CoopAccept": true, "InviteToDuel": true, "DuelAccept": true,
+	"PartyQueue": true, "CreateTournament": true, "JoinTournament": true,
+	"StartBattle": true, "RestartMatch": true, "SetReady": true,
+}
+
+const restartingMsg = "The server is restarting"
+
+// refusedWhileDraining answers a match request during shutdown and reports
+// whether it was refused.
+func (h *Hub) refusedWhileDraining(c *client, typ string) bool {
+	if !drainRefused[typ] {
+		return false
+	}
+	h.mu.Lock()
+	draining := h.draining
+	h.mu.Unlock()
+	if draining {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: restartingMsg + ": no new matches"})
+	}
+	return draining
+}
+
+// BeginShutdown refuses new matches and queue entries from now on, empties
+// the queues and shows every client a restart banner for grace.
+func (h *Hub) BeginShutdown(grace time.Duration) {
+	h.mu.Lock()
+	h.draining = true
+	queued := append(append(append([]*client(nil), h.pvpQueue...), h.teamQueue...), h.draftQueue...)
+	h.pvpQueue, h.teamQueue, h.draftQueue = h.pvpQueue[:0], h.teamQueue[:0], h.draftQueue[:0]
+	for _, c := range queued {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: restartingMsg + ": you have left the queue"})
+	}
+	h.mu.Unlock()
+	h.Announce(restartingMsg+"; running matches may finish", grace)
+}
+
+// activeMatches counts rooms with a running battle.
+func (h *Hub) activeMatches() int {
+	n := 0
+	h.onLoop(func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for _, r := range h.rooms {
+			if r.active {
+				n++
+			}
+		}
+	})
+	return n
+}
+
+// WaitForMatches waits until no battle is running or the deadline passes,
+// and returns how many are still running.
+func (h *Hub) WaitForMatches(deadline time.Time) int {
+	for {
+		n := h.activeMatches()
+		if n == 0 || !time.Now().Before(deadline) {
+			return n
+		}
+		log.Printf("shutdown: waiting for %d match(es)", n)
+		time.Sleep(min(5*time.Second, time.Until(deadline)))
+	}
+}
+
+// SuspendMatches saves every unfinished match and stops it, so the next
+// server process resumes it. A match that cannot be saved is ended as a
+// draw instead. It returns how many were saved.
+func (h *Hub) SuspendMatches() int {
+	saved := 0
+	h.onLoop(func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for id, r := range h.rooms {
+			if !r.active && r.resume == nil {
+				continue
+			}
+			if r.active {
+				if err := r.saveCheckpoint(); err != nil {
+					log.Printf("shutdown: %v", err)
+					r.endWith(restartingMsg)
+					continue
+				}
+				r.active = false
+				saved++
+			}
+			for _, c := range r.players {
+				c.room = nil
+				h.suspended[c] = true
+			}
+			delete(h.rooms, id)
+		}
+	})
+	return saved
+}
+
+// DisconnectAll tells every client the server is going away and closes its
+// connection.
+func (h *Hub) DisconnectAll() {
+	h.mu.Lock()
+	clients := make([]*client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+		reason := restartingMsg + ", please log in again shortly"
+		if h.suspended[c] {
+			reason = restartingMsg + "; log in again shortly to resume your match"
+		}
+		sendJSON(c, "Kicked", protocol.Kicked{Reason: reason})
+	}
+	h.mu.Unlock()
+	time.Sleep(500 * time.Millisecond) // let the writers deliver the notice
+	for _, c := range clients {
+		_ = c.conn.Close()
+	}
+}
@@ -2,48 +2,62 @@ package srv
 
 import (
     "encoding/json"
-    "os"
-    "path/filepath"
     "sort"
     "strings"
     "sync"
     "time"
 
+    "rumble/server/store"
     "rumble/shared/protocol"
 )
 
 type Social struct {
     mu      sync.RWMutex
-    fpath   string
-    mpath   string
+    st      store.Store
     friends map[string]map[string]bool // user -> set(friends)
     dms     map[string][]protocol.FriendDM // convoKey -> messages
 }
 
-func NewSocial(dataDir string) (*Social, error) {
+func NewSocial(st store.Store) (*Social, error) {
     s := &Social{
-        fpath: filepath.Join(dataDir, "friends.json"),
-        mpath: filepath.Join(dataDir, "messages.json"),
+        st: st,
         friends: map[string]map[string]bool{},
         dms: map[string][]protocol.FriendDM{},
     }
-    if b, err := os.ReadFile(s.fpath); err == nil {
-        _ = json.Unmarshal(b, &s.friends)
+    err := st.ForEach(store.Friends, func(key string, val []byte) error {
+        var set map[string]bool
+        if json.Unmarshal(val, &set) == nil {
+            s.friends[key] = set
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
     }
-    if b, err := os.ReadFile(s.mpath); err == nil {
-        _ = json.Unmarshal(b, &s.dms)
+    err = st.ForEach(store.DMs, func(key string, val []byte) error {
+        var msgs []protocol.FriendDM
+        if json.Unmarshal(val, &msgs) == nil {
+            s.dms[key] = msgs
+        }
+        return nil
+    })
+    return s, err
+}
+
+// saveFriends persists the friend sets of the given users.
+func (s *Social) saveFriends(users ...string) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    for _, u := range users {
+        _ = store.PutJSON(s.st, store.Friends, u, s.friends[u])
     }
-    return s, nil
 }
 
-func (s *Social) save() {
+// saveConvo persists one conversation.
+func (s *Social) saveConvo(k string) {
     s.mu.RLock()
-    fb, _ := json.MarshalIndent(s.friends, "", "  ")
-    mb, _ := json.MarshalIndent(s.dms, "", "  ")
-    s.mu.RUnlock()
-    _ = os.MkdirAll(filepath.Dir(s.fpath), 0o755)
-    _ = os.WriteFile(s.fpath, fb, 0o644)
-    _ = os.WriteFile(s.mpath, mb, 0o644)
+    defer s.mu.RUnlock()
+    _ = store.PutJSON(s.st, store.DMs, k, s.dms[k])
 }
 
 func (s *Social) AddFriend(a, b string) {
@@ -56,7 +70,7 @@ func (s *Social) AddFriend(a, b string) {
     s.friends[a][b] = true
     s.friends[b][a] = true
     s.mu.Unlock()
-    s.save()
+    s.saveFriends(a, b)
 }
 
 func (s *Social) RemoveFriend(a, b string) {
@@ -66,7 +80,7 @@ func (s *Social) RemoveFriend(a, b string) {
     if s.friends[a] != nil { delete(s.friends[a], b) }
     if s.friends[b] != nil { delete(s.friends[b], a) }
     s.mu.Unlock()
-    s.save()
+    s.saveFriends(a, b)
 }
 
 func (s *Social) ListFriends(user string) []string {
@@ -92,7 +106,7 @@ func (s *Social) AppendDM(from, to, text string) protocol.FriendDM {
     s.mu.Lock()
     s.dms[k] = append(s.dms[k], dm)
     s.mu.Unlock()
-    s.save()
+    s.saveConvo(k)
     return dm
 }
 
@@ -0,0 +1,223 @@
+package srv
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"rumble/shared/protocol"
+)
+
+// defaultSurvivalMap is used when CreateSurvival does not name a map.
+const defaultSurvivalMap = "colosseum"
+
+const (
+	survivalFirstWave = 5.0 // seconds before wave 1
+	survivalMinGap    = 8.0 // fastest wave interval
+	survivalMaxAlive  = 40  // waves are held back while this many enemies live
+)
+
+// survivalRun is the per-room state of an endless survival match.
+type survivalRun struct {
+	level    int     // army level the waves are scaled from
+	wave     int     // last wave spawned
+	nextWave float64 // seconds until the next wave
+}
+
+func newSurvivalRun(level int) *survivalRun {
+	if level < 1 {
+		level = 1
+	}
+	return &survivalRun{level: level, nextWave: survivalFirstWave}
+}
+
+// waveSize grows by one unit every other wave, capped at 14.
+func (s *survivalRun) waveSize(wave int) int {
+	n := 3 + wave/2
+	if n > 14 {
+		n = 14
+	}
+	return n
+}
+
+// waveGap shortens the pause between waves as the run goes on.
+func (s *survivalRun) waveGap(wave int) float64 {
+	return math.Max(survivalMinGap, 24-float64(wave))
+}
+
+// waveScale is the HP/DMG multiplier: the usual 10% per army level, plus 8% per wave.
+func (s *survivalRun) waveScale(wave int) float64 {
+	return (1.0 + 0.10*float64(s.level-1)) * (1.0 + 0.08*float64(wave-1))
+}
+
+// armyLevel averages the unit levels of an army (rounded .5 up).
+func armyLevel(army []string, unitXP map[string]int) int {
+	if len(army) == 0 {
+		return 1
+	}
+	sum := 0.0
+	for _, nm := range army {
+		lvl, _, _ := computeLevel(unitXP[nm])
+		if lvl < 1 {
+			lvl = 1
+		}
+		sum += float64(lvl)
+	}
+	round := int(sum/float64(len(army)) + 0.5)
+	if round < 1 {
+		round = 1
+	}
+	return round
+}
+
+// survivalPool lists the minis waves are drawn from.
+func (g *Game) survivalPool() []MiniCard {
+	var pool []MiniCard
+	for _, m := range g.minis {
+		if strings.EqualFold(m.Role, "mini") && !strings.EqualFold(m.Class, "spell") && m.HP > 0 {
+			pool = append(pool, m)
+		}
+	}
+	return pool
+}
+
+// updateSurvival advances the wave clock and spawns waves. It replaces the
+// regular PvE bot, and keeps the enemy fortress standing: a run only ends
+// when the player's base falls.
+func (r *Room) updateSurvival(dt float64) {
+	s := r.surv
+	if s == nil || r.g.isPaused {
+		return
+	}
+	ai := r.g.players[r.aiID]
+	if ai == nil {
+		return
+	}
+	ai.Base.HP = ai.Base.MaxHP
+
+	s.nextWave -= dt
+	if s.nextWave > 0 {
+		return
+	}
+	alive := 0
+	for _, u := range r.g.units {
+		if u.OwnerID == r.aiID {
+			alive++
+		}
+	}
+	if alive >= survivalMaxAlive {
+		s.nextWave = 1
+		return
+	}
+
+	pool := r.g.survivalPool()
+	if len(pool) == 0 {
+		return
+	}
+	s.wave++
+	n := s.waveSize(s.wave)
+	f := s.waveScale(s.wave)
+	cx := float64(ai.Base.X + ai.Base.W/2)
+	cy := float64(ai.Base.Y + ai.Base.H + 30)
+	for i := 0; i < n; i++ {
+		card := pool[rand.Intn(len(pool))]
+		card.HP = int(float64(card.HP) * f)
+		card.DMG = int(float64(card.DMG) * f)
+		x := cx + float64((i%5)-2)*36 + float64(rand.Intn(11)-5)
+		y := cy + float64(i/5)*30
+		r.g.spawnUnit(card, r.aiID, x, y)
+	}
+	s.nextWave = s.waveGap(s.wave)
+
+	ev := protocol.SurvivalWave{Wave: s.wave, Units: n, NextIn: int(s.nextWave)}
+	for _, c := range r.players {
+		sendJSON(c, "SurvivalWave", ev)
+	}
+}
+
+// endSurvival finishes the run: stores the best result, awards the usual
+// PvE XP for a loss and sends the result followed by GameOver. Runs on the
+// Run goroutine; a run that already ended is left alone.
+func (r *Room) endSurvival() {
+	if r.surv == nil || !r.active || r.g.matchEnded {
+		return
+	}
+	waves := r.surv.wave - 1 // the wave in progress does not count
+	if waves < 0 {
+		waves = 0
+	}
+	seconds := r.g.elapsedSeconds()
+	r.g.matchEnded = true
+
+	r.awardPveXPServer(r.aiID)
+	for _, c := range r.players {
+		if r.aiActive && c.id == r.aiID {
+			continue
+		}
+		res := protocol.SurvivalResult{Waves: waves, Seconds: seconds}
+		if r.hub != nil {
+			r.hub.mu.Lock()
+			if s := r.hub.sessions[c]; s != nil {
+				best := s.Profile.SurvivalBest
+				if best == nil || waves > best.Waves || (waves == best.Waves && seconds > best.Seconds) {
+					s.Profile.SurvivalBest = &protocol.SurvivalRecord{
+						Waves: waves, Seconds: seconds, Level: r.surv.level, At: time.Now().UnixMilli(),
+					}
+					res.NewBest = true
+					if err := r.hub.profiles.Save(s.Profile); err != nil {
+						log.Printf("profiles.Save(survival): %v", err)
+					}
+				}
+				res.Best = s.Profile.SurvivalBest
+			}
+			r.hub.mu.Unlock()
+		}
+		sendJSON(c, "SurvivalResult", res)
+	}
+
+	r.sendVictoryDefeatEvents(r.aiID)
+	for _, c := range r.players {
+		sendJSON(c, "GameOver", protocol.GameOver{WinnerID: r.aiID})
+	}
+	r.sendWatchers("GameOver", protocol.GameOver{WinnerID: r.aiID})
+	r.active = false
+	r.surv = nil
+	mMatchesFinished.Inc(r.Mode)
+}
+
+// buildSurvivalLeaderboardTop50 ranks best runs by waves, then run length.
+func (h *Hub) buildSurvivalLeaderboardTop50() protocol.SurvivalLeaderboard {
+	entries := []protocol.SurvivalLeaderboardEntry{}
+	h.profiles.ForEach(func(name string, prof protocol.Profile) {
+		if prof.SurvivalBest == nil {
+			return
+		}
+		entries = append(entries, protocol.SurvivalLeaderboardEntry{
+			Name:    name,
+			Waves:   prof.SurvivalBest.Waves,
+			Seconds: prof.SurvivalBest.Seconds,
+			Level:   prof.SurvivalBest.Level,
+		})
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Waves != entries[j].Waves {
+			return entries[i].Waves > entries[j].Waves
+		}
+		if entries[i].Seconds != entries[j].Seconds {
+			return entries[i].Seconds > entries[j].Seconds
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	if len(entries) > 50 {
+		entries = entries[:50]
+	}
+
+	return protocol.SurvivalLeaderboard{
+		Items:       entries,
+		GeneratedAt: time.Now().UnixMilli(),
+	}
+}
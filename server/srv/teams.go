@@ -0,0 +1,369 @@
+package srv
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"rumble/shared/protocol"
+)
+
+// teamSize is the number of players per side in a team battle.
+const teamSize = 2
+
+// teamLobby is a friendly 2v2 lobby filling up through a shared code.
+type teamLobby struct {
+	code  string
+	teams [2][]*client // teams[0][0] is the host
+}
+
+func (l *teamLobby) full() bool {
+	return len(l.teams[0]) == teamSize && len(l.teams[1]) == teamSize
+}
+
+func (h *Hub) teamLobbyStateLocked(l *teamLobby) protocol.TeamLobbyState {
+	st := protocol.TeamLobbyState{Code: l.code}
+	for t, side := range l.teams {
+		st.Teams[t] = []string{}
+		for _, c := range side {
+			name := c.name
+			if s := h.sessions[c]; s != nil {
+				name = s.Profile.Name
+			}
+			st.Teams[t] = append(st.Teams[t], name)
+		}
+	}
+	return st
+}
+
+func (h *Hub) broadcastTeamLobbyLocked(l *teamLobby) {
+	st := h.teamLobbyStateLocked(l)
+	for _, side := range l.teams {
+		for _, c := range side {
+			sendJSON(c, "TeamLobbyState", st)
+		}
+	}
+}
+
+// startTeamRoomLocked seats four players in a new room; seat order
+// alternates sides (see Room.joinTeam). Callers make sure no seat is
+// mid-battle (busySeatLocked). h.mu must be held.
+func (h *Hub) startTeamRoomLocked(mode, prefix string, seats []*client) *Room {
+	for _, c := range seats {
+		h.leaveIdleRoomLocked(c)
+	}
+	roomID := makeRoomID(prefix)
+	r := NewRoom(roomID, h)
+	r.Mode = mode
+	h.rooms[roomID] = r
+	if mode == "team" {
+		if arena := h.selectRandomArena(); arena != "" {
+			if mapDef, err := loadMapDef(arena); err == nil {
+				r.g.SetMapDef(&mapDef)
+			} else {
+				log.Printf("Failed to load arena %s for 2v2: %v", arena, err)
+			}
+		}
+	}
+	for _, c := range seats {
+		s := h.sessions[c]
+		if s == nil {
+			continue
+		}
+		r.JoinClient(c, s)
+		s.RoomID = roomID
+	}
+	return r
+}
+
+// launchRoom announces a room built under h.mu to its players and starts
+// the battle. Called without h.mu.
+func launchRoom(r *Room) {
+	for _, c := range r.players {
+		sendJSON(c, "RoomCreated", protocol.RoomCreated{RoomID: r.id})
+	}
+	r.StartBattle()
+}
+
+// ---- Ranked 2v2 queue
+
+// EnqueueTeam adds c to the 2v2 queue and starts a match once four players wait.
+func (h *Hub) EnqueueTeam(c *client) {
+	h.mu.Lock()
+	if c.room != nil && c.room.active {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "You are already in a battle"})
+		return
+	}
+	if h.queueBlockedLocked(c) {
+		h.mu.Unlock()
+		return
+	}
+	h.leaveTeamLobbyLocked(c)
+	for _, x := range h.teamQueue {
+		if x == c {
+			h.mu.Unlock()
+			return
+		}
+	}
+	h.teamQueue = append(h.teamQueue, c)
+
+	rooms := h.matchTeamQueueLocked()
+	h.sendTeamQueueStatusLocked()
+	h.mu.Unlock()
+
+	for _, r := range rooms {
+		launchRoom(r)
+	}
+}
+
+// matchTeamQueueLocked starts a room for every four players waiting, in
+// queue order. A party pair counts as one entry of two and is always seated
+// on the same side. h.mu must be held.
+func (h *Hub) matchTeamQueueLocked() []*Room {
+	var rooms []*Room
+	for {
+		var pairs [][2]*client
+		var solos []*client
+		taken := map[*client]bool{}
+		n := 0
+		for _, c := range h.teamQueue {
+			if taken[c] || n == 2*teamSize {
+				continue
+			}
+			if mate := h.queuedPartyMateLocked(c); mate != nil {
+				if n+2 <= 2*teamSize {
+					pairs = append(pairs, [2]*client{c, mate})
+					taken[c], taken[mate] = true, true
+					n += 2
+				}
+				continue
+			}
+			solos = append(solos, c)
+			taken[c] = true
+			n++
+		}
+		if n < 2*teamSize {
+			return rooms
+		}
+
+		var seats []*client
+		switch len(pairs) {
+		case 2:
+			seats = []*client{pairs[0][0], pairs[1][0], pairs[0][1], pairs[1][1]}
+		case 1:
+			seats = []*client{pairs[0][0], solos[0], pairs[0][1], solos[1]}
+		default:
+			seats = h.balancedSeatsLocked(solos)
+		}
+		if busy := h.busySeatLocked(seats); busy != nil {
+			// started another battle while queued: out of the queue, match again
+			h.removeFromTeamQueueLocked(busy)
+			sendJSON(busy, "Error", protocol.ErrorMsg{Message: "You are already in a battle"})
+			continue
+		}
+		rest := make([]*client, 0, len(h.teamQueue))
+		for _, c := range h.teamQueue {
+			if !taken[c] {
+				rest = append(rest, c)
+			}
+		}
+		h.teamQueue = rest
+		for _, c := range seats {
+			if p := h.partyOfLocked(c); p != nil && p.queue == "team" {
+				p.queue = ""
+			}
+		}
+		rooms = append(rooms, h.startTeamRoomLocked("team", "pvp-team", seats))
+	}
+}
+
+// DequeueTeam removes c from the 2v2 queue (with its party partner, if they
+// queued together).
+func (h *Hub) DequeueTeam(c *client) {
+	h.mu.Lock()
+	if p := h.partyOfLocked(c); p != nil && p.queue == "team" {
+		h.cancelPartyQueueLocked(p)
+		h.broadcastPartyLocked(p)
+	} else if h.removeFromTeamQueueLocked(c) {
+		sendJSON(c, "TeamQueueStatus", protocol.TeamQueueStatus{})
+		h.sendTeamQueueStatusLocked()
+	}
+	h.mu.Unlock()
+}
+
+func (h *Hub) removeFromTeamQueueLocked(c *client) bool {
+	for i, x := range h.teamQueue {
+		if x == c {
+			h.teamQueue = append(h.teamQueue[:i], h.teamQueue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Hub) sendTeamQueueStatusLocked() {
+	st := protocol.TeamQueueStatus{Queued: true, Waiting: len(h.teamQueue)}
+	for _, c := range h.teamQueue {
+		sendJSON(c, "TeamQueueStatus", st)
+	}
+}
+
+// balancedSeatsLocked orders four players so the strongest and weakest team
+// up against the middle two: ratings 1+4 vs 2+3.
+func (h *Hub) balancedSeatsLocked(four []*client) []*client {
+	rating := func(c *client) int {
+		if s := h.sessions[c]; s != nil {
+			return s.Profile.PvPRating
+		}
+		return 0
+	}
+	sort.SliceStable(four, func(i, j int) bool { return rating(four[i]) > rating(four[j]) })
+	return []*client{four[0], four[1], four[3], four[2]}
+}
+
+// ---- Friendly 2v2 lobby
+
+// TeamLobbyCreate opens a lobby with c as host (re-sends it if c already hosts one).
+func (h *Hub) TeamLobbyCreate(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if l := h.lobbyByClient[c]; l != nil && l.teams[0][0] == c {
+		sendJSON(c, "TeamLobbyState", h.teamLobbyStateLocked(l))
+		return
+	}
+	h.leaveTeamLobbyLocked(c)
+	h.removeFromTeamQueueLocked(c)
+
+	var code string
+	for {
+		code = genCode(6)
+		_, dup := h.friendly[code]
+		if _, taken := h.teamLobbies[code]; !taken && !dup {
+			break
+		}
+	}
+	l := &teamLobby{code: code}
+	l.teams[0] = []*client{c}
+	h.teamLobbies[code] = l
+	h.lobbyByClient[c] = l
+	sendJSON(c, "TeamLobbyState", h.teamLobbyStateLocked(l))
+}
+
+// TeamLobbyJoin seats c on its preferred side of the lobby and starts the
+// battle when the lobby is full.
+func (h *Hub) TeamLobbyJoin(c *client, m protocol.TeamLobbyJoin) {
+	h.mu.Lock()
+	l := h.teamLobbies[strings.ToUpper(strings.TrimSpace(m.Code))]
+	if l == nil {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Code not found"})
+		return
+	}
+	if c.room != nil && c.room.active {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "You are already in a battle"})
+		return
+	}
+	if h.lobbyByClient[c] == l && l.teams[0][0] == c {
+		st := h.teamLobbyStateLocked(l)
+		h.mu.Unlock()
+		sendJSON(c, "TeamLobbyState", st)
+		return
+	}
+	if h.lobbyByClient[c] != l {
+		h.leaveTeamLobbyLocked(c)
+	} else {
+		h.removeFromLobbyLocked(l, c) // switching sides
+	}
+	h.removeFromTeamQueueLocked(c)
+
+	team := 0
+	if m.Team == 1 {
+		team = 1
+	}
+	if len(l.teams[team]) >= teamSize {
+		team = 1 - team
+	}
+	if len(l.teams[team]) >= teamSize {
+		h.mu.Unlock()
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Lobby is full"})
+		return
+	}
+	l.teams[team] = append(l.teams[team], c)
+	h.lobbyByClient[c] = l
+	h.broadcastTeamLobbyLocked(l)
+
+	if !l.full() {
+		h.mu.Unlock()
+		return
+	}
+	seats := []*client{l.teams[0][0], l.teams[1][0], l.teams[0][1], l.teams[1][1]}
+	if busy := h.busySeatLocked(seats); busy != nil {
+		h.leaveTeamLobbyLocked(busy)
+		h.mu.Unlock()
+		sendJSON(busy, "TeamLobbyClosed", protocol.TeamLobbyClosed{Reason: "You are already in a battle"})
+		return
+	}
+	delete(h.teamLobbies, l.code)
+	for _, x := range seats {
+		delete(h.lobbyByClient, x)
+	}
+	r := h.startTeamRoomLocked("friendly", "frd-team", seats)
+	h.mu.Unlock()
+
+	launchRoom(r)
+}
+
+// TeamLobbyLeave takes c out of its lobby.
+func (h *Hub) TeamLobbyLeave(c *client) {
+	h.mu.Lock()
+	h.leaveTeamLobbyLocked(c)
+	h.mu.Unlock()
+	sendJSON(c, "TeamLobbyClosed", protocol.TeamLobbyClosed{})
+}
+
+// leaveTeamLobbyLocked removes c from its lobby; the lobby closes when the
+// host leaves. h.mu must be held.
+func (h *Hub) leaveTeamLobbyLocked(c *client) {
+	l := h.lobbyByClient[c]
+	if l == nil {
+		return
+	}
+	delete(h.lobbyByClient, c)
+	if l.teams[0][0] == c {
+		delete(h.teamLobbies, l.code)
+		for _, side := range l.teams {
+			for _, x := range side {
+				if x == c {
+					continue
+				}
+				delete(h.lobbyByClient, x)
+				sendJSON(x, "TeamLobbyClosed", protocol.TeamLobbyClosed{Reason: "The host left"})
+			}
+		}
+		return
+	}
+	h.removeFromLobbyLocked(l, c)
+	h.broadcastTeamLobbyLocked(l)
+}
+
+func (h *Hub) removeFromLobbyLocked(l *teamLobby, c *client) {
+	for t, side := range l.teams {
+		for i, x := range side {
+			if x == c {
+				l.teams[t] = append(side[:i], side[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// dropTeamStateLocked clears c from the 2v2 queue and lobbies on logout or
+// disconnect. h.mu must be held.
+func (h *Hub) dropTeamStateLocked(c *client) {
+	if h.removeFromTeamQueueLocked(c) {
+		h.sendTeamQueueStatusLocked()
+	}
+	h.leaveTeamLobbyLocked(c)
+}
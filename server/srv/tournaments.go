@@ -0,0 +1,492 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rumble/server/store"
+	"rumble/shared/protocol"
+)
+
+// tournamentNoShow is how long a player has to turn up for a ready match
+// before losing it by walkover.
+const tournamentNoShow = 3 * time.Minute
+
+// tournamentKeep is how long a finished or cancelled tournament stays listed.
+const tournamentKeep = 7 * 24 * time.Hour
+
+// Tournaments is the tournament store, persisted one tournament per key.
+type Tournaments struct {
+	mu    sync.Mutex
+	st    store.Store
+	items map[string]*protocol.Tournament
+}
+
+func NewTournaments(st store.Store) (*Tournaments, error) {
+	t := &Tournaments{st: st, items: map[string]*protocol.Tournament{}}
+	err := st.ForEach(store.Tournaments, func(key string, val []byte) error {
+		var tr protocol.Tournament
+		if err := json.Unmarshal(val, &tr); err != nil {
+			return fmt.Errorf("tournaments: %s: %w", key, err)
+		}
+		t.items[key] = &tr
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// saveLocked writes tr, the tournament that changed. t.mu must be held.
+func (t *Tournaments) saveLocked(tr *protocol.Tournament) {
+	if err := store.PutJSON(t.st, store.Tournaments, tr.ID, tr); err != nil {
+		log.Printf("tournaments: save %s: %v", tr.ID, err)
+	}
+}
+
+func (t *Tournaments) summariesLocked() []protocol.TournamentSummary {
+	out := []protocol.TournamentSummary{}
+	for _, tr := range t.items {
+		out = append(out, protocol.TournamentSummary{
+			ID: tr.ID, Name: tr.Name, Format: tr.Format, Status: tr.Status,
+			Players: len(tr.Players), MaxPlayers: tr.MaxPlayers,
+			SignupEnds: tr.SignupEnds, Champion: tr.Champion,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SignupEnds > out[j].SignupEnds })
+	return out
+}
+
+// HandleAPI serves GET /api/tournaments (summaries) and
+// /api/tournaments?id=... (one full bracket). It is read-only.
+func (t *Tournaments) HandleAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	t.mu.Lock()
+	var v interface{}
+	if id := r.URL.Query().Get("id"); id != "" {
+		tr := t.items[id]
+		if tr == nil {
+			t.mu.Unlock()
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		v = tr
+	} else {
+		v = protocol.TournamentList{Items: t.summariesLocked()}
+	}
+	b, err := json.Marshal(v)
+	t.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// ---- Signup
+
+// CreateTournament opens a new tournament for signups.
+func (h *Hub) CreateTournament(c *client, m protocol.CreateTournament) {
+	if h.tournaments == nil {
+		return
+	}
+	h.mu.Lock()
+	s := h.sessions[c]
+	by := ""
+	if s != nil {
+		by = s.Profile.Name
+	}
+	admin := h.isAdmin != nil && h.isAdmin(c.account)
+	h.mu.Unlock()
+	if by == "" {
+		return
+	}
+
+	name := strings.TrimSpace(m.Name)
+	if len(name) < 3 || len(name) > 40 {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Tournament name must be 3-40 characters"})
+		return
+	}
+	format := strings.ToLower(m.Format)
+	if format == "" {
+		format = "single"
+	}
+	if format != "single" && format != "double" {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Format must be single or double"})
+		return
+	}
+	signup := m.SignupMinutes
+	if signup <= 0 {
+		signup = 30
+	}
+	maxPlayers := m.MaxPlayers
+	if maxPlayers <= 0 {
+		maxPlayers = 16
+	}
+	if signup > 24*60 || maxPlayers < 2 || maxPlayers > 64 {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Signup must be at most a day and 2-64 players"})
+		return
+	}
+
+	t := h.tournaments
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// players run one tournament at a time; admins any number
+	if !admin {
+		for _, o := range t.items {
+			if strings.EqualFold(o.CreatedBy, by) && (o.Status == "signup" || o.Status == "running") {
+				sendJSON(c, "Error", protocol.ErrorMsg{Message: "You already have a tournament open: " + o.Name})
+				return
+			}
+		}
+	}
+
+	now := time.Now()
+	tr := &protocol.Tournament{
+		ID:         makeRoomID("tour"),
+		Name:       name,
+		Format:     format,
+		Status:     "signup",
+		CreatedBy:  by,
+		CreatedAt:  now.UnixMilli(),
+		SignupEnds: now.Add(time.Duration(signup) * time.Minute).UnixMilli(),
+		MaxPlayers: maxPlayers,
+		Players:    []protocol.TournamentPlayer{},
+	}
+	t.items[tr.ID] = tr
+	t.saveLocked(tr)
+	log.Printf("tournament %s %q created by %s (%s, signup %dm)", tr.ID, name, by, format, signup)
+	sendJSON(c, "TournamentState", protocol.TournamentState{Tournament: *tr})
+}
+
+// JoinTournament signs c up while the signup window is open.
+func (h *Hub) JoinTournament(c *client, id string) {
+	h.signup(c, id, true)
+}
+
+// LeaveTournament withdraws c before the bracket is drawn.
+func (h *Hub) LeaveTournament(c *client, id string) {
+	h.signup(c, id, false)
+}
+
+func (h *Hub) signup(c *client, id string, join bool) {
+	if h.tournaments == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.sessions[c]
+	if s == nil || s.Profile.Name == "" {
+		return
+	}
+	t := h.tournaments
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tr := t.items[id]
+	if tr == nil {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Tournament not found"})
+		return
+	}
+	if tr.Status != "signup" {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Signups are closed"})
+		return
+	}
+	idx := -1
+	for i, p := range tr.Players {
+		if strings.EqualFold(p.Name, s.Profile.Name) {
+			idx = i
+		}
+	}
+	switch {
+	case join && idx >= 0, !join && idx < 0:
+	case join && len(tr.Players) >= tr.MaxPlayers:
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Tournament is full"})
+		return
+	case join:
+		tr.Players = append(tr.Players, protocol.TournamentPlayer{Name: s.Profile.Name, Rating: s.Profile.PvPRating})
+		t.saveLocked(tr)
+	default:
+		tr.Players = append(tr.Players[:idx], tr.Players[idx+1:]...)
+		t.saveLocked(tr)
+	}
+	sendJSON(c, "TournamentState", protocol.TournamentState{Tournament: *tr})
+}
+
+// ---- Running tournaments
+
+// RunTournaments closes signups, starts match rooms and settles no-shows.
+func (h *Hub) RunTournaments() {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.mu.Lock()
+		draining := h.draining
+		h.mu.Unlock()
+		if !draining { // no new tournament matches while shutting down
+			h.tournamentTick(time.Now())
+		}
+	}
+}
+
+func (h *Hub) tournamentTick(now time.Time) {
+	if h.tournaments == nil {
+		return
+	}
+	var launch []*Room
+	h.mu.Lock()
+	t := h.tournaments
+	t.mu.Lock()
+	for id, tr := range t.items {
+		changed := false
+		switch tr.Status {
+		case "finished", "cancelled":
+			if tr.EndedAt == 0 {
+				tr.EndedAt = now.UnixMilli()
+				t.saveLocked(tr)
+			} else if now.Sub(time.UnixMilli(tr.EndedAt)) > tournamentKeep {
+				delete(t.items, id)
+				if err := t.st.Delete(store.Tournaments, id); err != nil {
+					log.Printf("tournaments: expire %s: %v", id, err)
+				}
+			}
+		case "signup":
+			if now.UnixMilli() >= tr.SignupEnds {
+				h.drawBracketLocked(tr, now)
+				changed = true
+			}
+		case "running":
+			for id := range tr.Matches {
+				m := &tr.Matches[id]
+				switch m.Status {
+				case "playing":
+					if h.rooms[m.RoomID] == nil {
+						// both players left the room without a result
+						m.Status, m.RoomID, m.ReadyAt = "ready", "", now.UnixMilli()
+						changed = true
+					}
+				case "ready":
+					a, b := h.tournamentClientLocked(m.A), h.tournamentClientLocked(m.B)
+					if a != nil && b != nil {
+						if r := h.startTournamentRoomLocked(tr, m, a, b); r != nil {
+							launch = append(launch, r)
+							changed = true
+						}
+					} else if now.UnixMilli()-m.ReadyAt > tournamentNoShow.Milliseconds() {
+						h.walkoverLocked(tr, id, a != nil, b != nil, now)
+						changed = true
+					}
+				}
+				if tr.Status != "running" {
+					break
+				}
+			}
+		}
+		if changed {
+			h.broadcastTournamentLocked(tr)
+			t.saveLocked(tr)
+		}
+	}
+	t.mu.Unlock()
+	h.mu.Unlock()
+
+	for _, r := range launch {
+		launchRoom(r)
+	}
+}
+
+// drawBracketLocked seeds the signed-up players by rating and builds the
+// bracket (or cancels the tournament if fewer than two signed up).
+func (h *Hub) drawBracketLocked(tr *protocol.Tournament, now time.Time) {
+	if len(tr.Players) < 2 {
+		tr.Status = "cancelled"
+		log.Printf("tournament %s cancelled: %d players", tr.ID, len(tr.Players))
+		return
+	}
+	for i := range tr.Players {
+		p := &tr.Players[i]
+		if c := h.clientByNameLocked(p.Name); c != nil && h.sessions[c] != nil {
+			p.Rating = h.sessions[c].Profile.PvPRating
+		} else if prof, err := h.profiles.Load(p.Name); err == nil {
+			p.Rating = prof.PvPRating
+		}
+	}
+	sort.SliceStable(tr.Players, func(i, j int) bool { return tr.Players[i].Rating > tr.Players[j].Rating })
+	for i := range tr.Players {
+		tr.Players[i].Seed = i + 1
+	}
+	tr.Matches = buildBracket(tr.Players, tr.Format == "double")
+	tr.Status = "running"
+	log.Printf("tournament %s started: %d players, %d matches", tr.ID, len(tr.Players), len(tr.Matches))
+	h.announceReadyLocked(tr, settleBracket(tr, now))
+}
+
+// tournamentClientLocked returns name's client if they can start a match
+// right now: online, not in a running battle and not drafting.
+func (h *Hub) tournamentClientLocked(name string) *client {
+	c := h.clientByNameLocked(name)
+	if c == nil || (c.room != nil && c.room.active) || h.drafts[c] != nil {
+		return nil
+	}
+	return c
+}
+
+// startTournamentRoomLocked seats a and b for match m. It returns nil and
+// leaves the match ready if either is mid-battle. h.mu must be held.
+func (h *Hub) startTournamentRoomLocked(tr *protocol.Tournament, m *protocol.TournamentMatch, a, b *client) *Room {
+	if h.busySeatLocked([]*client{a, b}) != nil {
+		return nil
+	}
+	h.leaveIdleRoomLocked(a)
+	h.leaveIdleRoomLocked(b)
+	roomID := makeRoomID("pvp-tour")
+	r := NewRoom(roomID, h)
+	r.Mode = "tournament"
+	r.tourney = &tourneyRef{id: tr.ID, match: m.ID}
+	r.g.SetMapDef(randomDuelMap())
+	h.rooms[roomID] = r
+	for _, c := range []*client{a, b} {
+		if s := h.sessions[c]; s != nil {
+			r.JoinClient(c, s)
+			s.RoomID = roomID
+		}
+	}
+	m.Status, m.RoomID = "playing", roomID
+	return r
+}
+
+// walkoverLocked settles a no-show: the player who turned up wins; if
+// neither did, the better seed goes through.
+func (h *Hub) walkoverLocked(tr *protocol.Tournament, id int, aHere, bHere bool, now time.Time) {
+	m := tr.Matches[id]
+	winner := m.A
+	switch {
+	case bHere && !aHere:
+		winner = m.B
+	case !aHere && !bHere && seedOf(tr, m.B) < seedOf(tr, m.A):
+		winner = m.B
+	}
+	log.Printf("tournament %s match %d: walkover to %s", tr.ID, id, winner)
+	resolveMatch(tr, id, winner, true)
+	h.announceReadyLocked(tr, settleBracket(tr, now))
+}
+
+func seedOf(tr *protocol.Tournament, name string) int {
+	for _, p := range tr.Players {
+		if p.Name == name {
+			return p.Seed
+		}
+	}
+	return len(tr.Players) + 1
+}
+
+func (h *Hub) announceReadyLocked(tr *protocol.Tournament, ids []int) {
+	for _, id := range ids {
+		m := tr.Matches[id]
+		for _, pair := range [][2]string{{m.A, m.B}, {m.B, m.A}} {
+			if c := h.clientByNameLocked(pair[0]); c != nil {
+				sendJSON(c, "TournamentMatchReady", protocol.TournamentMatchReady{
+					TournamentID: tr.ID, MatchID: id, Opponent: pair[1],
+					ExpiresIn: int(tournamentNoShow / time.Second),
+				})
+			}
+		}
+	}
+}
+
+func (h *Hub) broadcastTournamentLocked(tr *protocol.Tournament) {
+	st := protocol.TournamentState{Tournament: *tr}
+	for _, p := range tr.Players {
+		if c := h.clientByNameLocked(p.Name); c != nil {
+			sendJSON(c, "TournamentState", st)
+		}
+	}
+}
+
+// tourneyRef ties a room to the tournament match it decides.
+type tourneyRef struct {
+	id    string
+	match int
+}
+
+// recordTournamentResult advances the bracket when a tournament room ends.
+// A draw is replayed.
+func (h *Hub) recordTournamentResult(r *Room, winnerID int64) {
+	if h.tournaments == nil || r.tourney == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	winner := ""
+	for _, c := range r.players {
+		if s := h.sessions[c]; s != nil && c.id == winnerID {
+			winner = s.Profile.Name
+		}
+	}
+	t := h.tournaments
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tr := t.items[r.tourney.id]
+	if tr == nil || tr.Status != "running" || r.tourney.match >= len(tr.Matches) {
+		return
+	}
+	m := &tr.Matches[r.tourney.match]
+	if m.RoomID != r.id || m.Status != "playing" {
+		return
+	}
+	now := time.Now()
+	if winner == "" || (winner != m.A && winner != m.B) {
+		m.Status, m.RoomID, m.ReadyAt = "ready", "", now.UnixMilli()
+		h.announceReadyLocked(tr, []int{m.ID})
+	} else {
+		resolveMatch(tr, m.ID, winner, false)
+		h.announceReadyLocked(tr, settleBracket(tr, now))
+		if tr.Status == "finished" {
+			log.Printf("tournament %s won by %s", tr.ID, tr.Champion)
+		}
+	}
+	h.broadcastTournamentLocked(tr)
+	t.saveLocked(tr)
+}
+
+// ListTournaments and GetTournament answer the WebSocket queries.
+func (h *Hub) ListTournaments(c *client) {
+	if h.tournaments == nil {
+		sendJSON(c, "TournamentList", protocol.TournamentList{Items: []protocol.TournamentSummary{}})
+		return
+	}
+	t := h.tournaments
+	t.mu.Lock()
+	list := protocol.TournamentList{Items: t.summariesLocked()}
+	t.mu.Unlock()
+	sendJSON(c, "TournamentList", list)
+}
+
+func (h *Hub) GetTournament(c *client, id string) {
+	if h.tournaments == nil {
+		return
+	}
+	t := h.tournaments
+	t.mu.Lock()
+	tr := t.items[id]
+	var st protocol.TournamentState
+	if tr != nil {
+		st.Tournament = *tr
+	}
+	t.mu.Unlock()
+	if tr == nil {
+		sendJSON(c, "Error", protocol.ErrorMsg{Message: "Tournament not found"})
+		return
+	}
+	sendJSON(c, "TournamentState", st)
+}
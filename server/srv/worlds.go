@@ -0,0 +1,185 @@
+package srv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"rumble/server/store"
+	"rumble/shared/protocol"
+)
+
+const defaultWorldID = "rumble_world"
+
+var worldsDir = filepath.Join(dataDir, "worlds")
+
+func ensureWorldsDir() error     { return os.MkdirAll(worldsDir, 0o755) }
+func worldPath(id string) string { return filepath.Join(worldsDir, id+".json") }
+
+// validWorldID keeps a world ID inside data/worlds.
+func validWorldID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, `/\`) && !strings.Contains(id, "..")
+}
+
+var errBadWorldID = errors.New("invalid world id")
+
+// builtinWorld is the overview map shipped with the game, used when
+// data/worlds has no rumble_world.json.
+func builtinWorld() protocol.WorldDef {
+	return protocol.WorldDef{
+		ID:   defaultWorldID,
+		Name: "Rumble World",
+		Hotspots: []protocol.WorldHotspot{
+			{ID: "spawn_west", Name: "Western Keep", Info: "Good for melee rush.", TargetMapID: "west_keep",
+				Rect: protocol.WorldRect{Left: 0.095, Top: 0.547, Right: 0.155, Bottom: 0.607}},
+			{ID: "spawn_east", Name: "Eastern Gate", Info: "Open field, risky.", TargetMapID: "east_gate",
+				Rect: protocol.WorldRect{Left: 0.602, Top: 0.407, Right: 0.662, Bottom: 0.467}},
+			{ID: "mid_bridge", Name: "Central Bridge", Info: "Choke point.", TargetMapID: "mid_bridge",
+				Rect: protocol.WorldRect{Left: 0.252, Top: 0.132, Right: 0.312, Bottom: 0.192}},
+			{ID: "north_tower", Name: "North Tower", Info: "High ground.", TargetMapID: "north_tower",
+				Rect: protocol.WorldRect{Left: 0.670, Top: 0.110, Right: 0.730, Bottom: 0.170}},
+			{ID: "south_gate", Name: "South Gate", Info: "Wide approach.", TargetMapID: "south_gate",
+				Rect: protocol.WorldRect{Left: 0.327, Top: 0.705, Right: 0.387, Bottom: 0.765}},
+		},
+	}
+}
+
+// loadWorldDef reads data/worlds/<id>.json. Like maps, worlds are read fresh
+// on every call so edits show up without a restart.
+func loadWorldDef(id string) (protocol.WorldDef, error) {
+	if !validWorldID(id) {
+		return protocol.WorldDef{}, errBadWorldID
+	}
+	_ = ensureWorldsDir()
+	b, err := os.ReadFile(worldPath(id))
+	if err != nil {
+		if id == defaultWorldID {
+			return builtinWorld(), nil
+		}
+		return protocol.WorldDef{}, err
+	}
+	var def protocol.WorldDef
+	if err := json.Unmarshal(b, &def); err != nil {
+		return protocol.WorldDef{}, err
+	}
+	if def.ID == "" {
+		def.ID = id
+	}
+	if strings.TrimSpace(def.Name) == "" {
+		def.Name = id
+	}
+	return def, nil
+}
+
+// listWorlds returns every saved world plus the built-in one.
+func listWorlds() []protocol.MapInfo {
+	_ = ensureWorldsDir()
+	out := []protocol.MapInfo{}
+	seenDefault := false
+	_ = filepath.WalkDir(worldsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		def, err := loadWorldDef(id)
+		if err != nil {
+			return nil
+		}
+		if def.ID == defaultWorldID {
+			seenDefault = true
+		}
+		out = append(out, protocol.MapInfo{ID: def.ID, Name: def.Name})
+		return nil
+	})
+	if !seenDefault {
+		w := builtinWorld()
+		out = append(out, protocol.MapInfo{ID: w.ID, Name: w.Name})
+	}
+	return out
+}
+
+// saveWorldDef writes def, deriving a missing ID from the name, and returns
+// what was stored.
+func saveWorldDef(def protocol.WorldDef) (protocol.WorldDef, error) {
+	_ = ensureWorldsDir()
+	id := strings.TrimSpace(def.ID)
+	if id == "" {
+		id = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(def.Name)), " ", "_")
+	}
+	if id == "" {
+		id = "world"
+	}
+	if !validWorldID(id) {
+		return def, errBadWorldID
+	}
+	def.ID = id
+	b, err := json.MarshalIndent(def, "", "  ")
+	if err != nil {
+		return def, err
+	}
+	err = store.WriteFile(worldPath(id), b, 0o644)
+	worldGates.Lock()
+	worldGates.req = nil
+	worldGates.Unlock()
+	return def, err
+}
+
+// worldGates caches what worldUnlocked needs from the world files: the
+// campaign maps each hotspot target requires (lower-case map ID). It is
+// rebuilt after a save or when any world file is added, removed or edited.
+var worldGates struct {
+	sync.Mutex
+	stamp string
+	req   map[string][]string
+}
+
+// worldsStamp lists the name, size and modification time of every file in
+// the worlds directory, so an edit in place shows up as well.
+func worldsStamp() string {
+	entries, _ := os.ReadDir(worldsDir)
+	var b strings.Builder
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil {
+			fmt.Fprintf(&b, "%s %d %d\n", e.Name(), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
+
+func loadWorldGates() map[string][]string {
+	worldGates.Lock()
+	defer worldGates.Unlock()
+	stamp := worldsStamp()
+	if worldGates.req != nil && stamp == worldGates.stamp {
+		return worldGates.req
+	}
+	req := map[string][]string{}
+	for _, w := range listWorlds() {
+		def, err := loadWorldDef(w.ID)
+		if err != nil {
+			continue
+		}
+		for _, hs := range def.Hotspots {
+			id := strings.ToLower(hs.TargetMapID)
+			req[id] = append(req[id], hs.Requires...)
+		}
+	}
+	worldGates.req, worldGates.stamp = req, stamp
+	return req
+}
+
+// worldUnlocked checks the Requires of every world hotspot that launches
+// mapID. Maps no hotspot points at are always allowed.
+func worldUnlocked(mapID string, prof protocol.Profile) bool {
+	for _, req := range loadWorldGates()[strings.ToLower(mapID)] {
+		if prof.Campaign[req].Stars < 1 {
+			return false
+		}
+	}
+	return true
+}
@@ -9,8 +9,7 @@ import (
 
 // xpTable returns per-level XP required for levels 1->2, ..., 19->20.
 func xpTable() []int {
-	// Try to read from server/data/xp_levels.json
-	dataDir := filepath.Join("server", "data")
+	// Try to read from <data dir>/xp_levels.json
 	p := filepath.Join(dataDir, "xp_levels.json")
 	if b, err := os.ReadFile(p); err == nil {
 		var arr []int
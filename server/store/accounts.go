@@ -0,0 +1,52 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Accounts: every player has a stable account ID, and bucket Names maps the
+// lower-cased display name to it. Profiles are stored under the ID, so two
+// names can never end up sharing one.
+
+var accountMu sync.Mutex // serializes EnsureAccount so a name gets one ID
+
+// NewAccountID returns a fresh random account ID ("p" + 16 hex digits).
+func NewAccountID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "p" + hex.EncodeToString(b)
+}
+
+// NameKey is the Names key for a display name.
+func NameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// LookupAccount returns the account ID bound to name, or ErrNotFound.
+func LookupAccount(s Store, name string) (string, error) {
+	var id string
+	if err := GetJSON(s, Names, NameKey(name), &id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// EnsureAccount returns the account ID bound to name, binding a new one if
+// there is none yet.
+func EnsureAccount(s Store, name string) (string, error) {
+	accountMu.Lock()
+	defer accountMu.Unlock()
+	id, err := LookupAccount(s, name)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+	id = NewAccountID()
+	return id, PutJSON(s, Names, NameKey(name), id)
+}
@@ -0,0 +1,221 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// jsonFiles are the buckets kept as one map-shaped file each, rewritten in
+// full on every change. Profiles get a file per player instead (dirBuckets).
+var dirBuckets = map[string]bool{Players: true, Profiles: true}
+
+var jsonFiles = map[string]string{
+	Users:       "users.json",
+	Names:       "names.json",
+	Guilds:      "guilds.json",
+	Friends:     "friends.json",
+	DMs:         "messages.json",
+	Matches:     "matches.json",
+	Tournaments: "tournaments.json",
+}
+
+// JSONStore is the data/ directory layout the server has always used:
+// data/players/<account ID>.json (data/profiles/<name>.json before account
+// IDs) plus users.json, names.json, guilds.json, friends.json, messages.json
+// and tournaments.json.
+type JSONStore struct {
+	dir   string
+	lock  *os.File // data/.lock, held while open
+	mu    sync.Mutex
+	files map[string]map[string]json.RawMessage // loaded map files by bucket
+}
+
+// jsonLockFile keeps a second process (the server, rumbleadmin, migrate)
+// from opening the directory while another has it open; the server caches
+// what it reads and would overwrite the other's writes.
+const jsonLockFile = ".lock"
+
+var errInUse = errors.New("store: in use by another process (is the server running?)")
+
+func OpenJSON(dir string) (*JSONStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, Players), 0o755); err != nil {
+		return nil, err
+	}
+	lock, err := os.OpenFile(filepath.Join(dir, jsonLockFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(lock); err != nil {
+		_ = lock.Close()
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	return &JSONStore{dir: dir, lock: lock, files: map[string]map[string]json.RawMessage{}}, nil
+}
+
+func (s *JSONStore) entryPath(bucket, key string) string {
+	return filepath.Join(s.dir, bucket, SafeFileName(key)+".json")
+}
+
+// fileLocked returns the contents of a map file, reading it on first use.
+// s.mu must be held.
+func (s *JSONStore) fileLocked(bucket string) (map[string]json.RawMessage, error) {
+	if m := s.files[bucket]; m != nil {
+		return m, nil
+	}
+	name, ok := jsonFiles[bucket]
+	if !ok {
+		return nil, errors.New("store: unknown bucket " + bucket)
+	}
+	m := map[string]json.RawMessage{}
+	b, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err == nil && len(b) > 0 {
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	s.files[bucket] = m
+	return m, nil
+}
+
+// writeLocked rewrites a map file. s.mu must be held.
+func (s *JSONStore) writeLocked(bucket string, m map[string]json.RawMessage) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(0o644)
+	if bucket == Users {
+		perm = 0o600
+	}
+	return WriteFile(filepath.Join(s.dir, jsonFiles[bucket]), b, perm)
+}
+
+func (s *JSONStore) Get(bucket, key string) ([]byte, error) {
+	if dirBuckets[bucket] {
+		b, err := os.ReadFile(s.entryPath(bucket, key))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return b, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.fileLocked(bucket)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := m[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), v...), nil
+}
+
+func (s *JSONStore) Put(bucket, key string, val []byte) error {
+	if dirBuckets[bucket] {
+		indented, err := indentJSON(val)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Join(s.dir, bucket), 0o755); err != nil {
+			return err
+		}
+		return WriteFile(s.entryPath(bucket, key), indented, 0o644)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.fileLocked(bucket)
+	if err != nil {
+		return err
+	}
+	m[key] = append(json.RawMessage(nil), val...)
+	return s.writeLocked(bucket, m)
+}
+
+func (s *JSONStore) Delete(bucket, key string) error {
+	if dirBuckets[bucket] {
+		err := os.Remove(s.entryPath(bucket, key))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.fileLocked(bucket)
+	if err != nil {
+		return err
+	}
+	if _, ok := m[key]; !ok {
+		return nil
+	}
+	delete(m, key)
+	return s.writeLocked(bucket, m)
+}
+
+// ForEach works on a copy, so fn is free to write back. Legacy profiles are
+// keyed by the name inside the file, falling back to the file name stem.
+func (s *JSONStore) ForEach(bucket string, fn func(key string, val []byte) error) error {
+	entries := map[string][]byte{}
+	if dirBuckets[bucket] {
+		files, err := os.ReadDir(filepath.Join(s.dir, bucket))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		for _, f := range files {
+			if f.IsDir() || !strings.HasSuffix(strings.ToLower(f.Name()), ".json") {
+				continue
+			}
+			b, err := os.ReadFile(filepath.Join(s.dir, bucket, f.Name()))
+			if err != nil {
+				continue
+			}
+			key := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+			var named struct {
+				Name string `json:"name"`
+			}
+			if bucket == Profiles && json.Unmarshal(b, &named) == nil && named.Name != "" {
+				key = named.Name
+			}
+			entries[key] = b
+		}
+	} else {
+		s.mu.Lock()
+		m, err := s.fileLocked(bucket)
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		for k, v := range m {
+			entries[k] = append([]byte(nil), v...)
+		}
+		s.mu.Unlock()
+	}
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn(k, entries[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *JSONStore) Close() error { return s.lock.Close() }
+
+// indentJSON keeps profile files as readable as saveProfile always wrote them.
+func indentJSON(b []byte) ([]byte, error) {
+	var v json.RawMessage = b
+	return json.MarshalIndent(v, "", "  ")
+}
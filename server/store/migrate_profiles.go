@@ -0,0 +1,117 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ProfileCollision is a set of registered names that mapped to the same
+// name-keyed profile file and so shared one profile.
+type ProfileCollision struct {
+	File  string   // file name stem the names shared
+	Names []string // the registered users
+	Owner string   // who keeps the stored profile ("" if none of them wrote it)
+}
+
+type ProfileMigration struct {
+	Moved      int      // profiles re-keyed by account ID
+	Skipped    []string // legacy profiles whose account already has one
+	Collisions []ProfileCollision
+}
+
+// MigrateProfiles moves name-keyed profiles (bucket Profiles) to their
+// account ID (bucket Players) and reports registered names that used to
+// share a profile. The stored profile stays with the user whose name it
+// carries; the others start fresh. With dryRun nothing is written.
+func MigrateProfiles(s Store, dryRun bool) (ProfileMigration, error) {
+	var rep ProfileMigration
+
+	groups := map[string][]string{} // file stem -> registered names
+	err := s.ForEach(Users, func(key string, val []byte) error {
+		var u struct {
+			Username string `json:"username"`
+		}
+		_ = json.Unmarshal(val, &u)
+		if u.Username == "" {
+			u.Username = key
+		}
+		stem := SafeFileName(u.Username)
+		groups[stem] = append(groups[stem], u.Username)
+		return nil
+	})
+	if err != nil {
+		return rep, err
+	}
+
+	writers := map[string]string{} // file stem -> name inside the profile
+	moved := map[string]bool{}     // account IDs given a profile in this run
+	err = s.ForEach(Profiles, func(name string, val []byte) error {
+		writers[SafeFileName(name)] = name
+		var id string
+		var err error
+		if dryRun {
+			// the real run binds an account to a name that has none
+			id, err = LookupAccount(s, name)
+			if errors.Is(err, ErrNotFound) {
+				id, err = "new:"+NameKey(name), nil
+			}
+		} else {
+			id, err = EnsureAccount(s, name)
+		}
+		if err != nil {
+			return err
+		}
+		if moved[id] {
+			rep.Skipped = append(rep.Skipped, name)
+			return nil
+		}
+		if _, err := s.Get(Players, id); err == nil {
+			rep.Skipped = append(rep.Skipped, name)
+			return nil
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(val, &m); err != nil {
+			rep.Skipped = append(rep.Skipped, name)
+			return nil
+		}
+		if dryRun {
+			moved[id] = true
+			rep.Moved++
+			return nil
+		}
+		m["accountId"], _ = json.Marshal(id)
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if err := s.Put(Players, id, b); err != nil {
+			return err
+		}
+		moved[id] = true
+		rep.Moved++
+		return s.Delete(Profiles, name)
+	})
+	if err != nil {
+		return rep, err
+	}
+
+	for stem, names := range groups {
+		if len(names) < 2 || writers[stem] == "" {
+			continue // no shared file, nothing was lost
+		}
+		sort.Strings(names)
+		c := ProfileCollision{File: stem + ".json", Names: names}
+		for _, n := range names {
+			if strings.EqualFold(n, writers[stem]) {
+				c.Owner = n
+			}
+		}
+		rep.Collisions = append(rep.Collisions, c)
+	}
+	sort.Slice(rep.Collisions, func(i, j int) bool { return rep.Collisions[i].File < rep.Collisions[j].File })
+	return rep, nil
+}
@@ -0,0 +1,241 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Snapshots are timestamped copies of the whole data directory kept under a
+// separate directory, named data-YYYYMMDD-HHMMSS. Snapshot keeps the newest
+// N; Restore rolls the data directory back to one of them.
+
+const (
+	snapshotPrefix = "data-"
+	snapshotLayout = "20060102-150405"
+	// snapshotDB holds the database file of a BoltStore kept outside the
+	// data directory; Restore puts it back at the configured path.
+	snapshotDB = "_database.db"
+)
+
+// Snapshot copies dataDir into a new snapshot under snapDir and deletes all
+// but the newest keep snapshots (keep <= 0 keeps everything). If st is a
+// BoltStore, its file is copied from a read transaction so the copy is
+// consistent while the server runs: in place when it lies inside dataDir,
+// else as snapshotDB at the top of the snapshot.
+func Snapshot(st Store, dataDir, snapDir string, keep int) (string, error) {
+	if err := os.MkdirAll(snapDir, 0o755); err != nil {
+		return "", err
+	}
+	name := snapshotPrefix + time.Now().Format(snapshotLayout)
+	final := filepath.Join(snapDir, name)
+	if _, err := os.Stat(final); err == nil {
+		return "", fmt.Errorf("snapshot %s already exists", name)
+	}
+	// Copy into a hidden directory first so a half-written snapshot is never
+	// listed or restored.
+	partial := filepath.Join(snapDir, ".partial-"+name)
+	_ = os.RemoveAll(partial)
+
+	var db *bolt.DB
+	if bs, ok := st.(*BoltStore); ok {
+		db = bs.db
+	}
+	if err := copyTree(dataDir, partial, snapDir, db); err != nil {
+		_ = os.RemoveAll(partial)
+		return "", err
+	}
+	if db != nil && !within(dataDir, db.Path()) {
+		err := db.View(func(tx *bolt.Tx) error { return tx.CopyFile(filepath.Join(partial, snapshotDB), 0o600) })
+		if err != nil {
+			_ = os.RemoveAll(partial)
+			return "", fmt.Errorf("copy database %s: %w", db.Path(), err)
+		}
+	}
+	if err := os.Rename(partial, final); err != nil {
+		_ = os.RemoveAll(partial)
+		return "", err
+	}
+	syncDir(snapDir)
+
+	if keep > 0 {
+		names, err := ListSnapshots(snapDir)
+		if err != nil {
+			return name, err
+		}
+		for _, old := range names[min(keep, len(names)):] {
+			if err := os.RemoveAll(filepath.Join(snapDir, old)); err != nil {
+				return name, err
+			}
+		}
+	}
+	return name, nil
+}
+
+// ListSnapshots returns the snapshot names in snapDir, newest first.
+func ListSnapshots(snapDir string) ([]string, error) {
+	entries, err := os.ReadDir(snapDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), snapshotPrefix) {
+			continue
+		}
+		if _, err := time.Parse(snapshotLayout, strings.TrimPrefix(e.Name(), snapshotPrefix)); err != nil {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	// the timestamp layout sorts lexically
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+// within reports whether path lies inside dir.
+func within(dir, path string) bool {
+	dirAbs, _ := filepath.Abs(dir)
+	abs, _ := filepath.Abs(path)
+	rel, err := filepath.Rel(dirAbs, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Restore replaces dataDir with a copy of snapshot name ("latest" for the
+// newest). A database file the snapshot took from outside dataDir goes back
+// to dbPath. What was there before is moved aside, not deleted; the new
+// paths are returned. The server must not be running.
+func Restore(dataDir, dbPath, snapDir, name string) ([]string, error) {
+	if name == "latest" {
+		names, err := ListSnapshots(snapDir)
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, errors.New("no snapshots in " + snapDir)
+		}
+		name = names[0]
+	}
+	src := filepath.Join(snapDir, filepath.Base(name))
+	if fi, err := os.Stat(src); err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("no snapshot %q in %s", name, snapDir)
+	}
+	srcDB := filepath.Join(src, snapshotDB)
+	hasDB := false
+	if _, err := os.Stat(srcDB); err == nil {
+		if dbPath == "" || within(dataDir, dbPath) {
+			return nil, fmt.Errorf("snapshot %s has a database from outside the data dir; give its path", name)
+		}
+		hasDB = true
+	}
+
+	restoring := dataDir + ".restoring"
+	_ = os.RemoveAll(restoring)
+	if err := copyTree(src, restoring, srcDB, nil); err != nil {
+		_ = os.RemoveAll(restoring)
+		return nil, err
+	}
+	stamp := time.Now().Format(snapshotLayout)
+	var aside []string
+	if _, err := os.Stat(dataDir); err == nil {
+		moved := dataDir + ".before-restore-" + stamp
+		if err := os.Rename(dataDir, moved); err != nil {
+			_ = os.RemoveAll(restoring)
+			return nil, err
+		}
+		aside = append(aside, moved)
+	}
+	if err := os.Rename(restoring, dataDir); err != nil {
+		return aside, err
+	}
+	syncDir(filepath.Dir(dataDir))
+
+	if hasDB {
+		if _, err := os.Stat(dbPath); err == nil {
+			moved := dbPath + ".before-restore-" + stamp
+			if err := os.Rename(dbPath, moved); err != nil {
+				return aside, err
+			}
+			aside = append(aside, moved)
+		}
+		if err := copyFile(srcDB, dbPath); err != nil {
+			return aside, err
+		}
+		syncDir(filepath.Dir(dbPath))
+	}
+	return aside, nil
+}
+
+// copyTree copies the directory src to dst, skipping skip (a directory or
+// file, when it lies inside src) and temp files. Files are fsynced. The file of db, if any, is
+// copied from a read transaction instead of byte for byte.
+func copyTree(src, dst, skip string, db *bolt.DB) error {
+	skipAbs, _ := filepath.Abs(skip)
+	dbAbs := ""
+	if db != nil {
+		dbAbs, _ = filepath.Abs(db.Path())
+	}
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		abs, _ := filepath.Abs(path)
+		if skip != "" && abs == skipAbs {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if strings.HasSuffix(d.Name(), ".tmp") || d.Name() == jsonLockFile || !d.Type().IsRegular() {
+			return nil
+		}
+		if abs == dbAbs {
+			return db.View(func(tx *bolt.Tx) error { return tx.CopyFile(target, 0o600) })
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,113 @@
+// Package store is where the server keeps its persistent state. Everything
+// goes through the Store interface, a small bucket/key/value API with JSON
+// values, so the backing layout can be swapped: the classic data/ directory
+// of JSON files, or a single embedded database file.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Buckets.
+const (
+	Players     = "players"     // account ID -> protocol.Profile
+	Names       = "names"       // lower-cased player name -> account ID
+	Profiles    = "profiles"    // legacy: player name -> protocol.Profile (see MigrateProfiles)
+	Users       = "users"       // lower-cased username -> auth user record
+	Guilds      = "guilds"      // guild ID -> guild
+	Friends     = "friends"     // lower-cased username -> set of friends
+	DMs         = "dms"         // conversation key -> []protocol.FriendDM
+	Matches     = "matches"     // match ID -> MatchRecord
+	Tournaments = "tournaments" // tournament ID -> protocol.Tournament
+)
+
+// Buckets lists every bucket, in the order Copy migrates them.
+var Buckets = []string{Users, Names, Players, Profiles, Guilds, Friends, DMs, Matches, Tournaments}
+
+// ErrNotFound is returned by Get when the key does not exist.
+var ErrNotFound = errors.New("store: not found")
+
+// Store is a set of named buckets holding JSON values.
+type Store interface {
+	// Get returns the value stored under key, or ErrNotFound.
+	Get(bucket, key string) ([]byte, error)
+	Put(bucket, key string, val []byte) error
+	Delete(bucket, key string) error
+	// ForEach calls fn for every key in bucket, in key order. fn may write
+	// to the store.
+	ForEach(bucket string, fn func(key string, val []byte) error) error
+	Close() error
+}
+
+// MatchRecord is the outcome of one rated match.
+type MatchRecord struct {
+	ID      string         `json:"id"`
+	Mode    string         `json:"mode"`
+	At      int64          `json:"at"` // Unix ms
+	Winners []string       `json:"winners"`
+	Losers  []string       `json:"losers"`
+	Deltas  map[string]int `json:"deltas,omitempty"` // rating change per player
+}
+
+// Open opens a store by kind: "json" takes the data directory, "bolt" the
+// database file.
+func Open(kind, path string) (Store, error) {
+	switch strings.ToLower(kind) {
+	case "", "json":
+		return OpenJSON(path)
+	case "bolt", "db":
+		return OpenBolt(path)
+	}
+	return nil, fmt.Errorf("store: unknown kind %q (want json or bolt)", kind)
+}
+
+// GetJSON decodes the value under key into v.
+func GetJSON(s Store, bucket, key string, v interface{}) error {
+	b, err := s.Get(bucket, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// PutJSON encodes v and stores it under key.
+func PutJSON(s Store, bucket, key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Put(bucket, key, b)
+}
+
+// Copy writes every entry of src into dst and returns how many entries each
+// bucket had.
+func Copy(dst, src Store) (map[string]int, error) {
+	counts := map[string]int{}
+	for _, bucket := range Buckets {
+		err := src.ForEach(bucket, func(key string, val []byte) error {
+			counts[bucket]++
+			return dst.Put(bucket, key, val)
+		})
+		if err != nil {
+			return counts, fmt.Errorf("%s: %w", bucket, err)
+		}
+	}
+	return counts, nil
+}
+
+var unsafeRun = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// SafeFileName is the file name stem a name-keyed profile was stored under
+// in the JSON layout. Distinct names can share a stem ("Bob.1", "Bob_1"),
+// which is why profiles are now keyed by account ID.
+func SafeFileName(name string) string {
+	s := unsafeRun.ReplaceAllString(name, "_")
+	if s == "" {
+		s = "player"
+	}
+	return s
+}
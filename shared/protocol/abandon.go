@@ -0,0 +1,13 @@
+package protocol
+
+// S->C: the other side left or went AFK; GameOver follows.
+type MatchAbandoned struct {
+	Names  []string `json:"names"`
+	Reason string   `json:"reason"` // "left" | "afk"
+}
+
+// S->C: a ranked queue request was refused because of a leaver cooldown.
+type QueueBlocked struct {
+	Seconds int    `json:"seconds"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,15 @@
+package protocol
+
+// S->C: a server-wide announcement from an operator, shown as a banner
+// until ExpiresAt (Unix ms). An empty Text clears the banner.
+type Announcement struct {
+	Text      string `json:"text"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// S->C: sent right before the server closes the connection of a kicked or
+// banned user.
+type Kicked struct {
+	Reason string `json:"reason"`
+	Banned bool   `json:"banned,omitempty"`
+}
@@ -0,0 +1,47 @@
+package protocol
+
+// PvE campaign progression
+
+// CampaignRecord is the per-map completion record persisted in Profile.Campaign.
+type CampaignRecord struct {
+	Stars     int   `json:"stars"`              // best result so far, 0..3
+	BestTime  int   `json:"bestTime,omitempty"` // fastest win in seconds
+	Clears    int   `json:"clears,omitempty"`   // number of wins on this map
+	ClearedAt int64 `json:"clearedAt,omitempty"`
+}
+
+// CampaignNode is the client view of one campaign map.
+type CampaignNode struct {
+	MapID    string   `json:"mapId"`
+	Name     string   `json:"name"`
+	Requires []string `json:"requires,omitempty"` // map IDs that must be cleared first
+	Unlocked bool     `json:"unlocked"`
+	Stars    int      `json:"stars"`
+	// Star criteria (shown in the world map tooltip)
+	BaseHPPct int  `json:"baseHpPct"` // 2nd star: own base HP >= this percent at the end
+	TimeUnder int  `json:"timeUnder"` // 3rd star: win in under this many seconds (any win if <= 0)
+	Timed     bool `json:"timed"`     // false: no clock, so no 3rd star
+	// First-clear reward
+	RewardGold int `json:"rewardGold,omitempty"`
+	RewardXP   int `json:"rewardXp,omitempty"`
+}
+
+// C->S
+type GetCampaign struct{}
+
+// S->C
+type CampaignState struct {
+	Nodes []CampaignNode `json:"nodes"`
+}
+
+// CampaignResult is sent after a campaign PvE match ends.
+type CampaignResult struct {
+	MapID      string   `json:"mapId"`
+	Won        bool     `json:"won"`
+	Stars      int      `json:"stars"`     // stars earned this run
+	BestStars  int      `json:"bestStars"` // best on record after this run
+	FirstClear bool     `json:"firstClear"`
+	RewardGold int      `json:"rewardGold,omitempty"`
+	RewardXP   int      `json:"rewardXp,omitempty"`
+	Unlocked   []string `json:"unlocked,omitempty"` // maps newly unlocked by this run
+}
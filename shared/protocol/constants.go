@@ -4,6 +4,11 @@ const (
 	ScreenW = 600
 	ScreenH = 1000
 
+	// World size, in world units, of maps that don't set WorldW/WorldH.
+	// It matches the screen so those maps keep their one-screen layout.
+	DefaultWorldW = 600
+	DefaultWorldH = 1000
+
 	// Net/update cadence
 	TickRate           = 20
 	SnapshotIntervalMs = 1000
@@ -0,0 +1,32 @@
+package protocol
+
+// Co-op PvE: two friends on the same side against the AI
+
+// C->S: invite a friend to play a campaign map together. MapID is optional;
+// the server picks the inviter's next uncleared campaign map.
+type CoopInvite struct {
+	To    string `json:"to"`
+	MapID string `json:"mapId,omitempty"`
+}
+
+// C->S: answer an invite from From.
+type CoopAccept struct {
+	From string `json:"from"`
+}
+type CoopDecline struct {
+	From string `json:"from"`
+}
+
+// S->C
+type CoopInvited struct {
+	From    string `json:"from"`
+	MapID   string `json:"mapId"`
+	MapName string `json:"mapName,omitempty"`
+}
+type CoopInviteSent struct {
+	To    string `json:"to"`
+	MapID string `json:"mapId"`
+}
+type CoopDeclined struct {
+	By string `json:"by"`
+}
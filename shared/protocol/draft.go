@@ -0,0 +1,45 @@
+package protocol
+
+// Draft mode: both players build their army by alternately banning and
+// picking cards from a shared pool before the match starts.
+
+// C->S
+type JoinDraftQueue struct{}
+type LeaveDraftQueue struct{}
+type DraftPick struct {
+	Name string `json:"name"`
+}
+type DraftBan struct {
+	Name string `json:"name"` // empty skips the ban
+}
+
+// S->C
+type DraftCard struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Class    string `json:"class"`
+	Cost     int    `json:"cost"`
+	Portrait string `json:"portrait,omitempty"`
+}
+
+// DraftState is sent after every step, from the receiver's point of view.
+// Action "done" means the armies are complete and the match is starting.
+type DraftState struct {
+	DraftID     string      `json:"draftId"`
+	Pool        []DraftCard `json:"pool"`
+	Banned      []string    `json:"banned"`
+	YourPicks   []string    `json:"yourPicks"`
+	OppPicks    []string    `json:"oppPicks"`
+	OppName     string      `json:"oppName"`
+	YourTurn    bool        `json:"yourTurn"`
+	Action      string      `json:"action"` // "ban" | "pick" | "done"
+	SecondsLeft int         `json:"secondsLeft"`
+	Step        int         `json:"step"` // 0-based index of the current step
+	Steps       int         `json:"steps"`
+}
+type DraftQueueStatus struct {
+	Queued bool `json:"queued"`
+}
+type DraftCancelled struct {
+	Reason string `json:"reason,omitempty"`
+}
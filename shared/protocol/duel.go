@@ -0,0 +1,31 @@
+package protocol
+
+// Direct duel invitations between friends or guildmates. Accepting opens
+// the same friendly lobby as joining a FriendlyCode.
+
+// C->S: invite an online friend or guildmate to a friendly duel.
+type InviteToDuel struct {
+	Friend string `json:"friend"`
+}
+
+// C->S: answer an invite from From.
+type DuelAccept struct {
+	From string `json:"from"`
+}
+type DuelDecline struct {
+	From string `json:"from"`
+}
+
+// S->C
+type DuelInvited struct {
+	From      string `json:"from"`
+	Via       string `json:"via"`       // "friend" | "guild"
+	ExpiresIn int    `json:"expiresIn"` // seconds
+}
+type DuelInviteSent struct {
+	To        string `json:"to"`
+	ExpiresIn int    `json:"expiresIn"`
+}
+type DuelDeclined struct {
+	By string `json:"by"`
+}
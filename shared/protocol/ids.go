@@ -14,3 +14,14 @@ func NewID() int64 {
 	rand.Read(b[:])
 	return (base << 16) | int64(binary.BigEndian.Uint16(b[:]))
 }
+
+// ReserveIDs makes NewID return only IDs above id, e.g. after restoring
+// objects an earlier process created.
+func ReserveIDs(id int64) {
+	for {
+		cur := atomic.LoadInt64(&seq)
+		if cur >= id>>16 || atomic.CompareAndSwapInt64(&seq, cur, id>>16) {
+			return
+		}
+	}
+}
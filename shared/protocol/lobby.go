@@ -0,0 +1,19 @@
+package protocol
+
+// Friendly duel lobby. After a friendly code is joined both players wait in
+// the room: the host edits the settings, both toggle SetReady and the match
+// starts once everyone is ready. State is sent as RoomStatus; after the
+// match the room returns to the lobby for a rematch.
+
+// C->S (host only, while no match is running). Changing the settings
+// clears both players' ready flags.
+type LobbySettings struct {
+	MapID     string `json:"mapId"`
+	TimeLimit int    `json:"timeLimit"`
+	Rules     string `json:"rules"`
+}
+
+// S->C: the lobby is gone (the other player left).
+type LobbyClosed struct {
+	Reason string `json:"reason,omitempty"`
+}
@@ -44,6 +44,13 @@ type DecorativeElement struct {
 	Layer  int     `json:"layer"`  // rendering layer (0=background, 1=middle, 2=foreground)
 }
 
+// BossSpawn places a boss (by ID from bosses.json) on the enemy side of a PvE map.
+type BossSpawn struct {
+	ID string  `json:"id"`
+	X  float64 `json:"x"` // normalized 0-1
+	Y  float64 `json:"y"` // normalized 0-1
+}
+
 // MapDef describes a PVE map layout for gameplay
 type MapDef struct {
 	ID     string `json:"id"`
@@ -52,6 +59,11 @@ type MapDef struct {
 	Height int    `json:"height"`       // background height in pixels (optional)
 	Bg     string `json:"bg,omitempty"` // optional background image path
 
+	// Battlefield size in world units (0 = DefaultWorldW/DefaultWorldH).
+	// Normalized positions below are relative to it; larger maps scroll.
+	WorldW int `json:"worldW,omitempty"`
+	WorldH int `json:"worldH,omitempty"`
+
 	// Background positioning and scaling
 	BgScale   float64 `json:"bgScale,omitempty"`   // background scale factor
 	BgOffsetX float64 `json:"bgOffsetX,omitempty"` // background X offset
@@ -63,11 +75,17 @@ type MapDef struct {
 	Lanes              []Lane              `json:"lanes"`
 	Obstacles          []Obstacle          `json:"obstacles"`
 	DecorativeElements []DecorativeElement `json:"decorativeElements,omitempty"`
+	Bosses             []BossSpawn         `json:"bosses,omitempty"` // PvE only
 
 	// Base positions for PvP (configurable per map)
 	PlayerBase PointF `json:"playerBase,omitempty"` // Player base position (normalized 0-1)
 	EnemyBase  PointF `json:"enemyBase,omitempty"`  // Enemy base position (normalized 0-1)
 
+	// Base slots for 2v2: TeamBases[0] is the player side, TeamBases[1] the
+	// enemy side, one normalized position per teammate. Sides without enough
+	// slots spread their bases around PlayerBase/EnemyBase instead.
+	TeamBases [][]PointF `json:"teamBases,omitempty"`
+
 	// Match timer configuration
 	TimeLimit int `json:"timeLimit,omitempty"` // Time limit in seconds (default 180 = 3:00)
 
@@ -75,6 +93,18 @@ type MapDef struct {
 	IsArena bool `json:"isArena,omitempty"` // Whether this is an arena map (auto-mirrors bottom to top)
 }
 
+// WorldSize returns the battlefield size in world units.
+func (m *MapDef) WorldSize() (w, h int) {
+	w, h = DefaultWorldW, DefaultWorldH
+	if m != nil && m.WorldW > 0 {
+		w = m.WorldW
+	}
+	if m != nil && m.WorldH > 0 {
+		h = m.WorldH
+	}
+	return w, h
+}
+
 // C->S
 type GetMap struct{ ID string }
 type SaveMap struct{ Def MapDef }
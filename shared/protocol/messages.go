@@ -89,11 +89,14 @@ type MiniCardView struct {
 
 type Init struct {
 	PlayerID  int64          `json:"playerId"`
-	MapWidth  int            `json:"mapWidth"`
-	MapHeight int            `json:"mapHeight"`
+	MapWidth  int            `json:"mapWidth"`  // world size in world units;
+	MapHeight int            `json:"mapHeight"` // unit/base positions use them
 	Hand      []MiniCardView `json:"hand"`
 	Next      MiniCardView   `json:"next"`
 	Tick      int64          `json:"tick"`
+	Endless   bool           `json:"endless,omitempty"` // survival: timer counts up, no time limit
+	Allies    []int64        `json:"allies,omitempty"`  // teammates' player IDs (co-op)
+	Rules     *Mutators      `json:"rules,omitempty"`   // non-default ruleset for this match
 }
 
 type GoldUpdate struct {
@@ -118,6 +121,11 @@ type UnitState struct {
 	Class    string  `json:"class"`
 	Range    int     `json:"range"`
 	Particle string  `json:"particle,omitempty"`
+	// Boss units report their current phase (0 = opening phase)
+	Boss      bool   `json:"boss,omitempty"`
+	Phase     int    `json:"phase,omitempty"`
+	PhaseName string `json:"phaseName,omitempty"`
+	Portrait  string `json:"portrait,omitempty"` // set for bosses, which are not in minis.json
 }
 
 type ProjectileState struct {
@@ -150,6 +158,10 @@ type StateDelta struct {
 	Projectiles  []ProjectileState `json:"projectiles,omitempty"`
 	Bases        []BaseState       `json:"bases,omitempty"`
 	Events       []string          `json:"events,omitempty"`
+	// Fog of war: units that came into or went out of the recipient's sight
+	// this tick. Units that left vision are not dead, just hidden.
+	EnteredVision []int64 `json:"enteredVision,omitempty"`
+	LeftVision    []int64 `json:"leftVision,omitempty"`
 }
 
 type HealingEvent struct {
@@ -225,6 +237,16 @@ type AoEDamageEvent struct {
 	ImpactY      float64 `json:"impactY"`      // Y position where the projectile impacted
 }
 
+type BossPhaseEvent struct {
+	UnitID    int64   `json:"unitId"`    // ID of the boss unit
+	BossName  string  `json:"bossName"`  // Name of the boss
+	Phase     int     `json:"phase"`     // 1-based phase that was entered
+	PhaseName string  `json:"phaseName"` // Display name of the phase
+	X         float64 `json:"x"`         // Boss position when the phase started
+	Y         float64 `json:"y"`         // Boss position when the phase started
+	AoERadius float64 `json:"aoeRadius"` // Radius of the phase-entry area ability (0 if none)
+}
+
 type FullSnapshot struct {
 	Tick  int64       `json:"tick"`
 	Units []UnitState `json:"units"`
@@ -239,6 +261,14 @@ type RoomStatus struct {
 	Players []int64        `json:"players"`
 	Ready   map[int64]bool `json:"ready"`
 	HostID  int64
+
+	// Friendly duel lobby: the host's settings and the maps to choose from
+	Names     map[int64]string `json:"names,omitempty"`
+	MapID     string           `json:"mapId,omitempty"`     // "" = random duel map
+	TimeLimit int              `json:"timeLimit,omitempty"` // seconds; 0 = the map's default
+	Rules     string           `json:"rules,omitempty"`     // mutator preset ID
+	Maps      []MapInfo        `json:"maps,omitempty"`
+	Played    int              `json:"played,omitempty"` // matches finished in this lobby
 }
 
 type JoinRoom struct {
@@ -271,7 +301,10 @@ type PvpMatched struct {
 }
 
 // Friendly duels
-type FriendlyCreate struct{} // client -> server
+type FriendlyCreate struct { // client -> server
+	Draft bool   `json:"draft,omitempty"` // pick armies in a draft instead of saved armies
+	Rules string `json:"rules,omitempty"` // mutator preset ID (see MutatorList)
+}
 type FriendlyCancel struct{} // client -> server
 type FriendlyJoin struct {
 	Code string `json:"code"`
@@ -0,0 +1,67 @@
+package protocol
+
+// Rule mutators: a ruleset that changes gameplay constants for one match.
+// Zero values keep the regular rules.
+type Mutators struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	GoldTickSec float64 `json:"goldTickSec,omitempty"` // seconds per gold (default GoldTickSec)
+	GoldMax     int     `json:"goldMax,omitempty"`     // gold cap (default GoldMax)
+	StartGold   int     `json:"startGold,omitempty"`   // default 4
+	BaseHP      int     `json:"baseHp,omitempty"`      // default 3000 (before level scaling)
+	SpeedMult   float64 `json:"speedMult,omitempty"`   // unit movement speed
+	DamageMult  float64 `json:"damageMult,omitempty"`  // unit damage
+
+	AllowedClasses []string `json:"allowedClasses,omitempty"` // e.g. ["melee"]; empty allows all
+	FixedArmy      []string `json:"fixedArmy,omitempty"`      // 7 cards used by both sides
+
+	FogOfWar bool `json:"fogOfWar,omitempty"` // each side only sees what its units and bases see
+}
+
+// Fog of war sight radii, in world units.
+const (
+	FogUnitSight = 220.0
+	FogBaseSight = 300.0
+)
+
+// ChallengeRecord is a player's best clear of a weekly challenge, persisted
+// in Profile.WeeklyBest. Only the current week counts on the leaderboard.
+type ChallengeRecord struct {
+	Week    string `json:"week"`    // ISO week, e.g. "2026-W42"
+	Seconds int    `json:"seconds"` // fastest win
+	At      int64  `json:"at"`      // Unix ms
+}
+
+// C->S
+type GetMutators struct{}
+type CreateChallenge struct{}
+type GetChallengeLeaderboard struct{}
+
+// S->C: the presets friendly duels can pick from, and this week's challenge.
+type MutatorList struct {
+	Presets []Mutators `json:"presets"`
+	Weekly  Mutators   `json:"weekly"`
+	Week    string     `json:"week"`
+}
+
+// S->C: sent when a weekly challenge match ends.
+type ChallengeResult struct {
+	Won     bool             `json:"won"`
+	Seconds int              `json:"seconds"`
+	NewBest bool             `json:"newBest"`
+	Best    *ChallengeRecord `json:"best,omitempty"`
+}
+
+type ChallengeLeaderboardEntry struct {
+	Name    string `json:"name"`
+	Seconds int    `json:"seconds"`
+}
+
+type ChallengeLeaderboard struct {
+	Week        string                      `json:"week"`
+	Rules       Mutators                    `json:"rules"`
+	Items       []ChallengeLeaderboardEntry `json:"items"`
+	GeneratedAt int64                       `json:"generated_at"`
+}
@@ -0,0 +1,57 @@
+package protocol
+
+// Parties: a leader and up to three friends who queue or watch together.
+// Parties live only in server memory.
+
+// C->S
+type PartyCreate struct{}
+type PartyInvite struct {
+	Name string `json:"name"`
+}
+type PartyAccept struct {
+	Leader string `json:"leader"`
+}
+type PartyDecline struct {
+	Leader string `json:"leader"`
+}
+type PartyLeave struct{}
+
+// PartyQueue is sent by the leader. Mode "team" queues the pair for ranked
+// 2v2, "coop" starts a co-op campaign map (MapID, "" = the leader's next
+// map) and "watch" toggles the watch party: idle members spectate whenever
+// another member's battle starts. Mode "" cancels the queue.
+type PartyQueue struct {
+	Mode  string `json:"mode"`
+	MapID string `json:"mapId,omitempty"`
+}
+
+// S->C
+type PartyMember struct {
+	Name   string `json:"name"`
+	Online bool   `json:"online"`
+}
+type PartyState struct {
+	Leader  string        `json:"leader"`
+	Members []PartyMember `json:"members"` // leader first
+	Queue   string        `json:"queue"`   // "" | "team" | "watch"
+	Invited []string      `json:"invited,omitempty"`
+	// Seconds left before the party dissolves while the leader is offline
+	LeaderGrace int `json:"leaderGrace,omitempty"`
+}
+type PartyInvited struct {
+	Leader    string `json:"leader"`
+	ExpiresIn int    `json:"expiresIn"`
+}
+type PartyDeclined struct {
+	By string `json:"by"`
+}
+type PartyClosed struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// SpectateStart precedes the Init/MapDef/FullSnapshot of a battle the client
+// joins as a spectator (watch party). Watching is the player whose view is shown.
+type SpectateStart struct {
+	RoomID   string `json:"roomId"`
+	Watching string `json:"watching"`
+}
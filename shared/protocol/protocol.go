@@ -1,18 +1,30 @@
 package protocol
 
 type Profile struct {
-	PlayerID  int64               `json:"playerId"`
-	Name      string              `json:"name"`
-	Army      []string            `json:"army"`             // active: [champion, 6 minis]
-	Armies    map[string][]string `json:"armies,omitempty"` // all saved armies: champ -> 6 minis
-	Gold      int                 `json:"gold"`
-	AccountXP int                 `json:"accountXp"`
-	UnitXP    map[string]int      `json:"unitXp,omitempty"` // per-mini XP, e.g. "Archer": 120
-	Resources map[string]int      `json:"resources,omitempty"`
-	PvPRating int                 `json:"pvp_rating"` // e.g. 1200 base
-	PvPRank   string              `json:"pvp_rank"`   // derived server-side
-	Avatar    string              `json:"avatar"`     // in game avatar
-	GuildID   string              `json:"guildId,omitempty"`
+	PlayerID  int64                     `json:"playerId"`
+	AccountID string                    `json:"accountId,omitempty"` // stable ID the profile is stored under
+	Name      string                    `json:"name"`
+	Army      []string                  `json:"army"`             // active: [champion, 6 minis]
+	Armies    map[string][]string       `json:"armies,omitempty"` // all saved armies: champ -> 6 minis
+	Gold      int                       `json:"gold"`
+	AccountXP int                       `json:"accountXp"`
+	UnitXP    map[string]int            `json:"unitXp,omitempty"` // per-mini XP, e.g. "Archer": 120
+	Resources map[string]int            `json:"resources,omitempty"`
+	PvPRating int                       `json:"pvp_rating"` // e.g. 1200 base
+	PvPRank   string                    `json:"pvp_rank"`   // derived server-side
+	Avatar    string                    `json:"avatar"`     // in game avatar
+	GuildID   string                    `json:"guildId,omitempty"`
+	Campaign  map[string]CampaignRecord `json:"campaign,omitempty"` // PvE map ID -> best result
+	// Best endless survival run
+	SurvivalBest *SurvivalRecord `json:"survivalBest,omitempty"`
+	// Best clear of the weekly challenge (reset by week)
+	WeeklyBest *ChallengeRecord `json:"weeklyBest,omitempty"`
+	// Ranked abandons: recent leaves and the queue cooldown they earned
+	LeaverCount   int   `json:"leaverCount,omitempty"`
+	LastLeftAt    int64 `json:"lastLeftAt,omitempty"`    // Unix ms
+	QueueBanUntil int64 `json:"queueBanUntil,omitempty"` // Unix ms
+	// Stored layout version; the server upgrades older profiles on load
+	SchemaVersion int `json:"schemaVersion,omitempty"`
 }
 
 // Existing messages stay the same:
@@ -5,7 +5,7 @@ type RatingUpdate struct {
     NewRating int    `json:"new_rating"`
     Delta     int    `json:"delta"`      // +/-
     Rank      string `json:"rank"`
-    OppName   string `json:"opp_name"`   // optional
-    OppRating int    `json:"opp_rating"` // optional
-    MatchType string `json:"match_type"` // "queue" | "friendly"
+    OppName   string `json:"opp_name"`   // optional ("A & B" in 2v2)
+    OppRating int    `json:"opp_rating"` // optional (team average in 2v2)
+    MatchType string `json:"match_type"` // "queue" | "team" | "friendly"
 }
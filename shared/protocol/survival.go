@@ -0,0 +1,46 @@
+package protocol
+
+// Endless survival (PvE)
+
+// SurvivalRecord is a player's best survival run, persisted in Profile.SurvivalBest.
+type SurvivalRecord struct {
+	Waves   int   `json:"waves"`   // waves fully survived
+	Seconds int   `json:"seconds"` // run length
+	Level   int   `json:"level"`   // army level the waves were scaled from
+	At      int64 `json:"at"`      // Unix ms
+}
+
+// C->S: start a survival run. MapID is optional.
+type CreateSurvival struct {
+	MapID string `json:"mapId,omitempty"`
+}
+
+// S->C: a new wave has been spawned.
+type SurvivalWave struct {
+	Wave   int `json:"wave"`
+	Units  int `json:"units"`
+	NextIn int `json:"nextIn"` // seconds until the following wave
+}
+
+// S->C: sent when the player's base falls (or they surrender).
+type SurvivalResult struct {
+	Waves   int             `json:"waves"`
+	Seconds int             `json:"seconds"`
+	NewBest bool            `json:"newBest"`
+	Best    *SurvivalRecord `json:"best,omitempty"`
+}
+
+type SurvivalLeaderboardEntry struct {
+	Name    string `json:"name"`
+	Waves   int    `json:"waves"`
+	Seconds int    `json:"seconds"`
+	Level   int    `json:"level"`
+}
+
+type SurvivalLeaderboard struct {
+	Items       []SurvivalLeaderboardEntry `json:"items"`
+	GeneratedAt int64                      `json:"generated_at"`
+}
+
+// Empty request for the survival board.
+type GetSurvivalLeaderboard struct{}
@@ -0,0 +1,29 @@
+package protocol
+
+// 2v2 team battles: a ranked queue and a friendly lobby for four players
+
+// C->S: ranked 2v2 queue
+type JoinTeamQueue struct{}
+type LeaveTeamQueue struct{}
+
+// C->S: friendly 2v2 lobby. The host gets a code; friends join a side with
+// it and the battle starts once both sides have two players.
+type TeamLobbyCreate struct{}
+type TeamLobbyLeave struct{}
+type TeamLobbyJoin struct {
+	Code string `json:"code"`
+	Team int    `json:"team"` // preferred side (0 = host's side); full sides fall back to the other
+}
+
+// S->C
+type TeamQueueStatus struct {
+	Queued  bool `json:"queued"`
+	Waiting int  `json:"waiting"` // players in the queue, including you
+}
+type TeamLobbyState struct {
+	Code  string      `json:"code"`
+	Teams [2][]string `json:"teams"` // member names per side, host first
+}
+type TeamLobbyClosed struct {
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,94 @@
+package protocol
+
+// Tournaments: a signup window, then a single or double elimination bracket
+// seeded by PvPRating. The same structs are persisted in data/tournaments.json
+// and served read-only at /api/tournaments.
+
+type TournamentPlayer struct {
+	Name   string `json:"name"`
+	Rating int    `json:"rating"`
+	Seed   int    `json:"seed,omitempty"` // 1 = top seed; set when the bracket is drawn
+}
+
+// TournamentMatch is one pairing. Empty A/B slots wait for the feeding
+// match; once nothing feeds a slot any more it is a bye.
+type TournamentMatch struct {
+	ID       int    `json:"id"`
+	Bracket  string `json:"bracket"` // "W" winners | "L" losers | "GF" grand final
+	Round    int    `json:"round"`
+	A        string `json:"a,omitempty"`
+	B        string `json:"b,omitempty"`
+	Winner   string `json:"winner,omitempty"`
+	Status   string `json:"status"`             // "waiting" | "ready" | "playing" | "done"
+	Walkover bool   `json:"walkover,omitempty"` // decided by a bye or a no-show
+	RoomID   string `json:"roomId,omitempty"`
+	ReadyAt  int64  `json:"readyAt,omitempty"` // Unix ms both players became known (no-show clock)
+
+	// Where the winner and loser go next (match ID, slot 0=A 1=B); -1 = out
+	WinnerTo   int `json:"winnerTo"`
+	WinnerSlot int `json:"winnerSlot"`
+	LoserTo    int `json:"loserTo"`
+	LoserSlot  int `json:"loserSlot"`
+}
+
+type Tournament struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Format     string             `json:"format"` // "single" | "double"
+	Status     string             `json:"status"` // "signup" | "running" | "finished" | "cancelled"
+	CreatedBy  string             `json:"createdBy"`
+	CreatedAt  int64              `json:"createdAt"`
+	SignupEnds int64              `json:"signupEnds"` // Unix ms
+	MaxPlayers int                `json:"maxPlayers"`
+	Players    []TournamentPlayer `json:"players"`
+	Matches    []TournamentMatch  `json:"matches,omitempty"`
+	Champion   string             `json:"champion,omitempty"`
+	EndedAt    int64              `json:"endedAt,omitempty"` // Unix ms; finished or cancelled
+}
+
+type TournamentSummary struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Format     string `json:"format"`
+	Status     string `json:"status"`
+	Players    int    `json:"players"`
+	MaxPlayers int    `json:"maxPlayers"`
+	SignupEnds int64  `json:"signupEnds"`
+	Champion   string `json:"champion,omitempty"`
+}
+
+// C->S
+type ListTournaments struct{}
+type GetTournament struct {
+	ID string `json:"id"`
+}
+type CreateTournament struct {
+	Name          string `json:"name"`
+	Format        string `json:"format"`        // "single" (default) | "double"
+	SignupMinutes int    `json:"signupMinutes"` // default 30
+	MaxPlayers    int    `json:"maxPlayers"`    // default 16
+}
+type JoinTournament struct {
+	ID string `json:"id"`
+}
+type LeaveTournament struct {
+	ID string `json:"id"`
+}
+
+// S->C
+type TournamentList struct {
+	Items []TournamentSummary `json:"items"`
+}
+type TournamentState struct {
+	Tournament Tournament `json:"tournament"`
+}
+
+// TournamentMatchReady tells a player their next match is up. The room is
+// created as soon as both players are online and idle; whoever has not
+// shown up within ExpiresIn seconds loses by walkover.
+type TournamentMatchReady struct {
+	TournamentID string `json:"tournamentId"`
+	MatchID      int    `json:"matchId"`
+	Opponent     string `json:"opponent"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
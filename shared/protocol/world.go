@@ -0,0 +1,46 @@
+package protocol
+
+// World maps: the clickable overview the client shows on the Map tab. The
+// server owns the definitions so new locations ship without a client release.
+
+// WorldRect is a hotspot's clickable area, normalized (0-1) to the background.
+type WorldRect struct {
+	Left   float64 `json:"left"`
+	Top    float64 `json:"top"`
+	Right  float64 `json:"right"`
+	Bottom float64 `json:"bottom"`
+}
+
+type WorldHotspot struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Info        string    `json:"info,omitempty"`
+	Rect        WorldRect `json:"rect"`
+	TargetMapID string    `json:"targetMapId"`        // map launched when the hotspot is picked
+	Requires    []string  `json:"requires,omitempty"` // map IDs that need a star first
+}
+
+// WorldDef is one world map: a background image and its hotspots.
+type WorldDef struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Background string         `json:"background,omitempty"` // assets/maps/<background>.png, "" = the world ID
+	Hotspots   []WorldHotspot `json:"hotspots"`
+}
+
+// C->S
+type GetWorld struct {
+	ID string `json:"id"`
+}
+type ListWorlds struct{}
+type SaveWorld struct {
+	Def WorldDef `json:"def"`
+}
+
+// S->C
+type WorldDefMsg struct {
+	Def WorldDef `json:"def"`
+}
+type Worlds struct {
+	Items []MapInfo `json:"items"`
+}